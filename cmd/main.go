@@ -4,7 +4,9 @@ import (
 	"log"
 
 	"github.com/azka-art/taskwise-backend/config"
+	"github.com/azka-art/taskwise-backend/repositories"
 	"github.com/azka-art/taskwise-backend/routes"
+	"github.com/azka-art/taskwise-backend/services"
 	"github.com/gin-gonic/gin"
 )
 
@@ -12,6 +14,26 @@ func main() {
 	// Connect to database
 	config.ConnectDatabase()
 
+	// Limit queries on organization data to the organization of the request
+	if err := repositories.RegisterTenantScope(config.DB); err != nil {
+		log.Fatalf("❌ Failed to register the tenant scope: %v", err)
+	}
+
+	// Create tables and columns for newer features
+	migrateDatabase()
+
+	// Emails are refused rather than logged unless MAIL_DRIVER=log is set
+	if err := services.CheckMailer(); err != nil {
+		log.Printf("⚠️ No emails will be sent: %v (set SMTP_HOST, or MAIL_DRIVER=log in development)", err)
+	}
+
+	// Start background workers
+	services.StartWebhookWorker()
+	services.StartEventListener()
+	services.StartEscalationWorker()
+	services.StartSLAWorker()
+	services.StartTokenCleanupWorker()
+
 	// Initialize Gin router
 	r := gin.Default()
 
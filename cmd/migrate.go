@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/azka-art/taskwise-backend/config"
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"gorm.io/gorm"
+)
+
+// migrateDatabase creates the tables and columns added on top of the initial schema.
+// Existing tables are only extended column by column, because their enum column
+// definitions can't be auto-migrated on PostgreSQL.
+func migrateDatabase() {
+	if err := migrationDB().AutoMigrate(
+		&models.Organization{},
+		&models.OrganizationMember{},
+		&models.Project{},
+		&models.ProjectMember{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.Event{},
+		&models.TaskLabel{},
+		&models.CalendarToken{},
+		&models.SavedView{},
+		&models.EscalationPolicy{},
+		&models.TaskEscalation{},
+		&models.BusinessHours{},
+		&models.SLAPolicy{},
+		&models.TaskSLA{},
+		&models.CommentReaction{},
+		&models.CommentRevision{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.UserTokenRevocation{},
+		&models.PasswordResetToken{},
+		&models.EmailVerificationToken{},
+		&models.UserMFA{},
+		&models.MFARecoveryCode{},
+		&models.UserIdentity{},
+		&models.OIDCLoginState{},
+		&models.APIKey{},
+		&models.Invitation{},
+	); err != nil {
+		log.Fatalf("❌ Database migration failed: %v", err)
+	}
+
+	addMissingColumns(&models.Task{}, "ProjectID", "AssigneeID", "Rank", "DuplicatedFrom", "OrganizationID")
+	addMissingColumns(&models.Comment{}, "ParentID", "EditedAt")
+	addEmailVerification()
+	addOrganizations()
+	moveMFARequirement()
+	rankExistingTasks()
+
+	log.Println("✅ Database migrated successfully!")
+}
+
+// migrationDB is the database for schema changes, which run as raw SQL and so
+// have to see every organization
+func migrationDB() *gorm.DB {
+	return config.DB.WithContext(repositories.AllOrganizations(context.Background()))
+}
+
+// addMissingColumns adds the given model fields to an existing table if they don't exist yet
+func addMissingColumns(model interface{}, fields ...string) {
+	migrator := migrationDB().Migrator()
+	for _, field := range fields {
+		if migrator.HasColumn(model, field) {
+			continue
+		}
+		if err := migrator.AddColumn(model, field); err != nil {
+			log.Fatalf("❌ Failed to add column %s: %v", field, err)
+		}
+	}
+}
+
+// addEmailVerification adds the email verification column to users. Accounts that
+// existed before emails were verified count as verified.
+func addEmailVerification() {
+	if migrationDB().Migrator().HasColumn(&models.User{}, "EmailVerifiedAt") {
+		return
+	}
+
+	addMissingColumns(&models.User{}, "EmailVerifiedAt")
+	if err := repositories.MarkExistingUsersVerified(repositories.AllOrganizations(context.Background())); err != nil {
+		log.Fatalf("❌ Failed to mark existing users as verified: %v", err)
+	}
+}
+
+// addOrganizations moves the users and data that existed before organizations
+// into a default organization, keeping the role of every user
+func addOrganizations() {
+	ctx := repositories.AllOrganizations(context.Background())
+	exists, err := repositories.HasOrganizations(ctx)
+	if err != nil {
+		log.Fatalf("❌ Failed to check organizations: %v", err)
+	}
+	if exists {
+		return
+	}
+
+	var users int64
+	if err := config.DB.WithContext(ctx).Model(&models.User{}).Count(&users).Error; err != nil {
+		log.Fatalf("❌ Failed to count users: %v", err)
+	}
+	if users == 0 {
+		return
+	}
+
+	organization := models.Organization{Name: models.DefaultOrganizationName}
+	if err := config.DB.WithContext(ctx).Create(&organization).Error; err != nil {
+		log.Fatalf("❌ Failed to create the default organization: %v", err)
+	}
+	if err := repositories.MoveExistingDataToOrganization(ctx, organization.ID); err != nil {
+		log.Fatalf("❌ Failed to move existing data into the default organization: %v", err)
+	}
+	log.Printf("✅ Moved %d existing users into the %q organization", users, organization.Name)
+}
+
+// moveMFARequirement moves the 2FA requirement of users, which applied to all of
+// their organizations, to each of their memberships
+func moveMFARequirement() {
+	migrator := migrationDB().Migrator()
+	if !migrator.HasColumn(&models.User{}, "mfa_required") {
+		return
+	}
+
+	if err := repositories.MoveMFARequirementToMembers(repositories.AllOrganizations(context.Background())); err != nil {
+		log.Fatalf("❌ Failed to move the 2FA requirement to organization members: %v", err)
+	}
+	if err := migrator.DropColumn(&models.User{}, "mfa_required"); err != nil {
+		log.Fatalf("❌ Failed to drop column mfa_required: %v", err)
+	}
+}
+
+// rankExistingTasks gives tasks created before manual ordering a rank, keeping
+// them in the order they were created
+func rankExistingTasks() {
+	columns, err := repositories.GetUnrankedTaskColumns(repositories.AllOrganizations(context.Background()))
+	if err != nil {
+		log.Fatalf("❌ Failed to find unranked tasks: %v", err)
+	}
+
+	for _, column := range columns {
+		ctx := repositories.WithOrganization(context.Background(), column.OrganizationID)
+		if err := repositories.RebalanceTaskRanks(ctx, column.ProjectID, column.Status); err != nil {
+			log.Fatalf("❌ Failed to rank tasks: %v", err)
+		}
+	}
+}
@@ -15,6 +15,9 @@ import (
 // DB is the global database connection instance
 var DB *gorm.DB
 
+// DSN is the connection string of DB, for connections GORM can't provide (e.g. LISTEN)
+var DSN string
+
 func ConnectDatabase() {
 	// ✅ Force reload the latest `.env`
 	err := godotenv.Overload()
@@ -81,5 +84,6 @@ func ConnectDatabase() {
 
 	// Assign database connection to global variable
 	DB = database
+	DSN = dsn
 	log.Println("✅ Database connection established successfully! 🚀")
 }
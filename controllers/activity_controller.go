@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetProjectActivity returns the activity feed of a project, newest first
+func GetProjectActivity(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	filter, err := parseActivityFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+	page, size := parsePagination(c)
+
+	entries, total, err := services.GetProjectActivity(c.Request.Context(), projectID, userID, currentUserRole(c), filter, page, size)
+	if err != nil {
+		respondServiceError(c, err, "Failed to fetch project activity")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  entries,
+		"total": total,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+// parseActivityFilter reads the activity feed filters from the query string
+func parseActivityFilter(c *gin.Context) (models.ActivityFilter, error) {
+	var filter models.ActivityFilter
+
+	for _, eventType := range strings.Split(c.Query("type"), ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			filter.Types = append(filter.Types, models.EventType(eventType))
+		}
+	}
+
+	if value := c.Query("actor_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return filter, errors.New("invalid actor_id")
+		}
+		filter.ActorID = &id
+	}
+
+	if value := c.Query("task_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return filter, errors.New("invalid task_id")
+		}
+		filter.TaskID = &id
+	}
+
+	if value := c.Query("since"); value != "" {
+		since, err := parseActivityTime(value)
+		if err != nil {
+			return filter, errors.New("invalid since, use a date or an RFC 3339 time")
+		}
+		filter.Since = &since
+	}
+
+	if value := c.Query("until"); value != "" {
+		until, err := parseActivityTime(value)
+		if err != nil {
+			return filter, errors.New("invalid until, use a date or an RFC 3339 time")
+		}
+		filter.Until = &until
+	}
+
+	return filter, nil
+}
+
+// parseActivityTime accepts an RFC 3339 time or a date, which means midnight UTC
+func parseActivityTime(value string) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetBurndown returns the open and done task counts of a project per day.
+// The optional from and to query parameters (YYYY-MM-DD) default to the last 30 days.
+func GetBurndown(c *gin.Context) {
+	projectID, r, ok := parseAnalyticsParams(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	points, err := services.GetBurndown(c.Request.Context(), projectID, userID, currentUserRole(c), r)
+	if err != nil {
+		respondServiceError(c, err, "Failed to compute burndown")
+		return
+	}
+
+	c.JSON(http.StatusOK, points)
+}
+
+// GetCumulativeFlow returns the task counts of a project per status and day
+func GetCumulativeFlow(c *gin.Context) {
+	projectID, r, ok := parseAnalyticsParams(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	points, err := services.GetCumulativeFlow(c.Request.Context(), projectID, userID, currentUserRole(c), r)
+	if err != nil {
+		respondServiceError(c, err, "Failed to compute cumulative flow")
+		return
+	}
+
+	c.JSON(http.StatusOK, points)
+}
+
+// GetFlowTimes returns the average cycle and lead time of the tasks completed in the range
+func GetFlowTimes(c *gin.Context) {
+	projectID, r, ok := parseAnalyticsParams(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	report, err := services.GetFlowTimes(c.Request.Context(), projectID, userID, currentUserRole(c), r)
+	if err != nil {
+		respondServiceError(c, err, "Failed to compute cycle and lead time")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// parseAnalyticsParams reads the project ID and date range of an analytics request,
+// responding with 400 if either is invalid
+func parseAnalyticsParams(c *gin.Context) (uuid.UUID, services.AnalyticsRange, bool) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return uuid.Nil, services.AnalyticsRange{}, false
+	}
+
+	r, err := services.NewAnalyticsRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		respondServiceError(c, err, "Invalid date range")
+		return uuid.Nil, services.AnalyticsRange{}, false
+	}
+
+	return projectID, r, true
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateAPIKey creates an API key for the current user. The key is only returned in this response.
+func CreateAPIKey(c *gin.Context) {
+	var req models.APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	apiKey, err := services.CreateAPIKey(c.Request.Context(), userID, req)
+	if err != nil {
+		respondServiceError(c, err, "Failed to create API key")
+		return
+	}
+
+	c.JSON(http.StatusCreated, apiKey)
+}
+
+// GetAPIKeys lists the API keys of the current user
+func GetAPIKeys(c *gin.Context) {
+	userID, _ := currentUserID(c)
+
+	apiKeys, err := services.GetAPIKeys(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, apiKeys)
+}
+
+// RevokeAPIKey disables an API key of the current user
+func RevokeAPIKey(c *gin.Context) {
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	if err := services.RevokeAPIKey(c.Request.Context(), userID, keyID); err != nil {
+		respondServiceError(c, err, "Failed to revoke API key")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
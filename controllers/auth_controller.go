@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 
 	"github.com/azka-art/taskwise-backend/config"
@@ -11,13 +13,17 @@ import (
 
 // RegisterUser handles user registration
 func RegisterUser(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var req models.RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	createdUser, err := services.RegisterUser(user)
+	createdUser, err := services.RegisterUser(c.Request.Context(), req)
+	if errors.Is(err, services.ErrRegistrationClosed) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Registration is by invitation only"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
 		return
@@ -37,30 +43,206 @@ func LoginUser(c *gin.Context) {
 		return
 	}
 
-	token, user, err := services.LoginUser(credentials)
+	result, user, err := services.LoginUser(c.Request.Context(), credentials)
+	if errors.Is(err, services.ErrEmailNotVerified) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Email address is not verified"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
-	// Return token and user data (excluding password)
-	c.JSON(http.StatusOK, gin.H{
-		"token": token,
+	// The login is completed with a code at /api/login/mfa
+	if result.MFARequired {
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required":   true,
+			"mfa_token":      result.MFAToken,
+			"mfa_expires_at": result.MFAExpiresAt,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, loginResponse(result.Tokens, user))
+}
+
+// loginResponse returns the tokens and user data (excluding password) of a completed login
+func loginResponse(tokens models.AuthTokens, user models.User) gin.H {
+	return gin.H{
+		"token":              tokens.AccessToken, // Same as access_token, kept for older clients
+		"access_token":       tokens.AccessToken,
+		"access_expires_at":  tokens.AccessExpiresAt,
+		"refresh_token":      tokens.RefreshToken,
+		"refresh_expires_at": tokens.RefreshExpiresAt,
 		"user": gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-			"role":     user.Role,
+			"id":             user.ID,
+			"username":       user.Username,
+			"email":          user.Email,
+			"role":           tokens.Role, // Role in the organization of the tokens
+			"email_verified": user.IsEmailVerified(),
 		},
-	})
+		"organization_id": tokens.OrganizationID,
+	}
+}
+
+// RefreshToken exchanges a refresh token for a new access and refresh token
+func RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := services.RefreshTokens(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+			return
+		}
+		if errors.Is(err, services.ErrEmailNotVerified) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Email address is not verified"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// VerifyEmail verifies an email address with the token from a verification link
+func VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	if err := services.VerifyEmail(c.Request.Context(), token); err != nil {
+		if errors.Is(err, services.ErrInvalidVerificationToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email address"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email address verified"})
+}
+
+// ResendVerificationEmail emails a new verification link. It answers the same
+// whether or not an unverified account with the email exists.
+func ResendVerificationEmail(c *gin.Context) {
+	var req models.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.ResendVerificationEmail(c.Request.Context(), req.Email); err != nil {
+		if errors.Is(err, services.ErrAppURLNotConfigured) || errors.Is(err, services.ErrMailNotConfigured) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Verification emails are not configured"})
+			return
+		}
+		log.Printf("❌ Failed to resend verification email: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If an unverified account with this email exists, a verification link has been sent"})
 }
 
-// GetUsers retrieves all users (only accessible with JWT)
+// ForgotPassword emails a password reset link. It answers the same whether or not
+// an account with the email exists.
+func ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.RequestPasswordReset(c.Request.Context(), req.Email); err != nil {
+		// Checked before looking up the account, so it doesn't tell whether one exists
+		if errors.Is(err, services.ErrAppURLNotConfigured) || errors.Is(err, services.ErrMailNotConfigured) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Password reset emails are not configured"})
+			return
+		}
+		log.Printf("❌ Failed to start password reset: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If an account with this email exists, a password reset link has been sent"})
+}
+
+// ResetPassword sets a new password with the token from a reset link
+func ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired password reset token"})
+			return
+		}
+		respondServiceError(c, err, "Failed to reset password")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset, please log in again"})
+}
+
+// Logout revokes the access token of the request. A refresh token in the body
+// is revoked together with the tokens it was rotated from and into.
+func Logout(c *gin.Context) {
+	claims, ok := currentTokenClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.LogoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := services.Logout(c.Request.Context(), claims, req.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// LogoutAll revokes every access and refresh token of the current user
+func LogoutAll(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := services.LogoutAll(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out of all sessions"})
+}
+
+// GetUsers retrieves the users of the current organization with their role in it
 func GetUsers(c *gin.Context) {
 	var users []models.User
+	claims, _ := currentTokenClaims(c)
 
-	// Query all users from the database
-	if err := config.DB.Select("id, username, email, role, created_at, updated_at").Find(&users).Error; err != nil {
+	// Query the organization's users from the database
+	err := config.DB.WithContext(c.Request.Context()).
+		Select("users.id, users.username, users.email, organization_members.role, users.created_at, users.updated_at").
+		Joins("JOIN organization_members ON organization_members.user_id = users.id AND organization_members.organization_id = ?", claims.OrganizationID).
+		Find(&users).Error
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
 		return
 	}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetCalendarFeed serves the iCalendar feed of a calendar token (no JWT required)
+func GetCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	feed, err := services.GetCalendarFeed(c.Request.Context(), token)
+	if err != nil {
+		respondServiceError(c, err, "Failed to build calendar feed")
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(feed))
+}
+
+// CreateCalendarToken creates a calendar feed for the current user
+func CreateCalendarToken(c *gin.Context) {
+	var req models.CalendarTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	token, err := services.CreateCalendarToken(c.Request.Context(), userID, currentUserRole(c), req)
+	if err != nil {
+		respondServiceError(c, err, "Failed to create calendar feed")
+		return
+	}
+
+	token.URL = absoluteURL(c, token.URL)
+	c.JSON(http.StatusCreated, token)
+}
+
+// GetCalendarTokens lists the calendar feeds of the current user
+func GetCalendarTokens(c *gin.Context) {
+	userID, _ := currentUserID(c)
+
+	tokens, err := services.GetCalendarTokens(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calendar feeds"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RegenerateCalendarToken replaces the URL of a calendar feed
+func RegenerateCalendarToken(c *gin.Context) {
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid calendar feed ID"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	token, err := services.RegenerateCalendarToken(c.Request.Context(), userID, tokenID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to regenerate calendar feed")
+		return
+	}
+
+	token.URL = absoluteURL(c, token.URL)
+	c.JSON(http.StatusOK, token)
+}
+
+// RevokeCalendarToken disables a calendar feed
+func RevokeCalendarToken(c *gin.Context) {
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid calendar feed ID"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	if err := services.RevokeCalendarToken(c.Request.Context(), userID, tokenID); err != nil {
+		respondServiceError(c, err, "Failed to revoke calendar feed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar feed revoked successfully"})
+}
+
+// absoluteURL turns a path into a URL on the host the request was sent to
+func absoluteURL(c *gin.Context, path string) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host + path
+}
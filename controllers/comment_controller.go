@@ -12,7 +12,7 @@ import (
 // AddComment adds a comment to a task
 func AddComment(c *gin.Context) {
 	// ✅ Convert TaskID from string to uuid.UUID
-	taskID, err := uuid.Parse(c.Param("task_id"))
+	taskID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
 		return
@@ -42,29 +42,179 @@ func AddComment(c *gin.Context) {
 	comment.TaskID = taskID
 	comment.UserID = userUUID
 
-	newComment, err := services.CreateComment(comment)
+	newComment, err := services.CreateComment(c.Request.Context(), comment, currentUserRole(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add comment"})
+		respondServiceError(c, err, "Failed to add comment")
 		return
 	}
 
-	c.JSON(http.StatusCreated, newComment)
+	c.JSON(http.StatusCreated, newComment.ToResponse())
 }
 
-// GetComments retrieves all comments for a task
+// GetComments retrieves the comment threads of a task
 func GetComments(c *gin.Context) {
 	// ✅ Convert TaskID from string to uuid.UUID
-	taskID, err := uuid.Parse(c.Param("task_id"))
+	taskID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
 		return
 	}
 
-	comments, err := services.GetCommentsByTaskID(taskID)
+	userID, _ := currentUserID(c)
+	page, size := parsePagination(c)
+
+	comments, total, err := services.GetCommentsByTaskID(c.Request.Context(), taskID, userID, currentUserRole(c), page, size)
+	if err != nil {
+		respondServiceError(c, err, "Failed to fetch comments")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  models.CommentResponses(comments),
+		"total": total,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+// UpdateComment changes the content of a comment
+func UpdateComment(c *gin.Context) {
+	taskID, commentID, ok := parseCommentParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	comment, err := services.UpdateComment(c.Request.Context(), taskID, commentID, userID, currentUserRole(c), req.Content)
+	if err != nil {
+		respondServiceError(c, err, "Failed to update comment")
+		return
+	}
+
+	c.JSON(http.StatusOK, comment.ToResponse())
+}
+
+// GetCommentRevisions lists the previous contents of a comment
+func GetCommentRevisions(c *gin.Context) {
+	taskID, commentID, ok := parseCommentParams(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	revisions, err := services.GetCommentRevisions(c.Request.Context(), taskID, commentID, userID, currentUserRole(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		respondServiceError(c, err, "Failed to fetch comment revisions")
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// DeleteComment removes a comment and its replies
+func DeleteComment(c *gin.Context) {
+	taskID, commentID, ok := parseCommentParams(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, comments)
+	userID, _ := currentUserID(c)
+
+	if err := services.DeleteComment(c.Request.Context(), taskID, commentID, userID, currentUserRole(c)); err != nil {
+		respondServiceError(c, err, "Failed to delete comment")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+// ReplyToComment adds a reply to a comment
+func ReplyToComment(c *gin.Context) {
+	taskID, commentID, ok := parseCommentParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	reply, err := services.ReplyToComment(c.Request.Context(), taskID, commentID, userID, currentUserRole(c), req.Content)
+	if err != nil {
+		respondServiceError(c, err, "Failed to add reply")
+		return
+	}
+
+	c.JSON(http.StatusCreated, reply.ToResponse())
+}
+
+// AddReaction adds the user's emoji reaction to a comment
+func AddReaction(c *gin.Context) {
+	taskID, commentID, ok := parseCommentParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.ReactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	comment, err := services.AddReaction(c.Request.Context(), taskID, commentID, userID, currentUserRole(c), req.Emoji)
+	if err != nil {
+		respondServiceError(c, err, "Failed to add reaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, comment.ToResponse())
+}
+
+// RemoveReaction removes the user's emoji reaction from a comment
+func RemoveReaction(c *gin.Context) {
+	taskID, commentID, ok := parseCommentParams(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	comment, err := services.RemoveReaction(c.Request.Context(), taskID, commentID, userID, currentUserRole(c), c.Param("emoji"))
+	if err != nil {
+		respondServiceError(c, err, "Failed to remove reaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, comment.ToResponse())
+}
+
+// parseCommentParams reads the task and comment IDs from the URL
+func parseCommentParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	commentID, err := uuid.Parse(c.Param("comment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID format"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return taskID, commentID, true
 }
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// currentUserID returns the authenticated user's ID set by the JWT middleware
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	value, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, false
+	}
+
+	userID, ok := value.(uuid.UUID)
+	return userID, ok
+}
+
+// currentUserRole returns the authenticated user's role set by the JWT middleware
+func currentUserRole(c *gin.Context) models.UserRole {
+	value, _ := c.Get("role")
+	role, _ := value.(models.UserRole)
+	return role
+}
+
+// currentTokenClaims returns the claims of the access token set by the JWT middleware
+func currentTokenClaims(c *gin.Context) (models.AccessClaims, bool) {
+	value, exists := c.Get("token_claims")
+	if !exists {
+		return models.AccessClaims{}, false
+	}
+
+	claims, ok := value.(models.AccessClaims)
+	return claims, ok
+}
+
+// respondServiceError writes the HTTP status matching a well-known service error,
+// falling back to a 500 with the given message for anything else
+func respondServiceError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to perform this action"})
+	case errors.Is(err, services.ErrNotOrganizationMember):
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this organization"})
+	case errors.Is(err, services.ErrAppURLNotConfigured):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Links in emails are not configured, set APP_URL"})
+	case errors.Is(err, services.ErrMailNotConfigured):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Sending emails is not configured"})
+	case errors.Is(err, services.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrProjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+	case errors.Is(err, services.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+	case errors.Is(err, services.ErrCalendarTokenNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Calendar feed not found"})
+	case errors.Is(err, services.ErrCommentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+	case errors.Is(err, services.ErrEscalationPolicyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Escalation policy not found"})
+	case errors.Is(err, services.ErrInvitationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+	case errors.Is(err, services.ErrOrganizationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+	case errors.Is(err, services.ErrSLANotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "SLA not found"})
+	case errors.Is(err, services.ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, services.ErrViewNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "View not found"})
+	case errors.Is(err, services.ErrWebhookNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// parsePagination reads the page and size query parameters with sane defaults
+func parsePagination(c *gin.Context) (int, int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	size, err := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if err != nil || size < 1 {
+		size = 20
+	}
+	if size > 100 {
+		size = 100
+	}
+
+	return page, size
+}
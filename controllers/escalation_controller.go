@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateEscalationPolicy adds an escalation policy to a project
+func CreateEscalationPolicy(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	var req models.EscalationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	policy, err := services.CreateEscalationPolicy(c.Request.Context(), projectID, userID, currentUserRole(c), req)
+	if err != nil {
+		respondServiceError(c, err, "Failed to create escalation policy")
+		return
+	}
+
+	c.JSON(http.StatusCreated, policy)
+}
+
+// GetEscalationPolicies lists the escalation policies of a project
+func GetEscalationPolicies(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	policies, err := services.GetEscalationPolicies(c.Request.Context(), projectID, userID, currentUserRole(c))
+	if err != nil {
+		respondServiceError(c, err, "Failed to fetch escalation policies")
+		return
+	}
+
+	c.JSON(http.StatusOK, policies)
+}
+
+// UpdateEscalationPolicy changes an escalation policy
+func UpdateEscalationPolicy(c *gin.Context) {
+	projectID, policyID, ok := parseEscalationPolicyParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.EscalationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	policy, err := services.UpdateEscalationPolicy(c.Request.Context(), projectID, policyID, userID, currentUserRole(c), req)
+	if err != nil {
+		respondServiceError(c, err, "Failed to update escalation policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeleteEscalationPolicy removes an escalation policy
+func DeleteEscalationPolicy(c *gin.Context) {
+	projectID, policyID, ok := parseEscalationPolicyParams(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	if err := services.DeleteEscalationPolicy(c.Request.Context(), projectID, policyID, userID, currentUserRole(c)); err != nil {
+		respondServiceError(c, err, "Failed to delete escalation policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Escalation policy deleted successfully"})
+}
+
+// parseEscalationPolicyParams reads the project and policy IDs from the URL
+func parseEscalationPolicyParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	policyID, err := uuid.Parse(c.Param("policy_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid escalation policy ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return projectID, policyID, true
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ImportExternalTasks imports a Trello board JSON export or a Jira CSV/XML export
+// into a project. The form fields are: file (the export), source (trello or jira,
+// detected from the file when empty) and dry_run (true to only preview).
+func ImportExternalTasks(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "An export file is required in the \"file\" field"})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read the uploaded file"})
+		return
+	}
+	defer f.Close()
+
+	userID, _ := currentUserID(c)
+	opts := services.ExternalImportOptions{
+		UserID:    userID,
+		ProjectID: projectID,
+		Source:    models.ImportSource(strings.ToLower(c.PostForm("source"))),
+		DryRun:    c.PostForm("dry_run") == "true",
+	}
+
+	if opts.Source == "" {
+		// Trello only exports JSON, Jira exports CSV or XML
+		head := make([]byte, 512)
+		n, _ := f.Read(head)
+		if trimmed := bytes.TrimSpace(head[:n]); len(trimmed) > 0 && trimmed[0] == '{' {
+			opts.Source = models.ImportSourceTrello
+		} else {
+			opts.Source = models.ImportSourceJira
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read the uploaded file"})
+			return
+		}
+	}
+
+	report, err := services.ImportExternalTasks(c.Request.Context(), f, opts)
+	if err != nil {
+		respondServiceError(c, err, "Failed to import tasks")
+		return
+	}
+
+	if report.DryRun {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	c.JSON(http.StatusCreated, report)
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateInvitation invites someone by email into the current organization or one of its projects
+func CreateInvitation(c *gin.Context) {
+	var req models.InvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	invitation, err := services.CreateInvitation(c.Request.Context(), userID, currentUserRole(c), req)
+	if err != nil {
+		respondServiceError(c, err, "Failed to create invitation")
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// GetInvitations lists the pending invitations, optionally of one project (?project_id=)
+func GetInvitations(c *gin.Context) {
+	var projectID *uuid.UUID
+	if value := c.Query("project_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+			return
+		}
+		projectID = &id
+	}
+
+	userID, _ := currentUserID(c)
+
+	invitations, err := services.GetInvitations(c.Request.Context(), userID, currentUserRole(c), projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invitations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// RevokeInvitation withdraws a pending invitation
+func RevokeInvitation(c *gin.Context) {
+	invitationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	if err := services.RevokeInvitation(c.Request.Context(), userID, currentUserRole(c), invitationID); err != nil {
+		respondServiceError(c, err, "Failed to revoke invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation revoked"})
+}
+
+// AcceptInvitation accepts an invitation with the token from its link, registering
+// an account for the invited email if there is none yet
+func AcceptInvitation(c *gin.Context) {
+	var req models.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	acceptance, err := services.AcceptInvitation(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInvitation) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired invitation"})
+			return
+		}
+		respondServiceError(c, err, "Failed to accept invitation")
+		return
+	}
+
+	status := http.StatusOK
+	if acceptance.Created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, acceptance)
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CompleteMFALogin finishes a login with the mfa token and a TOTP or recovery code
+func CompleteMFALogin(c *gin.Context) {
+	var req models.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, user, err := services.CompleteMFALogin(c.Request.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidMFAToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token, please log in again"})
+		case errors.Is(err, services.ErrInvalidMFACode):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		case errors.Is(err, services.ErrMFALocked):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many wrong codes, please try again later"})
+		case errors.Is(err, services.ErrEmailNotVerified):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Email address is not verified"})
+		default:
+			respondServiceError(c, err, "Failed to log in")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, loginResponse(tokens, user))
+}
+
+// GetMFAStatus tells whether the current user has two-factor authentication
+func GetMFAStatus(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	status, err := services.GetMFAStatus(c.Request.Context(), userID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to fetch two-factor authentication status")
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// EnrollMFA starts setting up two-factor authentication. The otpauth_url is shown
+// as a QR code for the authenticator app.
+func EnrollMFA(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	enrollment, err := services.EnrollMFA(c.Request.Context(), userID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to set up two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmMFA enables two-factor authentication with a code from the authenticator app
+func ConfirmMFA(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := services.ConfirmMFA(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		respondMFAError(c, err, "Failed to enable two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes, // Only shown now
+	})
+}
+
+// DisableMFA turns two-factor authentication off
+func DisableMFA(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.DisableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.DisableMFA(c.Request.Context(), userID, req); err != nil {
+		respondMFAError(c, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the current user
+func RegenerateRecoveryCodes(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := services.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		respondMFAError(c, err, "Failed to generate recovery codes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// SetMFARequired lets an admin require a member of the current organization to use two-factor authentication
+func SetMFARequired(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.RequireMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.SetMFARequired(c.Request.Context(), userID, currentUserRole(c), req.Required); err != nil {
+		respondServiceError(c, err, "Failed to update user")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "mfa_required": req.Required})
+}
+
+// respondMFAError reports wrong codes as bad requests, and other errors like respondServiceError
+func respondMFAError(c *gin.Context, err error, message string) {
+	if errors.Is(err, services.ErrInvalidMFACode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+	if errors.Is(err, services.ErrMFALocked) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many wrong codes, please try again later"})
+		return
+	}
+	respondServiceError(c, err, message)
+}
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	oidcAppCallbackPath = "/sso/callback"   // Page of the web app single sign-on ends on
+	oidcStateCookie     = "oidc_state"      // Ties a sign-in to the browser that started it
+	oidcCookiePath      = "/api/auth/oidc/" // The cookie is only sent to the sign-in endpoints
+)
+
+// GetOIDCProviders lists the single sign-on providers, e.g. for login buttons
+func GetOIDCProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": services.GetOIDCProviders(absoluteURL(c, ""))})
+}
+
+// StartOIDCLogin sends the browser to the provider's sign-in page
+func StartOIDCLogin(c *gin.Context) {
+	authURL, state, err := services.StartOIDCLogin(c.Request.Context(), c.Param("provider"), absoluteURL(c, ""))
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	setOIDCStateCookie(c, state, int(services.OIDCLoginTTL().Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback completes single sign-on when the provider sends the browser back.
+// With APP_URL set, the browser is sent on to the app's /sso/callback page with
+// the login response in the URL fragment; otherwise the response is returned as JSON.
+func OIDCCallback(c *gin.Context) {
+	if providerError := c.Query("error"); providerError != "" {
+		respondOIDCCallback(c, http.StatusUnauthorized, gin.H{"error": "Sign-in was cancelled or refused: " + providerError})
+		return
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		respondOIDCCallback(c, http.StatusBadRequest, gin.H{"error": "code and state are required"})
+		return
+	}
+
+	// The state works once either way, so the cookie is no longer needed
+	browserState, _ := c.Cookie(oidcStateCookie)
+	setOIDCStateCookie(c, "", -1)
+
+	result, user, err := services.CompleteOIDCLogin(c.Request.Context(), c.Param("provider"), code, state, browserState, absoluteURL(c, ""))
+	if err != nil {
+		status, message := oidcErrorResponse(err)
+		respondOIDCCallback(c, status, gin.H{"error": message})
+		return
+	}
+
+	if result.MFARequired {
+		respondOIDCCallback(c, http.StatusOK, gin.H{
+			"mfa_required":   true,
+			"mfa_token":      result.MFAToken,
+			"mfa_expires_at": result.MFAExpiresAt,
+		})
+		return
+	}
+	respondOIDCCallback(c, http.StatusOK, loginResponse(result.Tokens, user))
+}
+
+// setOIDCStateCookie stores the state of a sign-in in the browser, or removes it
+// with a negative maxAge. SameSite=Lax still sends it when the provider redirects
+// back, as that is a top-level navigation.
+func setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" ||
+		strings.HasPrefix(os.Getenv("OIDC_REDIRECT_BASE_URL"), "https://")
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     oidcCookiePath,
+		MaxAge:   maxAge,
+		Secure:   secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// respondOIDCCallback answers the callback as JSON, or redirects to the app with
+// the flat values of the response in the URL fragment, which browsers don't send
+// to servers
+func respondOIDCCallback(c *gin.Context, status int, response gin.H) {
+	appURL := strings.TrimSuffix(os.Getenv("APP_URL"), "/")
+	if appURL == "" {
+		c.JSON(status, response)
+		return
+	}
+
+	fragment := url.Values{}
+	for key, value := range response {
+		switch v := value.(type) {
+		case gin.H:
+			continue // The app loads the user with the access token
+		case string:
+			fragment.Set(key, v)
+		default:
+			fragment.Set(key, toFragmentValue(v))
+		}
+	}
+	c.Redirect(http.StatusFound, appURL+oidcAppCallbackPath+"#"+fragment.Encode())
+}
+
+// toFragmentValue formats a login response value for the URL fragment
+func toFragmentValue(value interface{}) string {
+	switch v := value.(type) {
+	case interface{ MarshalText() ([]byte, error) }:
+		text, _ := v.MarshalText()
+		return string(text)
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		return ""
+	}
+}
+
+// respondOIDCError writes the response for a single sign-on error
+func respondOIDCError(c *gin.Context, err error) {
+	status, message := oidcErrorResponse(err)
+	c.JSON(status, gin.H{"error": message})
+}
+
+// oidcErrorResponse maps single sign-on errors to a status and message
+func oidcErrorResponse(err error) (int, string) {
+	switch {
+	case errors.Is(err, services.ErrOIDCProviderNotFound):
+		return http.StatusNotFound, "Sign-in provider not found"
+	case errors.Is(err, services.ErrInvalidOIDCState):
+		return http.StatusBadRequest, "Sign-in expired or was already completed, please try again"
+	case errors.Is(err, services.ErrOIDCAccountNotAllowed):
+		return http.StatusForbidden, strings.TrimPrefix(err.Error(), services.ErrOIDCAccountNotAllowed.Error()+": ")
+	case errors.Is(err, services.ErrEmailNotVerified):
+		return http.StatusForbidden, "Email address is not verified"
+	case errors.Is(err, services.ErrOIDCLoginFailed):
+		return http.StatusBadGateway, "Sign-in with the provider failed"
+	default:
+		return http.StatusInternalServerError, "Failed to sign in"
+	}
+}
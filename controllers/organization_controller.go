@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetOrganizations lists the organizations of the current user
+func GetOrganizations(c *gin.Context) {
+	claims, _ := currentTokenClaims(c)
+
+	organizations, err := services.GetOrganizations(c.Request.Context(), claims.UserID, claims.OrganizationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch organizations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, organizations)
+}
+
+// CreateOrganization creates an organization with the current user as its admin
+func CreateOrganization(c *gin.Context) {
+	var req models.OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	organization, err := services.CreateOrganization(c.Request.Context(), userID, req)
+	if err != nil {
+		respondServiceError(c, err, "Failed to create organization")
+		return
+	}
+
+	c.JSON(http.StatusCreated, organization)
+}
+
+// SwitchOrganization returns new tokens for another organization of the current user
+func SwitchOrganization(c *gin.Context) {
+	organizationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	tokens, user, err := services.SwitchOrganization(c.Request.Context(), userID, organizationID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to switch organization")
+		return
+	}
+
+	c.JSON(http.StatusOK, loginResponse(tokens, user))
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateProject creates a project owned by the current user
+func CreateProject(c *gin.Context) {
+	var req models.ProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	project, err := services.CreateProject(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, project)
+}
+
+// GetProjects retrieves the projects the current user is a member of
+func GetProjects(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	projects, err := services.GetProjectsForUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
+		return
+	}
+
+	c.JSON(http.StatusOK, projects)
+}
+
+// GetProject retrieves a single project with its members
+func GetProject(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	project, err := services.GetProject(c.Request.Context(), projectID, userID, currentUserRole(c))
+	if err != nil {
+		respondServiceError(c, err, "Failed to fetch project")
+		return
+	}
+
+	c.JSON(http.StatusOK, project)
+}
+
+// AddProjectMember adds a user to a project
+func AddProjectMember(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	var req models.ProjectMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	member, err := services.AddProjectMember(c.Request.Context(), projectID, userID, currentUserRole(c), req)
+	if err != nil {
+		respondServiceError(c, err, "Failed to add project member")
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
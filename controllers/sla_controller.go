@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetBusinessHours returns the business hours SLA clocks of a project run on
+func GetBusinessHours(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	hours, err := services.GetBusinessHours(c.Request.Context(), projectID, userID, currentUserRole(c))
+	if err != nil {
+		respondServiceError(c, err, "Failed to fetch business hours")
+		return
+	}
+
+	c.JSON(http.StatusOK, hours)
+}
+
+// SetBusinessHours replaces the business hours of a project
+func SetBusinessHours(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	var req models.BusinessHoursRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	hours, err := services.SetBusinessHours(c.Request.Context(), projectID, userID, currentUserRole(c), req)
+	if err != nil {
+		respondServiceError(c, err, "Failed to update business hours")
+		return
+	}
+
+	c.JSON(http.StatusOK, hours)
+}
+
+// SetSLAPolicy sets the SLA targets of a priority in a project
+func SetSLAPolicy(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	var req models.SLAPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	policy, err := services.SetSLAPolicy(c.Request.Context(), projectID, userID, currentUserRole(c), req)
+	if err != nil {
+		respondServiceError(c, err, "Failed to save SLA policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// GetSLAPolicies lists the SLA policies of a project
+func GetSLAPolicies(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	policies, err := services.GetSLAPolicies(c.Request.Context(), projectID, userID, currentUserRole(c))
+	if err != nil {
+		respondServiceError(c, err, "Failed to fetch SLA policies")
+		return
+	}
+
+	c.JSON(http.StatusOK, policies)
+}
+
+// DeleteSLAPolicy removes the SLA targets of a priority
+func DeleteSLAPolicy(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	policyID, err := uuid.Parse(c.Param("policy_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid SLA policy ID"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	if err := services.DeleteSLAPolicy(c.Request.Context(), projectID, policyID, userID, currentUserRole(c)); err != nil {
+		respondServiceError(c, err, "Failed to delete SLA policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "SLA policy deleted successfully"})
+}
+
+// GetProjectSLAStatuses lists the SLA status of the open tasks of a project,
+// optionally filtered with ?status=on_track|at_risk|breached
+func GetProjectSLAStatuses(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	status := models.SLAStatus(c.Query("status"))
+	switch status {
+	case "", models.SLAOnTrack, models.SLAAtRisk, models.SLABreached, models.SLAMet:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid SLA status"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	statuses, err := services.GetProjectSLAStatuses(c.Request.Context(), projectID, userID, currentUserRole(c), status)
+	if err != nil {
+		respondServiceError(c, err, "Failed to compute SLA status")
+		return
+	}
+
+	c.JSON(http.StatusOK, statuses)
+}
+
+// GetTaskSLAStatus returns the SLA status of a task
+func GetTaskSLAStatus(c *gin.Context) {
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	status, err := services.GetTaskSLAStatus(c.Request.Context(), taskID, userID, currentUserRole(c))
+	if err != nil {
+		respondServiceError(c, err, "Failed to compute SLA status")
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
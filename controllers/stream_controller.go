@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// streamHeartbeatInterval keeps idle connections open through proxies
+const streamHeartbeatInterval = 25 * time.Second
+
+// StreamEvents pushes task and comment events to the client as Server-Sent Events.
+// Clients resume after a disconnect by sending the Last-Event-ID header, and can
+// follow a single project's activity with ?project_id=.
+func StreamEvents(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	var lastSentID int64
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+			return
+		}
+		lastSentID = id
+	}
+
+	var projectID *uuid.UUID
+	if value := c.Query("project_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+			return
+		}
+		projectID = &id
+	}
+
+	// Subscribe before replaying, so nothing stored in between is lost
+	sub, err := services.SubscribeStream(c.Request.Context(), userID, currentUserRole(c), projectID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to subscribe to events")
+		return
+	}
+	defer services.UnsubscribeStream(sub)
+
+	var missed []models.Event
+	if lastEventID != "" {
+		var err error
+		missed, err = services.GetMissedEvents(c.Request.Context(), sub, lastSentID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load missed events"})
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+	c.Status(http.StatusOK)
+
+	for _, event := range missed {
+		if err := writeStreamEvent(c, event); err != nil {
+			return
+		}
+		lastSentID = event.ID
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case event, open := <-sub.Events:
+			if !open {
+				// The client fell behind; it reconnects with Last-Event-ID
+				return
+			}
+			if event.ID <= lastSentID {
+				continue // Already sent while replaying
+			}
+			if err := writeStreamEvent(c, event); err != nil {
+				return
+			}
+			lastSentID = event.ID
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeStreamEvent writes an event in the Server-Sent Events format
+func writeStreamEvent(c *gin.Context, event models.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/azka-art/taskwise-backend/models"
@@ -17,24 +19,47 @@ func CreateTask(c *gin.Context) {
 		return
 	}
 
-	newTask, err := services.CreateTask(task)
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	task.CreatedBy = userID
+
+	newTask, err := services.CreateTask(c.Request.Context(), task)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
+		if errors.Is(err, services.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this project"})
+			return
+		}
+		respondServiceError(c, err, "Failed to create task")
 		return
 	}
 
-	c.JSON(http.StatusCreated, newTask)
+	c.JSON(http.StatusCreated, newTask.ToResponse())
 }
 
-// GetTasks retrieves all tasks
+// GetTasks retrieves all tasks matching the query filters
 func GetTasks(c *gin.Context) {
-	tasks, err := services.GetAllTasks()
+	filter, err := parseTaskFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sort, err := models.ParseTaskSort(c.Query("sort"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tasks, err := services.GetTasks(c.Request.Context(), filter, sort)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
 		return
 	}
 
-	c.JSON(http.StatusOK, tasks)
+	c.JSON(http.StatusOK, models.TaskResponses(tasks))
 }
 
 // ✅ UpdateTask now correctly parses UUID
@@ -46,19 +71,71 @@ func UpdateTask(c *gin.Context) {
 		return
 	}
 
-	var task models.Task
-	if err := c.ShouldBindJSON(&task); err != nil {
+	var req models.UpdateTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	updatedTask, err := services.UpdateTask(c.Request.Context(), id, req, userID, currentUserRole(c))
+	if err != nil {
+		respondServiceError(c, err, "Failed to update task")
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedTask.ToResponse())
+}
+
+// MoveTask reorders a task within its status column or moves it to another one
+func MoveTask(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req models.MoveTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	task, err := services.MoveTask(c.Request.Context(), id, req, userID, currentUserRole(c))
+	if err != nil {
+		respondServiceError(c, err, "Failed to move task")
+		return
+	}
+
+	c.JSON(http.StatusOK, task.ToResponse())
+}
+
+// DuplicateTask copies a task with the parts selected in the request
+func DuplicateTask(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req models.DuplicateTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	updatedTask, err := services.UpdateTask(id, task)
+	userID, _ := currentUserID(c)
+
+	task, err := services.DuplicateTask(c.Request.Context(), id, req, userID, currentUserRole(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+		respondServiceError(c, err, "Failed to duplicate task")
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedTask)
+	c.JSON(http.StatusCreated, task.ToResponse())
 }
 
 // ✅ DeleteTask now correctly parses UUID
@@ -70,11 +147,117 @@ func DeleteTask(c *gin.Context) {
 		return
 	}
 
-	err = services.DeleteTask(id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
+	userID, _ := currentUserID(c)
+
+	if err := services.DeleteTask(c.Request.Context(), id, userID, currentUserRole(c)); err != nil {
+		respondServiceError(c, err, "Failed to delete task")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
 }
+
+// parseTaskFilter reads the task list filters from the query string:
+// project_id, assignee_id (or "me"), label, status, priority and overdue
+func parseTaskFilter(c *gin.Context) (models.TaskFilter, error) {
+	var filter models.TaskFilter
+
+	if projectID := c.Query("project_id"); projectID != "" {
+		id, err := uuid.Parse(projectID)
+		if err != nil {
+			return filter, errors.New("invalid project_id")
+		}
+		filter.ProjectID = &id
+	}
+
+	if assigneeID := c.Query("assignee_id"); assigneeID == "me" {
+		filter.AssignedToMe = true
+	} else if assigneeID != "" {
+		id, err := uuid.Parse(assigneeID)
+		if err != nil {
+			return filter, errors.New("invalid assignee_id")
+		}
+		filter.AssigneeID = &id
+	}
+
+	filter.Label = c.Query("label")
+	filter.Status = models.Status(c.Query("status"))
+	filter.Priority = models.Priority(c.Query("priority"))
+	filter.Overdue = c.Query("overdue") == "true"
+
+	userID, _ := currentUserID(c)
+	return filter.ForUser(userID, currentUserRole(c)), nil
+}
+
+// ExportTasksCSV streams the tasks matching the list filters as a CSV file
+func ExportTasksCSV(c *gin.Context) {
+	filter, err := parseTaskFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="tasks.csv"`)
+	c.Status(http.StatusOK)
+
+	if err := services.ExportTasksCSV(c.Request.Context(), c.Writer, filter); err != nil {
+		// Headers are already sent, so the client only sees a truncated file
+		c.Error(err)
+	}
+}
+
+// ImportTasks creates tasks from an uploaded CSV file. The form fields are:
+// file (the CSV), mapping (JSON object of task field -> CSV column),
+// project_id (optional) and dry_run (true to only validate and preview).
+func ImportTasks(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A CSV file is required in the \"file\" field"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+	opts := services.TaskImportOptions{
+		UserID: userID,
+		DryRun: c.PostForm("dry_run") == "true",
+	}
+
+	if mapping := c.PostForm("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &opts.Mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mapping must be a JSON object of task field to CSV column"})
+			return
+		}
+	}
+
+	if projectID := c.PostForm("project_id"); projectID != "" {
+		id, err := uuid.Parse(projectID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+			return
+		}
+		opts.ProjectID = &id
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read the uploaded file"})
+		return
+	}
+	defer f.Close()
+
+	result, err := services.ImportTasksCSV(c.Request.Context(), f, opts)
+	if err != nil {
+		respondServiceError(c, err, "Failed to import tasks")
+		return
+	}
+
+	switch {
+	case result.DryRun:
+		c.JSON(http.StatusOK, result)
+	case result.Invalid > 0:
+		c.JSON(http.StatusUnprocessableEntity, result)
+	default:
+		c.JSON(http.StatusCreated, result)
+	}
+}
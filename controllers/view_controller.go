@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateSavedView saves a named filter and sort preset
+func CreateSavedView(c *gin.Context) {
+	var req models.SavedViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	view, err := services.CreateSavedView(c.Request.Context(), userID, currentUserRole(c), req)
+	if err != nil {
+		respondServiceError(c, err, "Failed to create view")
+		return
+	}
+
+	c.JSON(http.StatusCreated, view)
+}
+
+// GetSavedViews lists the views available to the current user, optionally of one project
+func GetSavedViews(c *gin.Context) {
+	var projectID *uuid.UUID
+	if value := c.Query("project_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+			return
+		}
+		projectID = &id
+	}
+
+	userID, _ := currentUserID(c)
+
+	views, err := services.GetSavedViews(c.Request.Context(), userID, projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch views"})
+		return
+	}
+
+	c.JSON(http.StatusOK, views)
+}
+
+// GetSavedView returns a single view
+func GetSavedView(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid view ID"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	view, err := services.GetSavedView(c.Request.Context(), id, userID, currentUserRole(c))
+	if err != nil {
+		respondServiceError(c, err, "Failed to fetch view")
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+// UpdateSavedView changes a view
+func UpdateSavedView(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid view ID"})
+		return
+	}
+
+	var req models.SavedViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	view, err := services.UpdateSavedView(c.Request.Context(), id, userID, currentUserRole(c), req)
+	if err != nil {
+		respondServiceError(c, err, "Failed to update view")
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+// DeleteSavedView deletes a view
+func DeleteSavedView(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid view ID"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	if err := services.DeleteSavedView(c.Request.Context(), id, userID, currentUserRole(c)); err != nil {
+		respondServiceError(c, err, "Failed to delete view")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "View deleted successfully"})
+}
+
+// GetSavedViewTasks runs a view and returns its tasks
+func GetSavedViewTasks(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid view ID"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	result, err := services.RunSavedView(c.Request.Context(), id, userID, currentUserRole(c))
+	if err != nil {
+		respondServiceError(c, err, "Failed to fetch tasks")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
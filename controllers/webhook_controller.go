@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateWebhook registers a webhook for a project
+func CreateWebhook(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	var req models.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	webhook, err := services.CreateWebhook(c.Request.Context(), projectID, userID, currentUserRole(c), req)
+	if err != nil {
+		respondServiceError(c, err, "Failed to create webhook")
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// GetWebhooks lists the webhooks of a project
+func GetWebhooks(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	webhooks, err := services.GetWebhooks(c.Request.Context(), projectID, userID, currentUserRole(c))
+	if err != nil {
+		respondServiceError(c, err, "Failed to fetch webhooks")
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// DeleteWebhook removes a webhook from a project
+func DeleteWebhook(c *gin.Context) {
+	projectID, webhookID, ok := parseWebhookParams(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	if err := services.DeleteWebhook(c.Request.Context(), projectID, webhookID, userID, currentUserRole(c)); err != nil {
+		respondServiceError(c, err, "Failed to delete webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetWebhookDeliveries returns the delivery log of a webhook
+func GetWebhookDeliveries(c *gin.Context) {
+	projectID, webhookID, ok := parseWebhookParams(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := currentUserID(c)
+	page, size := parsePagination(c)
+
+	deliveries, total, err := services.GetWebhookDeliveries(c.Request.Context(), projectID, webhookID, userID, currentUserRole(c), page, size)
+	if err != nil {
+		respondServiceError(c, err, "Failed to fetch webhook deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  deliveries,
+		"total": total,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+// RedeliverWebhook queues a delivery again
+func RedeliverWebhook(c *gin.Context) {
+	projectID, webhookID, ok := parseWebhookParams(c)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	userID, _ := currentUserID(c)
+
+	delivery, err := services.RedeliverWebhook(c.Request.Context(), projectID, webhookID, deliveryID, userID, currentUserRole(c))
+	if err != nil {
+		respondServiceError(c, err, "Failed to redeliver webhook")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// parseWebhookParams reads the project and webhook IDs from the URL
+func parseWebhookParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	webhookID, err := uuid.Parse(c.Param("webhook_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return projectID, webhookID, true
+}
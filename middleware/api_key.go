@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyResources maps the first path segment after /api to the resource of the
+// API key scopes. Routes that are not listed, like logout, two-factor
+// authentication and API key management, can't be used with an API key.
+var apiKeyResources = map[string]string{
+	"tasks":    "tasks",
+	"projects": "projects",
+	"views":    "views",
+	"calendar": "calendar",
+	"users":    "users",
+	"stream":   "events",
+}
+
+// apiKeyDeniedRoutes are routes under a scoped resource that change security
+// settings, which API keys are never allowed to do
+var apiKeyDeniedRoutes = map[string]bool{
+	"/api/users/:id/mfa-required": true,
+}
+
+// authenticateAPIKey authenticates a request made with an API key instead of a JWT
+// and checks that the key has a scope for the route
+func authenticateAPIKey(c *gin.Context, key string) {
+	claims, apiKey, err := services.AuthenticateAPIKey(c.Request.Context(), key, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAPIKey) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		} else {
+			log.Printf("❌ Failed to authenticate API key: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to authenticate API key"})
+		}
+		c.Abort()
+		return
+	}
+
+	resource, ok := apiKeyResource(c.FullPath())
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys can't be used for this endpoint"})
+		c.Abort()
+		return
+	}
+
+	write := !isReadOnlyMethod(c.Request.Method)
+	if !apiKey.Allows(resource, write) {
+		access := "read"
+		if write {
+			access = "write"
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key is missing the " + resource + ":" + access + " scope"})
+		c.Abort()
+		return
+	}
+
+	// Expose the user the key acts for, like for a JWT
+	c.Set("user_id", claims.UserID)
+	c.Set("role", claims.Role)
+	c.Set("token_claims", claims)
+	c.Set("api_key_id", apiKey.ID)
+	c.Request = c.Request.WithContext(repositories.WithOrganization(c.Request.Context(), claims.OrganizationID))
+
+	c.Next()
+}
+
+// apiKeyResource returns the scope resource of a route like /api/tasks/:id
+func apiKeyResource(route string) (string, bool) {
+	if apiKeyDeniedRoutes[route] {
+		return "", false
+	}
+	path := strings.TrimPrefix(route, "/api/")
+	if path == route {
+		return "", false
+	}
+	segment, _, _ := strings.Cut(path, "/")
+	resource, ok := apiKeyResources[segment]
+	return resource, ok
+}
@@ -1,15 +1,17 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
-	"os"
 	"strings"
 
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/azka-art/taskwise-backend/services"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-// JWTAuthMiddleware validates the JWT token from the request header
+// JWTAuthMiddleware validates the JWT token or API key from the request header
 func JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -27,20 +29,30 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := parts[1]
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+		if strings.HasPrefix(parts[1], models.APIKeyPrefix) {
+			authenticateAPIKey(c, parts[1])
+			return
+		}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		claims, err := services.AuthenticateToken(c.Request.Context(), parts[1])
+		if err != nil {
+			if errors.Is(err, services.ErrTokenRevoked) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			} else {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			}
 			c.Abort()
 			return
 		}
 
+		// Expose the authenticated user and token to the handlers
+		c.Set("user_id", claims.UserID)
+		c.Set("role", claims.Role)
+		c.Set("token_claims", claims)
+
+		// Queries of the request only see the data of the token's organization
+		c.Request = c.Request.WithContext(repositories.WithOrganization(c.Request.Context(), claims.OrganizationID))
+
 		c.Next()
 	}
 }
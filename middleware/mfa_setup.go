@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireMFASetup blocks users the organization of their token requires to use
+// two-factor authentication until they have set it up. Must run after JWTAuthMiddleware.
+func RequireMFASetup() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("token_claims")
+		claims, ok := value.(models.AccessClaims)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			c.Abort()
+			return
+		}
+
+		// The claim is only checked again in case 2FA was set up after the token was issued
+		if claims.MFASetupRequired {
+			needsSetup, err := services.NeedsMFASetup(c.Request.Context(), claims.OrganizationID, claims.UserID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check two-factor authentication"})
+				c.Abort()
+				return
+			}
+			if needsSetup {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Set up two-factor authentication to continue"})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
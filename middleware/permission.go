@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission only lets users whose role grants all of the permissions
+// through. Must run after JWTAuthMiddleware.
+func RequirePermission(permissions ...models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("role")
+		role, ok := value.(models.UserRole)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !role.Can(permission) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to do this"})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
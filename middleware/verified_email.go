@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireVerifiedEmail applies the policy for users who have not verified their
+// email address. Under the read_only policy they can only read. Must run after
+// JWTAuthMiddleware.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isReadOnlyMethod(c.Request.Method) || services.GetUnverifiedEmailPolicy() == models.UnverifiedAllow {
+			c.Next()
+			return
+		}
+
+		value, _ := c.Get("token_claims")
+		claims, ok := value.(models.AccessClaims)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			c.Abort()
+			return
+		}
+
+		// The claim is only checked again if the email was verified after the token was issued
+		if !claims.EmailVerified {
+			verified, err := services.IsEmailVerified(c.Request.Context(), claims.UserID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check email verification"})
+				c.Abort()
+				return
+			}
+			if !verified {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Verify your email address to make changes"})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// isReadOnlyMethod checks if an HTTP method only reads data
+func isReadOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
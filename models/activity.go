@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ActivityFilter narrows down a project's activity feed. Zero values are ignored.
+type ActivityFilter struct {
+	Types   []EventType
+	ActorID *uuid.UUID
+	TaskID  *uuid.UUID
+	Since   *time.Time
+	Until   *time.Time
+}
+
+// ActivityActor is the user who caused an activity entry
+type ActivityActor struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username,omitempty"`
+}
+
+// ActivityEntry is one item of a project's activity feed
+type ActivityEntry struct {
+	ID        int64          `json:"id"` // Event ID, as sent on the realtime stream
+	Type      EventType      `json:"type"`
+	TaskID    *uuid.UUID     `json:"task_id,omitempty"`
+	Actor     *ActivityActor `json:"actor"` // Nil for automatic changes such as escalations
+	Summary   string         `json:"summary"`
+	Data      interface{}    `json:"data"`
+	CreatedAt time.Time      `json:"created_at"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatusTransition is a single status change of a task, read from the event log
+type StatusTransition struct {
+	TaskID uuid.UUID `json:"task_id"`
+	From   Status    `json:"from"`
+	To     Status    `json:"to"`
+	At     time.Time `json:"at"`
+}
+
+// BurndownPoint is the number of open and done tasks at the end of a day
+type BurndownPoint struct {
+	Date  string `json:"date"` // YYYY-MM-DD, UTC
+	Total int    `json:"total"`
+	Open  int    `json:"open"`
+	Done  int    `json:"done"`
+}
+
+// CumulativeFlowPoint is the number of tasks in every status at the end of a day
+type CumulativeFlowPoint struct {
+	Date   string         `json:"date"` // YYYY-MM-DD, UTC
+	Counts map[Status]int `json:"counts"`
+}
+
+// DurationStats summarizes durations in hours
+type DurationStats struct {
+	Count        int     `json:"count"`
+	AverageHours float64 `json:"average_hours"`
+	MedianHours  float64 `json:"median_hours"`
+}
+
+// FlowTimeReport holds the cycle time (In Progress to Done) and lead time
+// (created to Done) of the tasks completed in a date range
+type FlowTimeReport struct {
+	From      string        `json:"from"`
+	To        string        `json:"to"`
+	Completed int           `json:"completed"`
+	CycleTime DurationStats `json:"cycle_time"`
+	LeadTime  DurationStats `json:"lead_time"`
+}
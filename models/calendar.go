@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CalendarToken grants read access to a user's iCalendar feed of task deadlines.
+// Only the hash of the token is stored; the token itself is part of the feed URL.
+type CalendarToken struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	OrganizationID uuid.UUID  `gorm:"type:uuid;index" json:"-"`
+	Name           string     `json:"name"`
+	TokenHash      string     `gorm:"uniqueIndex;not null" json:"-"`
+	AssignedToMe   bool       `json:"assigned_to_me"`
+	ProjectID      *uuid.UUID `gorm:"type:uuid" json:"project_id"`
+	Label          string     `json:"label"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate ensures UUID is generated before inserting a new record
+func (t *CalendarToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
+
+// Filter returns the task filter of the feed
+func (t *CalendarToken) Filter() TaskFilter {
+	return TaskFilter{
+		ProjectID:    t.ProjectID,
+		AssignedToMe: t.AssignedToMe,
+		Label:        t.Label,
+		HasDeadline:  true,
+	}
+}
+
+// CalendarTokenRequest represents the data needed to create a calendar feed
+type CalendarTokenRequest struct {
+	Name         string     `json:"name"`
+	AssignedToMe bool       `json:"assigned_to_me"`
+	ProjectID    *uuid.UUID `json:"project_id"`
+	Label        string     `json:"label"`
+}
+
+// CalendarTokenResponse is returned when a feed token is created or regenerated.
+// The token and URL are only shown at that moment.
+type CalendarTokenResponse struct {
+	CalendarToken
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
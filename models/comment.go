@@ -2,8 +2,11 @@ package models
 
 import (
 	"errors"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/azka-art/taskwise-backend/utils"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -13,7 +16,9 @@ type Comment struct {
 	ID        uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
 	TaskID    uuid.UUID      `gorm:"type:uuid;not null" json:"task_id"`
 	UserID    uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
+	ParentID  *uuid.UUID     `gorm:"type:uuid;index" json:"parent_id"` // Set on replies; threads are one level deep
 	Content   string         `gorm:"not null" json:"content"`
+	EditedAt  *time.Time     `json:"edited_at"` // Last time the content was changed
 	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -21,6 +26,10 @@ type Comment struct {
 	// Add these if you want to include related data in JSON responses
 	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	Task *Task `gorm:"foreignKey:TaskID" json:"task,omitempty"`
+
+	// Assembled when comments are loaded as threads
+	Replies   []Comment       `gorm:"-" json:"replies,omitempty"`
+	Reactions []ReactionCount `gorm:"-" json:"reactions,omitempty"`
 }
 
 // BeforeCreate ensures UUID is generated before inserting a new record
@@ -48,6 +57,42 @@ func (c *Comment) Validate() error {
 	return nil
 }
 
+// ToResponse converts a comment to the data returned by the API
+func (c *Comment) ToResponse() CommentResponse {
+	response := CommentResponse{
+		ID:          c.ID,
+		TaskID:      c.TaskID,
+		UserID:      c.UserID,
+		ParentID:    c.ParentID,
+		Content:     c.Content,
+		ContentHTML: utils.RenderMarkdown(c.Content),
+		Reactions:   c.Reactions,
+		Edited:      c.EditedAt != nil,
+		EditedAt:    c.EditedAt,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+	}
+	if response.Reactions == nil {
+		response.Reactions = []ReactionCount{}
+	}
+	if c.User != nil {
+		response.Username = c.User.Username
+	}
+	for i := range c.Replies {
+		response.Replies = append(response.Replies, c.Replies[i].ToResponse())
+	}
+	return response
+}
+
+// CommentResponses converts comments to the data returned by the API
+func CommentResponses(comments []Comment) []CommentResponse {
+	responses := make([]CommentResponse, len(comments))
+	for i := range comments {
+		responses[i] = comments[i].ToResponse()
+	}
+	return responses
+}
+
 // CommentRequest represents the data needed to create or update a comment
 type CommentRequest struct {
 	Content string `json:"content" binding:"required"`
@@ -55,11 +100,68 @@ type CommentRequest struct {
 
 // CommentResponse represents the data returned when a comment is requested
 type CommentResponse struct {
-	ID        uuid.UUID `json:"id"`
-	TaskID    uuid.UUID `json:"task_id"`
-	UserID    uuid.UUID `json:"user_id"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Username  string    `json:"username,omitempty"`
+	ID          uuid.UUID         `json:"id"`
+	TaskID      uuid.UUID         `json:"task_id"`
+	UserID      uuid.UUID         `json:"user_id"`
+	ParentID    *uuid.UUID        `json:"parent_id,omitempty"`
+	Content     string            `json:"content"`      // Markdown
+	ContentHTML string            `json:"content_html"` // Rendered and sanitized
+	Reactions   []ReactionCount   `json:"reactions"`
+	Edited      bool              `json:"edited"`
+	EditedAt    *time.Time        `json:"edited_at,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	Username    string            `json:"username,omitempty"`
+	Replies     []CommentResponse `json:"replies,omitempty"`
+}
+
+// CommentRevision keeps the content a comment had before it was edited
+type CommentRevision struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	CommentID uuid.UUID `gorm:"type:uuid;not null;index" json:"comment_id"`
+	Content   string    `gorm:"not null" json:"content"`
+	EditedBy  uuid.UUID `gorm:"type:uuid;not null" json:"edited_by"` // Who replaced this content
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`    // When it was replaced
+}
+
+// BeforeCreate ensures UUID is generated before inserting a new record
+func (r *CommentRevision) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return
+}
+
+// maxEmojiLength limits reactions to a single emoji, including skin tone and ZWJ sequences
+const maxEmojiLength = 16
+
+// CommentReaction is an emoji reaction of a user to a comment
+type CommentReaction struct {
+	CommentID uuid.UUID `gorm:"type:uuid;primaryKey" json:"comment_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Emoji     string    `gorm:"type:varchar(64);primaryKey" json:"emoji"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ReactionRequest represents the data needed to react to a comment
+type ReactionRequest struct {
+	Emoji string `json:"emoji" binding:"required"`
+}
+
+// ValidateEmoji checks that a reaction is a single short symbol without whitespace
+func ValidateEmoji(emoji string) error {
+	if emoji == "" || utf8.RuneCountInString(emoji) > maxEmojiLength || len(emoji) > 64 {
+		return errors.New("emoji must be a single emoji")
+	}
+	if strings.ContainsAny(emoji, " \t\r\n") || !utf8.ValidString(emoji) {
+		return errors.New("emoji must be a single emoji")
+	}
+	return nil
+}
+
+// ReactionCount is the number of users that reacted to a comment with an emoji
+type ReactionCount struct {
+	Emoji       string `json:"emoji"`
+	Count       int    `json:"count"`
+	ReactedByMe bool   `json:"reacted_by_me"`
 }
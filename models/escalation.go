@@ -0,0 +1,95 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EscalationAction is what an escalation policy does with an overdue task
+type EscalationAction string
+
+const (
+	EscalationRaisePriority EscalationAction = "raise_priority"
+	EscalationNotifyLead    EscalationAction = "notify_lead"
+	EscalationReassign      EscalationAction = "reassign"
+)
+
+// EscalationPolicy acts on the tasks of a project that are overdue by at least OverdueHours
+type EscalationPolicy struct {
+	ID           uuid.UUID        `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	ProjectID    uuid.UUID        `gorm:"type:uuid;not null;index" json:"project_id"`
+	Name         string           `gorm:"not null" json:"name"`
+	OverdueHours int              `gorm:"not null" json:"overdue_hours"`
+	Action       EscalationAction `gorm:"type:varchar(30);not null" json:"action"`
+	AssigneeID   *uuid.UUID       `gorm:"type:uuid" json:"assignee_id,omitempty"` // New assignee of the reassign action
+	Active       bool             `gorm:"not null;default:true" json:"active"`
+	CreatedBy    uuid.UUID        `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt    time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate ensures UUID is generated before inserting a new record
+func (p *EscalationPolicy) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return
+}
+
+// Validate checks if the policy data is valid
+func (p *EscalationPolicy) Validate() error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if p.OverdueHours < 0 {
+		return errors.New("overdue_hours cannot be negative")
+	}
+
+	switch p.Action {
+	case EscalationRaisePriority, EscalationNotifyLead:
+	case EscalationReassign:
+		if p.AssigneeID == nil {
+			return errors.New("assignee_id is required to reassign tasks")
+		}
+	default:
+		return errors.New("action must be raise_priority, notify_lead or reassign")
+	}
+
+	return nil
+}
+
+// TaskEscalation records that a policy acted on a task for a given deadline, so
+// every policy acts once per deadline
+type TaskEscalation struct {
+	PolicyID  uuid.UUID `gorm:"type:uuid;primaryKey" json:"policy_id"`
+	TaskID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"task_id"`
+	Deadline  time.Time `gorm:"primaryKey" json:"deadline"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// EscalationPolicyRequest represents the data needed to create or update an escalation policy
+type EscalationPolicyRequest struct {
+	Name         string           `json:"name" binding:"required"`
+	OverdueHours int              `json:"overdue_hours"`
+	Action       EscalationAction `json:"action" binding:"required"`
+	AssigneeID   *uuid.UUID       `json:"assignee_id"`
+	Active       *bool            `json:"active"` // Defaults to true
+}
+
+// Escalation is the event data recorded when a policy acts on an overdue task
+type Escalation struct {
+	Task         *TaskEventData   `json:"task"`
+	PolicyID     uuid.UUID        `json:"policy_id"`
+	PolicyName   string           `json:"policy_name"`
+	Action       EscalationAction `json:"action"`
+	OverdueHours float64          `json:"overdue_hours"` // How long the task was overdue
+	FromPriority Priority         `json:"from_priority,omitempty"`
+	ToPriority   Priority         `json:"to_priority,omitempty"`
+	FromAssignee *uuid.UUID       `json:"from_assignee_id,omitempty"`
+	ToAssignee   *uuid.UUID       `json:"to_assignee_id,omitempty"`
+	Notified     []uuid.UUID      `json:"notified,omitempty"` // Project leads notified of the task
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EventType identifies something that happened to a task or comment
+type EventType string
+
+const (
+	EventTaskCreated       EventType = "task.created"
+	EventTaskUpdated       EventType = "task.updated"
+	EventTaskDeleted       EventType = "task.deleted"
+	EventTaskStatusChanged EventType = "task.status_changed"
+	EventTaskAssigned      EventType = "task.assigned"
+	EventTaskEscalated     EventType = "task.escalated"
+	EventTaskSLABreached   EventType = "task.sla_breached"
+	EventCommentCreated    EventType = "comment.created"
+	EventCommentUpdated    EventType = "comment.updated"
+	EventCommentDeleted    EventType = "comment.deleted"
+)
+
+// EventTypes lists every event type that can be subscribed to
+var EventTypes = []EventType{
+	EventTaskCreated,
+	EventTaskUpdated,
+	EventTaskDeleted,
+	EventTaskStatusChanged,
+	EventTaskAssigned,
+	EventTaskEscalated,
+	EventTaskSLABreached,
+	EventCommentCreated,
+	EventCommentUpdated,
+	EventCommentDeleted,
+}
+
+// IsValidEventType checks if the given event type is known
+func IsValidEventType(eventType EventType) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Event represents a change published by the services layer. Events are stored
+// so that clients of the realtime stream can resume from the last ID they saw.
+type Event struct {
+	ID             int64       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Type           EventType   `gorm:"type:varchar(50);not null;index" json:"type"`
+	OrganizationID uuid.UUID   `gorm:"type:uuid;index" json:"-"`
+	ProjectID      *uuid.UUID  `gorm:"type:uuid;index" json:"project_id,omitempty"`
+	TaskID         *uuid.UUID  `gorm:"type:uuid;index" json:"task_id,omitempty"`
+	ActorID        *uuid.UUID  `gorm:"type:uuid;index" json:"actor_id,omitempty"`
+	Summary        string      `json:"summary,omitempty"`            // Human-readable description, e.g. for activity feeds
+	Payload        string      `gorm:"type:jsonb;not null" json:"-"` // Data encoded as JSON
+	Data           interface{} `gorm:"-" json:"data"`
+	CreatedAt      time.Time   `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// TaskEventData is a task as sent in event data. Events reach webhooks and the
+// realtime stream, so related records such as the creator's account are left out.
+type TaskEventData struct {
+	ID             uuid.UUID      `json:"id"`
+	Title          string         `json:"title"`
+	Description    string         `json:"description"`
+	Priority       Priority       `json:"priority"`
+	Status         Status         `json:"status"`
+	Deadline       *time.Time     `json:"deadline"`
+	ProjectID      *uuid.UUID     `json:"project_id"`
+	AssigneeID     *uuid.UUID     `json:"assignee_id"`
+	DuplicatedFrom *uuid.UUID     `json:"duplicated_from"`
+	CreatedBy      uuid.UUID      `json:"created_by"`
+	Labels         []string       `json:"labels,omitempty"`
+	Actor          *ActivityActor `json:"actor,omitempty"` // Nil for automatic changes
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// NewTaskEventData copies the fields of a task that events may carry
+func NewTaskEventData(task Task, actor *ActivityActor) *TaskEventData {
+	data := &TaskEventData{
+		ID:             task.ID,
+		Title:          task.Title,
+		Description:    task.Description,
+		Priority:       task.Priority,
+		Status:         task.Status,
+		Deadline:       task.Deadline,
+		ProjectID:      task.ProjectID,
+		AssigneeID:     task.AssigneeID,
+		DuplicatedFrom: task.DuplicatedFrom,
+		CreatedBy:      task.CreatedBy,
+		Actor:          actor,
+		CreatedAt:      task.CreatedAt,
+		UpdatedAt:      task.UpdatedAt,
+	}
+	for _, label := range task.Labels {
+		data.Labels = append(data.Labels, label.Name)
+	}
+	return data
+}
+
+// CommentEventData is a comment as sent in event data, without its author's account
+type CommentEventData struct {
+	ID        uuid.UUID      `json:"id"`
+	TaskID    uuid.UUID      `json:"task_id"`
+	UserID    uuid.UUID      `json:"user_id"`
+	ParentID  *uuid.UUID     `json:"parent_id"`
+	Content   string         `json:"content"`
+	EditedAt  *time.Time     `json:"edited_at"`
+	Actor     *ActivityActor `json:"actor,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// NewCommentEventData copies the fields of a comment that events may carry
+func NewCommentEventData(comment Comment, actor *ActivityActor) *CommentEventData {
+	return &CommentEventData{
+		ID:        comment.ID,
+		TaskID:    comment.TaskID,
+		UserID:    comment.UserID,
+		ParentID:  comment.ParentID,
+		Content:   comment.Content,
+		EditedAt:  comment.EditedAt,
+		Actor:     actor,
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+	}
+}
+
+// StatusChange is the event data sent when a task moves between statuses
+type StatusChange struct {
+	Task *TaskEventData `json:"task"`
+	From Status         `json:"from"`
+	To   Status         `json:"to"`
+}
+
+// AssigneeChange is the event data sent when a task is assigned to someone else
+type AssigneeChange struct {
+	Task *TaskEventData `json:"task"`
+	From *uuid.UUID     `json:"from"`
+	To   *uuid.UUID     `json:"to"`
+}
+
+// AfterFind exposes the stored payload as the event data
+func (e *Event) AfterFind(tx *gorm.DB) (err error) {
+	if e.Data == nil && e.Payload != "" {
+		e.Data = json.RawMessage(e.Payload)
+	}
+	return
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserIdentity links a user to their account at an OpenID Connect provider
+type UserIdentity struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Provider    string    `gorm:"not null;uniqueIndex:idx_user_identities_subject" json:"provider"`
+	Subject     string    `gorm:"not null;uniqueIndex:idx_user_identities_subject" json:"subject"` // sub claim, stable per provider
+	Email       string    `json:"email"`                                                           // Email at the provider when last signed in
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+// BeforeCreate ensures UUID is generated before inserting a new record
+func (i *UserIdentity) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return
+}
+
+// OIDCLoginState remembers a sign-in that was sent to a provider until it comes
+// back to the callback. It is keyed by the hash of the state parameter.
+type OIDCLoginState struct {
+	StateHash    string    `gorm:"type:char(64);primaryKey" json:"-"`
+	Provider     string    `gorm:"not null" json:"provider"`
+	Nonce        string    `gorm:"not null" json:"-"`
+	CodeVerifier string    `gorm:"not null" json:"-"` // PKCE
+	ExpiresAt    time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// OIDCProviderInfo describes a configured provider for login buttons
+type OIDCProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	LoginURL    string `json:"login_url"`
+}
+
+// OIDCIdentity is what a provider tells about the user who signed in
+type OIDCIdentity struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
@@ -0,0 +1,62 @@
+package models
+
+import "github.com/google/uuid"
+
+// ImportRowResult is the outcome of a single row of an import
+type ImportRowResult struct {
+	Row    int      `json:"row"` // 1-based line number in the source file
+	Task   *Task    `json:"task,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// TaskImportResult is the report returned by the CSV task import
+type TaskImportResult struct {
+	DryRun  bool              `json:"dry_run"`
+	Total   int               `json:"total"`
+	Valid   int               `json:"valid"`
+	Invalid int               `json:"invalid"`
+	Created int               `json:"created"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+// ImportSource is a tool whose export files can be imported
+type ImportSource string
+
+const (
+	ImportSourceTrello ImportSource = "trello"
+	ImportSourceJira   ImportSource = "jira"
+)
+
+// ImportedTask describes a task created from an external item
+type ImportedTask struct {
+	SourceID string    `json:"source_id"`
+	TaskID   uuid.UUID `json:"task_id"`
+	Title    string    `json:"title"`
+	Status   Status    `json:"status"`
+	Comments int       `json:"comments"`
+}
+
+// ImportSkipped describes an external item that was not imported
+type ImportSkipped struct {
+	SourceID string `json:"source_id"`
+	Title    string `json:"title"`
+	Reason   string `json:"reason"`
+}
+
+// ImportUnmapped describes a value from the export that has no TaskWise
+// counterpart, and what was used instead
+type ImportUnmapped struct {
+	Kind     string `json:"kind"` // status, priority or member
+	Value    string `json:"value"`
+	Fallback string `json:"fallback"`
+	Count    int    `json:"count"`
+}
+
+// ExternalImportReport is the report returned by the Trello and Jira importers
+type ExternalImportReport struct {
+	Source   ImportSource     `json:"source"`
+	DryRun   bool             `json:"dry_run"`
+	Created  []ImportedTask   `json:"created"`
+	Skipped  []ImportSkipped  `json:"skipped"`
+	Unmapped []ImportUnmapped `json:"unmapped"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Invitation invites someone by email into an organization, or into a project
+// and its organization. The token is emailed and works once; only its hash is
+// stored.
+type Invitation struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	OrganizationID uuid.UUID  `gorm:"type:uuid;index" json:"-"`
+	ProjectID      *uuid.UUID `gorm:"type:uuid;index" json:"project_id"` // Invitation into a project, if set
+	Email          string     `gorm:"not null;index" json:"email"`
+	Role           string     `gorm:"type:varchar(20);not null" json:"role"` // Organization role, or project role for project invitations
+	InvitedBy      uuid.UUID  `gorm:"type:uuid;not null" json:"invited_by"`
+	TokenHash      string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at"`
+	AcceptedBy     *uuid.UUID `gorm:"type:uuid" json:"accepted_by"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate ensures UUID is generated before inserting a new record
+func (i *Invitation) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return
+}
+
+// IsPending checks if the invitation can still be accepted
+func (i *Invitation) IsPending(now time.Time) bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && now.Before(i.ExpiresAt)
+}
+
+// InvitationRequest represents the data needed to invite someone. Without a
+// project the invitation is into the organization, with role admin or member
+// (default member). With a project, role is the project role.
+type InvitationRequest struct {
+	Email     string     `json:"email" binding:"required"`
+	Role      string     `json:"role"`
+	ProjectID *uuid.UUID `json:"project_id"`
+}
+
+// AcceptInvitationRequest accepts an invitation. Username and password are only
+// needed when there is no account with the invited email yet, and the password
+// also when that account was never verified.
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// InvitationAcceptance is the result of accepting an invitation
+type InvitationAcceptance struct {
+	User           User       `json:"user"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	ProjectID      *uuid.UUID `json:"project_id,omitempty"`
+	Created        bool       `json:"created"` // A new account was registered for the invited email
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserMFA is the TOTP two-factor authentication of a user. It is created on
+// enrollment and only protects logins once the first code was confirmed.
+type UserMFA struct {
+	UserID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Secret         string     `gorm:"not null" json:"-"`           // Base32 TOTP secret shared with the authenticator app
+	EnabledAt      *time.Time `json:"enabled_at"`                  // Nil until enrollment was confirmed
+	LastUsedStep   int64      `gorm:"not null;default:0" json:"-"` // Time step of the last accepted code, so codes can't be replayed
+	FailedAttempts int        `gorm:"not null;default:0" json:"-"` // Wrong codes since the last accepted one
+	LockedUntil    *time.Time `json:"-"`                           // No codes are checked before this moment
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// IsEnabled checks if logins require a code
+func (m *UserMFA) IsEnabled() bool {
+	return m != nil && m.EnabledAt != nil
+}
+
+// MFARecoveryCode is a one-time code that replaces a TOTP code, e.g. when the
+// authenticator app was lost. Only the SHA-256 hash of the code is stored.
+type MFARecoveryCode struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"type:char(64);not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate ensures UUID is generated before inserting a new record
+func (c *MFARecoveryCode) BeforeCreate(tx *gorm.DB) (err error) {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return
+}
+
+// MFAEnrollment is what an authenticator app needs to generate codes. OTPAuthURL
+// is the payload of the QR code to show.
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+// MFAStatus tells whether a user has two-factor authentication
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at"`
+	Required               bool       `json:"required"` // Set by an admin of the current organization; the user can't disable it
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+}
+
+// MFACodeRequest carries a TOTP code from the authenticator app
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableMFARequest turns two-factor authentication off. Either a TOTP code or a
+// recovery code is needed besides the password.
+type DisableMFARequest struct {
+	Password     string `json:"password"` // Not needed by single sign-on accounts, which confirm with a code instead
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// MFALoginRequest completes a login with the mfa_token returned by the login and
+// either a TOTP code or a recovery code
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// RequireMFARequest sets whether a user must use two-factor authentication
+type RequireMFARequest struct {
+	Required bool `json:"required"`
+}
+
+// LoginResult is the outcome of checking a user's password. With two-factor
+// authentication only MFAToken is set, to be exchanged for tokens with a code.
+type LoginResult struct {
+	Tokens       AuthTokens
+	MFARequired  bool
+	MFAToken     string
+	MFAExpiresAt time.Time
+}
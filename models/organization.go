@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultOrganizationName is the name of the organization that users, projects
+// and tasks created before organizations existed are moved into
+const DefaultOrganizationName = "Default"
+
+// Organization is a tenant: a company or team whose users, projects and tasks are
+// kept apart from those of every other organization
+type Organization struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Name      string    `gorm:"not null" json:"name"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate ensures UUID is generated before inserting a new record
+func (o *Organization) BeforeCreate(tx *gorm.DB) (err error) {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return
+}
+
+// Validate checks if the organization data is valid
+func (o *Organization) Validate() error {
+	o.Name = strings.TrimSpace(o.Name)
+	if o.Name == "" {
+		return errors.New("organization name is required")
+	}
+	if len(o.Name) > 100 {
+		return errors.New("organization name must be at most 100 characters")
+	}
+	return nil
+}
+
+// OrganizationMember is the membership of a user in an organization. A user can
+// belong to several organizations, with a role in each of them.
+type OrganizationMember struct {
+	OrganizationID uuid.UUID `gorm:"type:uuid;primaryKey" json:"organization_id"`
+	UserID         uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"user_id"`
+	Role           UserRole  `gorm:"type:varchar(20);not null;default:'member'" json:"role"`
+	MFARequired    bool      `gorm:"not null;default:false" json:"mfa_required"` // Set by an admin of the organization: the user must use two-factor authentication
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Organization *Organization `gorm:"foreignKey:OrganizationID;constraint:OnDelete:CASCADE;" json:"organization,omitempty"`
+}
+
+// OrganizationRequest represents the data needed to create an organization
+type OrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// OrganizationResponse is an organization the current user belongs to
+type OrganizationResponse struct {
+	Organization
+	Role    UserRole `json:"role"`
+	Current bool     `json:"current"` // The organization the access token is for
+}
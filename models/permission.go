@@ -0,0 +1,55 @@
+package models
+
+// Permission is something a user may do, granted through their role
+type Permission string
+
+const (
+	PermTaskCreate       Permission = "task.create"
+	PermTaskUpdate       Permission = "task.update"     // Tasks the user can see
+	PermTaskMove         Permission = "task.move"       // Change the status and order of tasks the user can see
+	PermTaskDelete       Permission = "task.delete"     // Own tasks, and tasks of projects the user manages
+	PermTaskDeleteAny    Permission = "task.delete.any" // Any task
+	PermProjectCreate    Permission = "project.create"
+	PermProjectMembers   Permission = "project.members"    // Add members to projects the user is a project admin of
+	PermProjectViewAny   Permission = "project.view.any"   // Projects and their tasks and events without being a member
+	PermProjectManageAny Permission = "project.manage.any" // What project admins can do, in every project
+	PermCommentDeleteAny Permission = "comment.delete.any" // Comments of others, on tasks outside of projects too
+	PermUserManage       Permission = "user.manage"        // List users with their emails and change their security settings
+	PermUserInvite       Permission = "user.invite"        // Invite people into the organization; project admins can invite into their projects without it
+)
+
+// RolePermissions are the permissions granted to each role
+var RolePermissions = map[UserRole][]Permission{
+	RoleAdmin: {
+		PermTaskCreate,
+		PermTaskUpdate,
+		PermTaskMove,
+		PermTaskDelete,
+		PermTaskDeleteAny,
+		PermProjectCreate,
+		PermProjectMembers,
+		PermProjectViewAny,
+		PermProjectManageAny,
+		PermCommentDeleteAny,
+		PermUserManage,
+		PermUserInvite,
+	},
+	RoleMember: {
+		PermTaskCreate,
+		PermTaskUpdate,
+		PermTaskMove,
+		PermTaskDelete,
+		PermProjectCreate,
+		PermProjectMembers,
+	},
+}
+
+// Can checks if the role grants a permission
+func (r UserRole) Can(permission Permission) bool {
+	for _, p := range RolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProjectRole represents the role of a user within a project
+type ProjectRole string
+
+const (
+	ProjectRoleAdmin  ProjectRole = "admin"
+	ProjectRoleMember ProjectRole = "member"
+)
+
+// Project groups tasks and the users working on them
+type Project struct {
+	ID             uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Name           string         `gorm:"not null" json:"name"`
+	Description    string         `json:"description"`
+	OwnerID        uuid.UUID      `gorm:"type:uuid;not null" json:"owner_id"`
+	OrganizationID uuid.UUID      `gorm:"type:uuid;index" json:"-"`
+	CreatedAt      time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Members []ProjectMember `gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE;" json:"members,omitempty"`
+}
+
+// BeforeCreate ensures UUID is generated before inserting a new record
+func (p *Project) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return
+}
+
+// Validate checks if the project data is valid
+func (p *Project) Validate() error {
+	if p.Name == "" {
+		return errors.New("project name is required")
+	}
+
+	if p.OwnerID == uuid.Nil {
+		return errors.New("owner is required")
+	}
+
+	return nil
+}
+
+// ProjectMember links a user to a project with a project-level role
+type ProjectMember struct {
+	ProjectID uuid.UUID   `gorm:"type:uuid;primaryKey" json:"project_id"`
+	UserID    uuid.UUID   `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Role      ProjectRole `gorm:"type:varchar(20);default:'member'" json:"role"`
+	CreatedAt time.Time   `gorm:"autoCreateTime" json:"created_at"`
+
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// ProjectRequest represents the data needed to create a project
+type ProjectRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// ProjectMemberRequest represents the data needed to add a member to a project
+type ProjectMemberRequest struct {
+	UserID uuid.UUID   `json:"user_id" binding:"required"`
+	Role   ProjectRole `json:"role"`
+}
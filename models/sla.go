@@ -0,0 +1,304 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Business hours can use any time zone, even without system zoneinfo
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	businessClockFormat = "15:04"
+	holidayFormat       = "2006-01-02"
+	businessSearchDays  = 3660 // Stop looking for working time after ten years
+)
+
+// BusinessHours is the working-time calendar SLA clocks of a project run on.
+// Workdays use time.Weekday numbers (0 = Sunday).
+type BusinessHours struct {
+	ProjectID uuid.UUID `gorm:"type:uuid;primaryKey" json:"project_id"`
+	Timezone  string    `gorm:"not null;default:'UTC'" json:"timezone"`
+	DayStart  string    `gorm:"type:varchar(5);not null" json:"day_start"` // HH:MM
+	DayEnd    string    `gorm:"type:varchar(5);not null" json:"day_end"`   // HH:MM
+	Workdays  []int     `gorm:"type:jsonb;serializer:json" json:"workdays"`
+	Holidays  []string  `gorm:"type:jsonb;serializer:json" json:"holidays"` // YYYY-MM-DD
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// DefaultBusinessHours is used by projects that have not set their own: Monday
+// to Friday, 09:00 to 17:00 UTC
+func DefaultBusinessHours(projectID uuid.UUID) BusinessHours {
+	return BusinessHours{
+		ProjectID: projectID,
+		Timezone:  "UTC",
+		DayStart:  "09:00",
+		DayEnd:    "17:00",
+		Workdays:  []int{1, 2, 3, 4, 5},
+		Holidays:  []string{},
+	}
+}
+
+// Validate checks if the calendar data is valid
+func (b *BusinessHours) Validate() error {
+	if _, err := time.LoadLocation(b.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", b.Timezone)
+	}
+
+	start, err := time.Parse(businessClockFormat, b.DayStart)
+	if err != nil {
+		return errors.New("day_start must be a time in the format HH:MM")
+	}
+	end, err := time.Parse(businessClockFormat, b.DayEnd)
+	if err != nil {
+		return errors.New("day_end must be a time in the format HH:MM")
+	}
+	if !end.After(start) {
+		return errors.New("day_end must be after day_start")
+	}
+
+	if len(b.Workdays) == 0 {
+		return errors.New("at least one workday is required")
+	}
+	for _, day := range b.Workdays {
+		if day < 0 || day > 6 {
+			return errors.New("workdays must be numbers from 0 (Sunday) to 6 (Saturday)")
+		}
+	}
+
+	for _, holiday := range b.Holidays {
+		if _, err := time.Parse(holidayFormat, holiday); err != nil {
+			return fmt.Errorf("holiday %q must be a date in the format YYYY-MM-DD", holiday)
+		}
+	}
+
+	return nil
+}
+
+// DayLength returns the working time of a single business day
+func (b *BusinessHours) DayLength() time.Duration {
+	start, _ := time.Parse(businessClockFormat, b.DayStart)
+	end, _ := time.Parse(businessClockFormat, b.DayEnd)
+	return end.Sub(start)
+}
+
+// Add returns the moment the given amount of working time after start has passed
+func (b *BusinessHours) Add(start time.Time, d time.Duration) time.Time {
+	day := b.localDay(start)
+	for i := 0; i < businessSearchDays; i, day = i+1, day.AddDate(0, 0, 1) {
+		opens, closes, ok := b.window(day)
+		if !ok || !closes.After(start) {
+			continue
+		}
+		if opens.Before(start) {
+			opens = start
+		}
+
+		available := closes.Sub(opens)
+		if d <= available {
+			return opens.Add(d)
+		}
+		d -= available
+	}
+	return start.Add(d) // Unreachable with a valid calendar
+}
+
+// Between returns the working time from one moment to another, negative when to is before from
+func (b *BusinessHours) Between(from, to time.Time) time.Duration {
+	if to.Before(from) {
+		return -b.Between(to, from)
+	}
+
+	var total time.Duration
+	for day := b.localDay(from); !day.After(to); day = day.AddDate(0, 0, 1) {
+		opens, closes, ok := b.window(day)
+		if !ok {
+			continue
+		}
+		if opens.Before(from) {
+			opens = from
+		}
+		if closes.After(to) {
+			closes = to
+		}
+		if closes.After(opens) {
+			total += closes.Sub(opens)
+		}
+	}
+	return total
+}
+
+// window returns the working hours of a day, or false if it is not a workday
+func (b *BusinessHours) window(day time.Time) (time.Time, time.Time, bool) {
+	if !b.isWorkday(day) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	start, _ := time.Parse(businessClockFormat, b.DayStart)
+	end, _ := time.Parse(businessClockFormat, b.DayEnd)
+	y, m, d := day.Date()
+	return time.Date(y, m, d, start.Hour(), start.Minute(), 0, 0, day.Location()),
+		time.Date(y, m, d, end.Hour(), end.Minute(), 0, 0, day.Location()),
+		true
+}
+
+// isWorkday checks if a day is a workday and not a holiday
+func (b *BusinessHours) isWorkday(day time.Time) bool {
+	date := day.Format(holidayFormat)
+	for _, holiday := range b.Holidays {
+		if holiday == date {
+			return false
+		}
+	}
+
+	for _, workday := range b.Workdays {
+		if time.Weekday(workday) == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// localDay returns midnight of the day t falls on in the calendar's time zone
+func (b *BusinessHours) localDay(t time.Time) time.Time {
+	loc, err := time.LoadLocation(b.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// BusinessHoursRequest represents the data needed to set a project's business hours
+type BusinessHoursRequest struct {
+	Timezone string   `json:"timezone"`
+	DayStart string   `json:"day_start" binding:"required"`
+	DayEnd   string   `json:"day_end" binding:"required"`
+	Workdays []int    `json:"workdays" binding:"required"`
+	Holidays []string `json:"holidays"`
+}
+
+// SLAPolicy sets the response and resolution targets for the tasks of one
+// priority in a project. Targets are business time, such as "4h", "90m" or
+// "3d" (business days).
+type SLAPolicy struct {
+	ID               uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	ProjectID        uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_sla_policy_priority" json:"project_id"`
+	Priority         Priority  `gorm:"type:varchar(20);not null;uniqueIndex:idx_sla_policy_priority" json:"priority"`
+	ResponseTarget   string    `json:"response_target"`   // Time until the task is In Progress
+	ResolutionTarget string    `json:"resolution_target"` // Time until the task is Done
+	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate ensures UUID is generated before inserting a new record
+func (p *SLAPolicy) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return
+}
+
+// Validate checks if the policy data is valid
+func (p *SLAPolicy) Validate() error {
+	if p.Priority != PriorityLow && p.Priority != PriorityMedium && p.Priority != PriorityHigh {
+		return errors.New("invalid priority value")
+	}
+
+	if p.ResponseTarget == "" && p.ResolutionTarget == "" {
+		return errors.New("a response or resolution target is required")
+	}
+
+	for _, target := range []string{p.ResponseTarget, p.ResolutionTarget} {
+		if target == "" {
+			continue
+		}
+		if _, err := ParseSLATarget(target, time.Hour); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ParseSLATarget converts a target such as "4h", "1h30m" or "3d" to business
+// time. A "d" is a business day of the given length.
+func ParseSLATarget(target string, dayLength time.Duration) (time.Duration, error) {
+	var d time.Duration
+	if days := strings.TrimSuffix(target, "d"); days != target {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid SLA target %q", target)
+		}
+		d = time.Duration(n * float64(dayLength))
+	} else {
+		parsed, err := time.ParseDuration(target)
+		if err != nil || parsed <= 0 {
+			return 0, fmt.Errorf("invalid SLA target %q, use e.g. 30m, 4h or 3d", target)
+		}
+		d = parsed
+	}
+	return d, nil
+}
+
+// SLAPolicyRequest represents the data needed to set the SLA targets of a priority
+type SLAPolicyRequest struct {
+	Priority         Priority `json:"priority" binding:"required"`
+	ResponseTarget   string   `json:"response_target"`
+	ResolutionTarget string   `json:"resolution_target"`
+}
+
+// TaskSLA holds the SLA clock of a task: when its targets are due, when they
+// were met and when a breach was reported
+type TaskSLA struct {
+	TaskID               uuid.UUID  `gorm:"type:uuid;primaryKey" json:"task_id"`
+	ProjectID            uuid.UUID  `gorm:"type:uuid;not null;index" json:"project_id"`
+	PolicyID             uuid.UUID  `gorm:"type:uuid;not null;index" json:"policy_id"`
+	ResponseDue          *time.Time `gorm:"index" json:"response_due"`
+	ResolutionDue        *time.Time `gorm:"index" json:"resolution_due"`
+	RespondedAt          *time.Time `json:"responded_at"`
+	ResolvedAt           *time.Time `json:"resolved_at"`
+	ResponseBreachedAt   *time.Time `json:"response_breached_at"`
+	ResolutionBreachedAt *time.Time `json:"resolution_breached_at"`
+	UpdatedAt            time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// SLAStatus summarizes how a task is doing against its SLA targets
+type SLAStatus string
+
+const (
+	SLAOnTrack  SLAStatus = "on_track"
+	SLAAtRisk   SLAStatus = "at_risk"
+	SLABreached SLAStatus = "breached"
+	SLAMet      SLAStatus = "met"
+)
+
+// SLATargetStatus is the state of a single SLA target of a task
+type SLATargetStatus struct {
+	Target           string     `json:"target"`
+	Due              time.Time  `json:"due"`
+	CompletedAt      *time.Time `json:"completed_at"`
+	Status           SLAStatus  `json:"status"`
+	RemainingMinutes int64      `json:"remaining_minutes"` // Business minutes left, negative once breached
+}
+
+// TaskSLAStatus is the computed SLA state of a task
+type TaskSLAStatus struct {
+	TaskID     uuid.UUID        `json:"task_id"`
+	Title      string           `json:"title"`
+	Priority   Priority         `json:"priority"`
+	Status     SLAStatus        `json:"status"`
+	Response   *SLATargetStatus `json:"response,omitempty"`
+	Resolution *SLATargetStatus `json:"resolution,omitempty"`
+}
+
+// SLABreach is the event data sent when a task misses an SLA target
+type SLABreach struct {
+	Task   *TaskEventData `json:"task"`
+	Target string         `json:"target"` // response or resolution
+	Due    time.Time      `json:"due"`
+}
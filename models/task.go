@@ -1,9 +1,13 @@
 package models
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/azka-art/taskwise-backend/utils"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -28,20 +32,51 @@ const (
 
 // Task represents a task in the system
 type Task struct {
-	ID          uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	Title       string         `gorm:"not null" json:"title"`
-	Description string         `json:"description"`
-	Priority    Priority       `gorm:"type:enum('Low', 'Medium', 'High');default:'Medium'" json:"priority"`
-	Status      Status         `gorm:"type:enum('Pending', 'In Progress', 'Done');default:'Pending'" json:"status"`
-	Deadline    *time.Time     `json:"deadline"`
-	CreatedBy   uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
-	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID             uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Title          string         `gorm:"not null" json:"title"`
+	Description    string         `json:"description"`
+	Priority       Priority       `gorm:"type:enum('Low', 'Medium', 'High');default:'Medium'" json:"priority"`
+	Status         Status         `gorm:"type:enum('Pending', 'In Progress', 'Done');default:'Pending'" json:"status"`
+	Deadline       *time.Time     `json:"deadline"`
+	ProjectID      *uuid.UUID     `gorm:"type:uuid;index" json:"project_id"`
+	AssigneeID     *uuid.UUID     `gorm:"type:uuid;index" json:"assignee_id"`
+	Rank           string         `gorm:"type:varchar(255) COLLATE \"C\";not null;default:''" json:"rank"` // Position within its status column
+	DuplicatedFrom *uuid.UUID     `gorm:"type:uuid;index" json:"-"`                                        // Task this one was copied from, only set by DuplicateTask
+	CreatedBy      uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
+	OrganizationID uuid.UUID      `gorm:"type:uuid;index" json:"-"`
+	CreatedAt      time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relationships
-	Comments []Comment `gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE;" json:"comments,omitempty"`
-	User     *User     `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	Comments []Comment   `gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE;" json:"comments,omitempty"`
+	Labels   []TaskLabel `gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE;" json:"labels,omitempty"`
+	User     *User       `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+}
+
+// TaskLabel is a free-form label attached to a task
+type TaskLabel struct {
+	TaskID uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	Name   string    `gorm:"primaryKey" json:"name"`
+}
+
+// MarshalJSON encodes a label as its name
+func (l TaskLabel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.Name)
+}
+
+// UnmarshalJSON decodes a label from its name
+func (l *TaskLabel) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &l.Name)
+}
+
+// LabelNames returns the names of the task's labels
+func (t *Task) LabelNames() []string {
+	names := make([]string, len(t.Labels))
+	for i, label := range t.Labels {
+		names[i] = label.Name
+	}
+	return names
 }
 
 // BeforeCreate ensures UUID is generated before inserting a new record
@@ -52,6 +87,16 @@ func (t *Task) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+// IsValid checks if the priority is one of the known priorities
+func (p Priority) IsValid() bool {
+	return p == PriorityLow || p == PriorityMedium || p == PriorityHigh
+}
+
+// IsValid checks if the status is one of the known statuses
+func (s Status) IsValid() bool {
+	return s == StatusPending || s == StatusInProgress || s == StatusDone
+}
+
 // Validate checks if the task data is valid
 func (t *Task) Validate() error {
 	if t.Title == "" {
@@ -94,28 +139,209 @@ func (t *Task) SetDefaults() {
 	}
 }
 
+// IsOverdue checks if the task's deadline has passed while it is not done
+func (t *Task) IsOverdue(now time.Time) bool {
+	return t.Deadline != nil && t.Deadline.Before(now) && t.Status != StatusDone
+}
+
+// ToResponse converts a task to the data returned by the API
+func (t *Task) ToResponse() TaskResponse {
+	response := TaskResponse{
+		ID:              t.ID,
+		Title:           t.Title,
+		Description:     t.Description,
+		DescriptionHTML: utils.RenderMarkdown(t.Description),
+		Priority:        t.Priority,
+		Status:          t.Status,
+		Deadline:        t.Deadline,
+		ProjectID:       t.ProjectID,
+		AssigneeID:      t.AssigneeID,
+		Rank:            t.Rank,
+		DuplicatedFrom:  t.DuplicatedFrom,
+		Labels:          t.LabelNames(),
+		Overdue:         t.IsOverdue(time.Now()),
+		CreatedBy:       t.CreatedBy,
+		CreatedAt:       t.CreatedAt,
+		UpdatedAt:       t.UpdatedAt,
+	}
+
+	if t.User != nil {
+		response.Creator = t.User.Username
+	}
+
+	for i := range t.Comments {
+		response.Comments = append(response.Comments, t.Comments[i].ToResponse())
+	}
+
+	return response
+}
+
+// TaskResponses converts tasks to the data returned by the API
+func TaskResponses(tasks []Task) []TaskResponse {
+	responses := make([]TaskResponse, len(tasks))
+	for i := range tasks {
+		responses[i] = tasks[i].ToResponse()
+	}
+	return responses
+}
+
 // TaskRequest represents the data needed to create or update a task
 type TaskRequest struct {
-	Title       string   `json:"title" binding:"required"`
-	Description string   `json:"description"`
-	Priority    Priority `json:"priority"`
-	Status      Status   `json:"status"`
-	Deadline    *string  `json:"deadline"` // Format: "2006-01-02T15:04:05Z"
+	Title       string     `json:"title" binding:"required"`
+	Description string     `json:"description"`
+	Priority    Priority   `json:"priority"`
+	Status      Status     `json:"status"`
+	Deadline    *string    `json:"deadline"` // Format: "2006-01-02T15:04:05Z"
+	ProjectID   *uuid.UUID `json:"project_id"`
+	AssigneeID  *uuid.UUID `json:"assignee_id"`
+	Labels      []string   `json:"labels"`
 }
 
 // TaskResponse represents the data returned when a task is requested
 type TaskResponse struct {
-	ID          uuid.UUID         `json:"id"`
-	Title       string            `json:"title"`
-	Description string            `json:"description"`
-	Priority    Priority          `json:"priority"`
-	Status      Status            `json:"status"`
-	Deadline    *time.Time        `json:"deadline"`
-	CreatedBy   uuid.UUID         `json:"created_by"`
-	CreatedAt   time.Time         `json:"created_at"`
-	UpdatedAt   time.Time         `json:"updated_at"`
-	Creator     string            `json:"creator,omitempty"`
-	Comments    []CommentResponse `json:"comments,omitempty"`
+	ID              uuid.UUID         `json:"id"`
+	Title           string            `json:"title"`
+	Description     string            `json:"description"`      // Markdown
+	DescriptionHTML string            `json:"description_html"` // Rendered and sanitized
+	Priority        Priority          `json:"priority"`
+	Status          Status            `json:"status"`
+	Deadline        *time.Time        `json:"deadline"`
+	ProjectID       *uuid.UUID        `json:"project_id"`
+	AssigneeID      *uuid.UUID        `json:"assignee_id"`
+	Rank            string            `json:"rank"`
+	DuplicatedFrom  *uuid.UUID        `json:"duplicated_from,omitempty"`
+	Labels          []string          `json:"labels,omitempty"`
+	Overdue         bool              `json:"overdue"` // Computed: the deadline has passed and the task is not done
+	CreatedBy       uuid.UUID         `json:"created_by"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+	Creator         string            `json:"creator,omitempty"`
+	Comments        []CommentResponse `json:"comments,omitempty"`
+}
+
+// UpdateTaskRequest changes the fields of a task. Fields that are left out keep
+// their value; the position, project and creator are not changed through it.
+type UpdateTaskRequest struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Priority    Priority   `json:"priority"`
+	Status      Status     `json:"status"`
+	Deadline    *time.Time `json:"deadline"`
+	AssigneeID  *uuid.UUID `json:"assignee_id"`
+	Labels      []string   `json:"labels"` // Replaces the labels when present, an empty list removes them
+}
+
+// Validate checks the priority and status of an update
+func (r UpdateTaskRequest) Validate() error {
+	if r.Priority != "" && !r.Priority.IsValid() {
+		return errors.New("invalid priority value")
+	}
+	if r.Status != "" && !r.Status.IsValid() {
+		return errors.New("invalid status value")
+	}
+	return nil
+}
+
+// MoveTaskRequest places a task between two neighbours of a status column.
+// Without neighbours the task is moved to the end of the column.
+type MoveTaskRequest struct {
+	Status   Status     `json:"status"`    // Column to move to, defaults to the current status
+	BeforeID *uuid.UUID `json:"before_id"` // Task that ends up directly before (above) the moved task
+	AfterID  *uuid.UUID `json:"after_id"`  // Task that ends up directly after (below) the moved task
+}
+
+// DuplicateTaskRequest selects which parts of a task are copied. The title,
+// priority and project are always copied; the copy starts as Pending. Comments
+// are never copied, and tasks have no checklists, subtasks or attachments yet.
+type DuplicateTaskRequest struct {
+	Title              string `json:"title"` // Defaults to "Copy of" the original title
+	IncludeDescription bool   `json:"include_description"`
+	IncludeLabels      bool   `json:"include_labels"`
+	IncludeAssignee    bool   `json:"include_assignee"`
+	IncludeDeadline    bool   `json:"include_deadline"`
+	DeadlineOffsetDays int    `json:"deadline_offset_days"` // Shifts the copied deadline, may be negative
+}
+
+// TaskFilter narrows down task queries. Zero values are ignored.
+type TaskFilter struct {
+	ProjectID    *uuid.UUID `json:"project_id,omitempty"`
+	AssigneeID   *uuid.UUID `json:"assignee_id,omitempty"`
+	AssignedToMe bool       `json:"assigned_to_me,omitempty"` // Resolved to AssigneeID for the requesting user
+	Label        string     `json:"label,omitempty"`
+	Status       Status     `json:"status,omitempty"`
+	Priority     Priority   `json:"priority,omitempty"`
+	HasDeadline  bool       `json:"has_deadline,omitempty"`
+	Overdue      bool       `json:"overdue,omitempty"` // Deadline has passed and the task is not done
+
+	// VisibleTo limits the results to tasks outside of any project or in the
+	// projects the user is a member of
+	VisibleTo *uuid.UUID `json:"-"`
+}
+
+// Validate checks the status and priority values of the filter
+func (f TaskFilter) Validate() error {
+	if f.Status != "" && f.Status != StatusPending && f.Status != StatusInProgress && f.Status != StatusDone {
+		return errors.New("invalid status value")
+	}
+
+	if f.Priority != "" && f.Priority != PriorityLow && f.Priority != PriorityMedium && f.Priority != PriorityHigh {
+		return errors.New("invalid priority value")
+	}
+
+	return nil
+}
+
+// ForUser resolves the parts of the filter that depend on who is asking:
+// AssignedToMe becomes the user's ID, and users who can't view every project only
+// see tasks they can access
+func (f TaskFilter) ForUser(userID uuid.UUID, role UserRole) TaskFilter {
+	if f.AssignedToMe {
+		f.AssigneeID = &userID
+	}
+
+	if !role.Can(PermProjectViewAny) {
+		f.VisibleTo = &userID
+	}
+
+	return f
+}
+
+// TaskSortFields are the fields task lists can be sorted by
+var TaskSortFields = []string{"rank", "title", "priority", "status", "deadline", "created_at", "updated_at"}
+
+// TaskSort is one key of a task list's sort order
+type TaskSort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseTaskSort parses a comma-separated sort order such as "-priority,deadline",
+// where a leading "-" sorts that field in descending order
+func ParseTaskSort(value string) ([]TaskSort, error) {
+	var sort []TaskSort
+	for _, key := range strings.Split(value, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+
+		field := strings.TrimPrefix(key, "-")
+		if !isTaskSortField(field) {
+			return nil, fmt.Errorf("invalid sort field %q", field)
+		}
+		sort = append(sort, TaskSort{Field: field, Desc: strings.HasPrefix(key, "-")})
+	}
+	return sort, nil
+}
+
+// isTaskSortField checks if tasks can be sorted by a field
+func isTaskSortField(field string) bool {
+	for _, f := range TaskSortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
 }
 
 // AIRecommendationRequest represents input data for AI task recommendations
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshToken is an opaque, single-use token that is exchanged for a new access
+// token. Only the SHA-256 hash of the token is stored. Every token issued by
+// rotating another one belongs to the same family as the token it replaced.
+type RefreshToken struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	FamilyID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"family_id"` // Tokens issued since the same login
+	OrganizationID *uuid.UUID `gorm:"type:uuid" json:"organization_id"`          // Organization the access tokens are issued for
+	TokenHash      string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt         *time.Time `json:"used_at"`    // Set once it was exchanged
+	RevokedAt      *time.Time `json:"revoked_at"` // Set when its family was revoked
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate ensures UUID is generated before inserting a new record
+func (t *RefreshToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
+
+// AuthTokens are the tokens issued on login and refresh
+type AuthTokens struct {
+	AccessToken      string    `json:"access_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	OrganizationID   uuid.UUID `json:"organization_id"` // Organization the access token is for
+	Role             UserRole  `json:"role"`            // Role of the user in that organization
+}
+
+// RefreshTokenRequest represents the data needed to refresh an access token
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RevokedToken is an access token that was revoked before it expired, identified
+// by its jti claim. Rows can be removed once the token has expired.
+type RevokedToken struct {
+	TokenID   uuid.UUID `gorm:"type:uuid;primaryKey" json:"token_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// UserTokenRevocation revokes every access token of a user issued before a moment,
+// e.g. after logging out everywhere or changing the password
+type UserTokenRevocation struct {
+	UserID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	RevokedBefore time.Time `gorm:"not null" json:"revoked_before"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// AccessClaims are the claims of a verified access token
+type AccessClaims struct {
+	UserID           uuid.UUID
+	OrganizationID   uuid.UUID // Organization the token is for
+	Role             UserRole  // Role of the user in that organization
+	EmailVerified    bool      // When the token was issued
+	MFASetupRequired bool      // Two-factor authentication is required but was not set up when the token was issued
+	TokenID          uuid.UUID // jti
+	IssuedAt         time.Time
+	ExpiresAt        time.Time
+}
+
+// LogoutRequest optionally names the refresh token to revoke with the access token
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// PasswordResetToken is a single-use token emailed to reset a forgotten password.
+// Only the SHA-256 hash of the token is stored.
+type PasswordResetToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	TokenHash string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate ensures UUID is generated before inserting a new record
+func (t *PasswordResetToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
+
+// ForgotPasswordRequest asks for a password reset link
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// ResetPasswordRequest sets a new password with a token from a reset link
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// EmailVerificationToken is a single-use token emailed to confirm a user's email
+// address. Only the SHA-256 hash of the token is stored.
+type EmailVerificationToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	TokenHash string    `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate ensures UUID is generated before inserting a new record
+func (t *EmailVerificationToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
@@ -19,14 +19,15 @@ const (
 
 // User represents a user in the system
 type User struct {
-	ID        uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	Username  string         `gorm:"unique;not null" json:"username"`
-	Email     string         `gorm:"unique;not null" json:"email"`
-	Password  string         `gorm:"not null" json:"-"` // Never expose password in JSON
-	Role      UserRole       `gorm:"type:enum('admin', 'member');default:'member'" json:"role"`
-	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID              uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Username        string         `gorm:"unique;not null" json:"username"`
+	Email           string         `gorm:"unique;not null" json:"email"`
+	Password        string         `gorm:"not null" json:"-"` // Never expose password in JSON
+	Role            UserRole       `gorm:"type:enum('admin', 'member');default:'member'" json:"role"`
+	EmailVerifiedAt *time.Time     `json:"email_verified_at"` // Nil until the email address was verified
+	CreatedAt       time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relationships
 	Tasks    []Task    `gorm:"foreignKey:CreatedBy;constraint:OnDelete:CASCADE;" json:"tasks,omitempty"`
@@ -41,6 +42,11 @@ func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+// IsEmailVerified checks if the user verified their email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
 // Validate checks if the user data is valid
 func (u *User) Validate() error {
 	if u.Username == "" {
@@ -99,11 +105,12 @@ type RegisterRequest struct {
 
 // UserResponse represents the data returned when a user is requested
 type UserResponse struct {
-	ID        uuid.UUID `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Role      UserRole  `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            uuid.UUID `json:"id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	Role          UserRole  `json:"role"`
+	EmailVerified bool      `json:"email_verified"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 // TokenResponse represents the data returned after successful authentication
@@ -120,3 +127,17 @@ type UpdateUserRequest struct {
 	Password string   `json:"password,omitempty"`
 	Role     UserRole `json:"role,omitempty"`
 }
+
+// UnverifiedEmailPolicy decides what users who have not verified their email address can do
+type UnverifiedEmailPolicy string
+
+const (
+	UnverifiedAllow    UnverifiedEmailPolicy = "allow"     // Everything, as if verified
+	UnverifiedReadOnly UnverifiedEmailPolicy = "read_only" // Log in and read, but not change anything
+	UnverifiedBlock    UnverifiedEmailPolicy = "block"     // Not even log in
+)
+
+// ResendVerificationRequest asks for a new email verification link
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required"`
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TaskViewFields are the task fields a saved view can show
+var TaskViewFields = []string{
+	"id", "title", "description", "description_html", "priority", "status", "deadline", "project_id",
+	"assignee_id", "rank", "labels", "overdue", "created_by", "created_at", "updated_at", "user",
+}
+
+// SavedView is a named combination of task filters, sort order and visible fields.
+// Views without a project are personal; views with one are shared with its members.
+type SavedView struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Name           string     `gorm:"not null" json:"name"`
+	OwnerID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"owner_id"`
+	OrganizationID uuid.UUID  `gorm:"type:uuid;index" json:"-"`
+	ProjectID      *uuid.UUID `gorm:"type:uuid;index" json:"project_id"`
+	Filters        TaskFilter `gorm:"type:jsonb;serializer:json" json:"filters"`
+	Sort           string     `json:"sort"`                                     // e.g. "-priority,deadline"
+	Fields         []string   `gorm:"type:jsonb;serializer:json" json:"fields"` // Empty shows every field
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate ensures UUID is generated before inserting a new record
+func (v *SavedView) BeforeCreate(tx *gorm.DB) (err error) {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return
+}
+
+// Validate checks if the view data is valid
+func (v *SavedView) Validate() error {
+	if strings.TrimSpace(v.Name) == "" {
+		return errors.New("name is required")
+	}
+
+	if err := v.Filters.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := ParseTaskSort(v.Sort); err != nil {
+		return err
+	}
+
+	for _, field := range v.Fields {
+		if !isTaskViewField(field) {
+			return fmt.Errorf("invalid field %q", field)
+		}
+	}
+
+	return nil
+}
+
+// isTaskViewField checks if a saved view can show a task field
+func isTaskViewField(field string) bool {
+	for _, f := range TaskViewFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// SavedViewRequest represents the data needed to create or update a saved view
+type SavedViewRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	ProjectID *uuid.UUID `json:"project_id"` // Share the view with the members of this project
+	Filters   TaskFilter `json:"filters"`
+	Sort      string     `json:"sort"`
+	Fields    []string   `json:"fields"`
+}
+
+// SavedViewTasks is the result of running a saved view
+type SavedViewTasks struct {
+	View  SavedView                `json:"view"`
+	Tasks []map[string]interface{} `json:"tasks"`
+}
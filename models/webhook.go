@@ -0,0 +1,128 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookDeliveryStatus represents the state of a webhook delivery
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending"
+	DeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	DeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// Webhook is an outbound HTTP endpoint registered for project events
+type Webhook struct {
+	ID        uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	ProjectID uuid.UUID      `gorm:"type:uuid;not null;index" json:"project_id"`
+	URL       string         `gorm:"not null" json:"url"`
+	Secret    string         `gorm:"not null" json:"-"` // Only returned once, on creation
+	Events    string         `gorm:"not null" json:"-"` // Comma separated list of EventType
+	Active    bool           `gorm:"default:true" json:"active"`
+	CreatedBy uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BeforeCreate ensures UUID is generated before inserting a new record
+func (w *Webhook) BeforeCreate(tx *gorm.DB) (err error) {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return
+}
+
+// EventList returns the event types the webhook is subscribed to
+func (w *Webhook) EventList() []EventType {
+	var events []EventType
+	for _, e := range strings.Split(w.Events, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			events = append(events, EventType(e))
+		}
+	}
+	return events
+}
+
+// Subscribes checks if the webhook wants to receive the given event type
+func (w *Webhook) Subscribes(eventType EventType) bool {
+	for _, e := range w.EventList() {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks if the webhook data is valid
+func (w *Webhook) Validate() error {
+	if w.ProjectID == uuid.Nil {
+		return errors.New("project ID is required")
+	}
+
+	if !strings.HasPrefix(w.URL, "http://") && !strings.HasPrefix(w.URL, "https://") {
+		return errors.New("webhook URL must start with http:// or https://")
+	}
+
+	events := w.EventList()
+	if len(events) == 0 {
+		return errors.New("at least one event is required")
+	}
+
+	for _, e := range events {
+		if !IsValidEventType(e) {
+			return errors.New("invalid event type: " + string(e))
+		}
+	}
+
+	return nil
+}
+
+// WebhookDelivery is a single signed POST of an event to a webhook
+type WebhookDelivery struct {
+	ID            uuid.UUID             `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	WebhookID     uuid.UUID             `gorm:"type:uuid;not null;index" json:"webhook_id"`
+	Event         EventType             `gorm:"type:varchar(50);not null" json:"event"`
+	Payload       string                `gorm:"type:text;not null" json:"payload"`
+	Status        WebhookDeliveryStatus `gorm:"type:varchar(20);default:'pending';index" json:"status"`
+	Attempts      int                   `gorm:"default:0" json:"attempts"`
+	ResponseCode  int                   `json:"response_code"`
+	Error         string                `json:"error,omitempty"`
+	NextAttemptAt *time.Time            `gorm:"index" json:"next_attempt_at,omitempty"`
+	RedeliveryOf  *uuid.UUID            `gorm:"type:uuid" json:"redelivery_of,omitempty"`
+	CreatedAt     time.Time             `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time             `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate ensures UUID is generated before inserting a new record
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) (err error) {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return
+}
+
+// WebhookRequest represents the data needed to register a webhook
+type WebhookRequest struct {
+	URL    string      `json:"url" binding:"required"`
+	Events []EventType `json:"events" binding:"required"`
+}
+
+// WebhookResponse represents a webhook returned by the API
+type WebhookResponse struct {
+	ID        uuid.UUID   `json:"id"`
+	ProjectID uuid.UUID   `json:"project_id"`
+	URL       string      `json:"url"`
+	Events    []EventType `json:"events"`
+	Active    bool        `json:"active"`
+	Secret    string      `json:"secret,omitempty"` // Only set on creation
+	CreatedBy uuid.UUID   `json:"created_by"`
+	CreatedAt time.Time   `json:"created_at"`
+}
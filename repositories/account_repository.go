@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+)
+
+// AccountRepository defines the methods to find, create and secure user accounts
+// and their single sign-on identities
+type AccountRepository interface {
+	FindByID(id uuid.UUID) (*models.User, error)
+	FindByEmail(email string) (*models.User, error)
+	FindByUsername(username string) (*models.User, error)
+	CreateWithOrganization(user *models.User, organization *models.Organization) error
+	SetPassword(userID uuid.UUID, hashedPassword string) error
+	MarkEmailVerified(userID uuid.UUID, at time.Time) error
+	DeleteMFA(userID uuid.UUID) error
+	RevokeSessions(userID uuid.UUID, at time.Time) error
+	FindIdentity(provider, subject string) (*models.UserIdentity, error)
+	CreateIdentity(identity *models.UserIdentity) error
+	TouchIdentity(id uuid.UUID, email string, at time.Time) error
+}
+
+// accountRepository implements the AccountRepository interface
+type accountRepository struct {
+	ctx context.Context
+}
+
+// NewAccountRepository creates an account repository for the queries of a
+// context, so that they take part in its transaction and organization
+func NewAccountRepository(ctx context.Context) AccountRepository {
+	return &accountRepository{
+		ctx: ctx,
+	}
+}
+
+// FindByID finds a user by their ID
+func (r *accountRepository) FindByID(id uuid.UUID) (*models.User, error) {
+	return GetUserByID(r.ctx, id)
+}
+
+// FindByEmail finds a user by their email address
+func (r *accountRepository) FindByEmail(email string) (*models.User, error) {
+	return GetUserByEmail(r.ctx, email)
+}
+
+// FindByUsername finds a user by their username
+func (r *accountRepository) FindByUsername(username string) (*models.User, error) {
+	return GetUserByUsername(r.ctx, username)
+}
+
+// CreateWithOrganization creates a user together with an organization they are
+// the admin of
+func (r *accountRepository) CreateWithOrganization(user *models.User, organization *models.Organization) error {
+	return WithTransaction(r.ctx, func(ctx context.Context) error {
+		if err := CreateUser(ctx, user); err != nil {
+			return err
+		}
+		return CreateOrganization(ctx, organization, user.ID)
+	})
+}
+
+// SetPassword replaces the password hash of a user
+func (r *accountRepository) SetPassword(userID uuid.UUID, hashedPassword string) error {
+	return SetPasswordHash(r.ctx, userID, hashedPassword)
+}
+
+// MarkEmailVerified marks the email address of a user as verified
+func (r *accountRepository) MarkEmailVerified(userID uuid.UUID, at time.Time) error {
+	return MarkEmailVerified(r.ctx, userID, at)
+}
+
+// DeleteMFA turns off two-factor authentication of a user
+func (r *accountRepository) DeleteMFA(userID uuid.UUID) error {
+	return DeleteUserMFA(r.ctx, userID)
+}
+
+// RevokeSessions revokes the access and refresh tokens of a user and their API
+// keys in every organization
+func (r *accountRepository) RevokeSessions(userID uuid.UUID, at time.Time) error {
+	// Token iat claims only have whole seconds, so tokens issued later in the same
+	// second must not compare as older
+	if err := RevokeUserAccessTokens(r.ctx, userID, at.Truncate(time.Second)); err != nil {
+		return err
+	}
+	if err := RevokeUserRefreshTokens(r.ctx, userID, at); err != nil {
+		return err
+	}
+	return RevokeUserAPIKeys(AllOrganizations(r.ctx), userID, at)
+}
+
+// FindIdentity finds a single sign-on identity by its provider and subject
+func (r *accountRepository) FindIdentity(provider, subject string) (*models.UserIdentity, error) {
+	return GetUserIdentity(r.ctx, provider, subject)
+}
+
+// CreateIdentity links a single sign-on identity to a user
+func (r *accountRepository) CreateIdentity(identity *models.UserIdentity) error {
+	return CreateUserIdentity(r.ctx, identity)
+}
+
+// TouchIdentity records a sign-in with an identity
+func (r *accountRepository) TouchIdentity(id uuid.UUID, email string, at time.Time) error {
+	return TouchUserIdentity(r.ctx, id, email, at)
+}
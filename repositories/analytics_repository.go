@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+)
+
+// GetProjectTaskHistory mengambil semua tugas sebuah project yang dibuat sebelum
+// until, termasuk yang sudah dihapus, beserta riwayat perubahan statusnya
+func GetProjectTaskHistory(ctx context.Context, projectID uuid.UUID, until time.Time) ([]models.Task, []models.StatusTransition, error) {
+	var tasks []models.Task
+	err := database(ctx).Unscoped().
+		Select("id", "status", "created_at", "deleted_at").
+		Where("project_id = ? AND created_at < ?", projectID, until).
+		Find(&tasks).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var transitions []models.StatusTransition
+	err = database(ctx).Model(&models.Event{}).
+		Select(`task_id, payload->>'from' AS "from", payload->>'to' AS "to", created_at AS at`).
+		Where("type = ? AND project_id = ? AND created_at < ?", models.EventTaskStatusChanged, projectID, until).
+		Order("id").
+		Scan(&transitions).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tasks, transitions, nil
+}
@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// apiKeyTouchInterval limits how often the last use of a key is written
+const apiKeyTouchInterval = time.Minute
+
+// CreateAPIKey saves a new API key
+func CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	return database(ctx).Create(key).Error
+}
+
+// GetAPIKeyByID finds an API key by its ID
+func GetAPIKeyByID(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	var key models.APIKey
+	err := database(ctx).Where("id = ?", id).First(&key).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// GetAPIKeyByHash finds an API key by the hash of the key
+func GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := database(ctx).Where("key_hash = ?", hash).First(&key).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// GetAPIKeysByUserID finds all API keys of a user that have not been revoked
+func GetAPIKeysByUserID(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := database(ctx).Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// RevokeAPIKey disables an API key
+func RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	return database(ctx).Model(&models.APIKey{}).Where("id = ?", id).Update("revoked_at", time.Now()).Error
+}
+
+// RevokeUserAPIKeys disables every API key of a user, in all organizations of the context
+func RevokeUserAPIKeys(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	return database(ctx).Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+// TouchAPIKey records when and from where a key was last used. It is written at
+// most once per apiKeyTouchInterval, so busy scripts don't cause a write per request.
+func TouchAPIKey(ctx context.Context, id uuid.UUID, ip string) error {
+	now := time.Now()
+	return database(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ? OR last_used_ip <> ?)", id, now.Add(-apiKeyTouchInterval), ip).
+		UpdateColumns(map[string]interface{}{"last_used_at": now, "last_used_ip": ip}).Error
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateCalendarToken saves a new calendar feed token
+func CreateCalendarToken(ctx context.Context, token *models.CalendarToken) error {
+	return database(ctx).Create(token).Error
+}
+
+// GetCalendarTokenByID finds a calendar feed token by its ID
+func GetCalendarTokenByID(ctx context.Context, id uuid.UUID) (*models.CalendarToken, error) {
+	var token models.CalendarToken
+	err := database(ctx).Where("id = ?", id).First(&token).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// GetActiveCalendarTokenByHash finds a calendar feed token that has not been revoked
+func GetActiveCalendarTokenByHash(ctx context.Context, hash string) (*models.CalendarToken, error) {
+	var token models.CalendarToken
+	err := database(ctx).Where("token_hash = ? AND revoked_at IS NULL", hash).First(&token).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// GetCalendarTokensByUserID finds all calendar feed tokens of a user that have not been revoked
+func GetCalendarTokensByUserID(ctx context.Context, userID uuid.UUID) ([]models.CalendarToken, error) {
+	var tokens []models.CalendarToken
+	err := database(ctx).Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// UpdateCalendarTokenHash replaces the token of a feed, invalidating the old URL
+func UpdateCalendarTokenHash(ctx context.Context, id uuid.UUID, hash string) error {
+	return database(ctx).Model(&models.CalendarToken{}).Where("id = ?", id).Update("token_hash", hash).Error
+}
+
+// RevokeCalendarToken disables a calendar feed token
+func RevokeCalendarToken(ctx context.Context, id uuid.UUID) error {
+	return database(ctx).Model(&models.CalendarToken{}).Where("id = ?", id).Update("revoked_at", time.Now()).Error
+}
+
+// TouchCalendarToken records when a feed was last fetched
+func TouchCalendarToken(ctx context.Context, id uuid.UUID) error {
+	return database(ctx).Model(&models.CalendarToken{}).Where("id = ?", id).UpdateColumn("last_used_at", time.Now()).Error
+}
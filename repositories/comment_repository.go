@@ -6,6 +6,7 @@ import (
 	"github.com/azka-art/taskwise-backend/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CommentRepository defines all the methods to access comment data
@@ -13,9 +14,15 @@ type CommentRepository interface {
 	Create(comment *models.Comment) error
 	FindByID(id uuid.UUID) (*models.Comment, error)
 	FindByTaskID(taskID uuid.UUID) ([]models.Comment, error)
+	FindThreadsByTaskID(taskID uuid.UUID, page, size int) ([]models.Comment, int64, error)
 	Update(comment *models.Comment) error
+	UpdateWithRevision(comment *models.Comment, revision *models.CommentRevision) error
+	FindRevisions(commentID uuid.UUID) ([]models.CommentRevision, error)
 	Delete(id uuid.UUID) error
 	DeleteByTaskID(taskID uuid.UUID) error
+	AddReaction(reaction *models.CommentReaction) error
+	RemoveReaction(commentID, userID uuid.UUID, emoji string) error
+	CountReactions(commentIDs []uuid.UUID, userID uuid.UUID) (map[uuid.UUID][]models.ReactionCount, error)
 }
 
 // commentRepository implements the CommentRepository interface
@@ -56,17 +63,80 @@ func (r *commentRepository) FindByID(id uuid.UUID) (*models.Comment, error) {
 	return &comment, nil
 }
 
-// FindByTaskID finds all comments for a specific task
+// FindByTaskID finds all comments for a specific task as threads: top-level
+// comments oldest first, each with its replies oldest first
 func (r *commentRepository) FindByTaskID(taskID uuid.UUID) ([]models.Comment, error) {
 	var comments []models.Comment
 
 	// Find all comments for the task and preload User data
-	err := r.db.Preload("User").Where("task_id = ?", taskID).Order("created_at DESC").Find(&comments).Error
+	err := r.db.Preload("User").Where("task_id = ?", taskID).Order("created_at ASC, id ASC").Find(&comments).Error
 	if err != nil {
 		return nil, err
 	}
 
-	return comments, nil
+	return assembleThreads(comments), nil
+}
+
+// FindThreadsByTaskID finds a page of the top-level comments of a task, oldest
+// first, with all of their replies. The total counts the top-level comments.
+func (r *commentRepository) FindThreadsByTaskID(taskID uuid.UUID, page, size int) ([]models.Comment, int64, error) {
+	query := r.db.Model(&models.Comment{}).Where("task_id = ? AND parent_id IS NULL", taskID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var comments []models.Comment
+	err := query.Preload("User").
+		Order("created_at ASC, id ASC").
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&comments).Error
+	if err != nil || len(comments) == 0 {
+		return comments, total, err
+	}
+
+	ids := make([]uuid.UUID, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+
+	var replies []models.Comment
+	err = r.db.Preload("User").
+		Where("parent_id IN ?", ids).
+		Order("created_at ASC, id ASC").
+		Find(&replies).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return assembleThreads(append(comments, replies...)), total, nil
+}
+
+// assembleThreads nests replies under their parent comment, keeping the order of
+// the comments. Replies whose parent is gone are shown as top-level comments.
+func assembleThreads(comments []models.Comment) []models.Comment {
+	parents := make(map[uuid.UUID]bool, len(comments))
+	replies := make(map[uuid.UUID][]models.Comment)
+	for _, comment := range comments {
+		parents[comment.ID] = comment.ParentID == nil
+	}
+	for _, comment := range comments {
+		if comment.ParentID != nil && parents[*comment.ParentID] {
+			replies[*comment.ParentID] = append(replies[*comment.ParentID], comment)
+		}
+	}
+
+	threads := make([]models.Comment, 0, len(comments))
+	for _, comment := range comments {
+		if comment.ParentID != nil && parents[*comment.ParentID] {
+			continue
+		}
+		comment.Replies = replies[comment.ID]
+		threads = append(threads, comment)
+	}
+	return threads
 }
 
 // Update updates an existing comment
@@ -93,6 +163,31 @@ func (r *commentRepository) Update(comment *models.Comment) error {
 	}).Error
 }
 
+// UpdateWithRevision changes the content of a comment and keeps its previous
+// content as a revision, in a single transaction
+func (r *commentRepository) UpdateWithRevision(comment *models.Comment, revision *models.CommentRevision) error {
+	if err := comment.Validate(); err != nil {
+		return err
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+		return tx.Model(comment).Updates(map[string]interface{}{
+			"content":   comment.Content,
+			"edited_at": comment.EditedAt,
+		}).Error
+	})
+}
+
+// FindRevisions finds the previous contents of a comment, newest first
+func (r *commentRepository) FindRevisions(commentID uuid.UUID) ([]models.CommentRevision, error) {
+	var revisions []models.CommentRevision
+	err := r.db.Where("comment_id = ?", commentID).Order("created_at DESC").Find(&revisions).Error
+	return revisions, err
+}
+
 // Delete removes a comment from the database
 func (r *commentRepository) Delete(id uuid.UUID) error {
 	// Check if comment exists
@@ -105,10 +200,56 @@ func (r *commentRepository) Delete(id uuid.UUID) error {
 		return errors.New("comment not found")
 	}
 
-	return r.db.Delete(&models.Comment{}, id).Error
+	// Replies are removed with their thread
+	return r.db.Where("id = ? OR parent_id = ?", id, id).Delete(&models.Comment{}).Error
 }
 
 // DeleteByTaskID removes all comments for a specific task
 func (r *commentRepository) DeleteByTaskID(taskID uuid.UUID) error {
 	return r.db.Where("task_id = ?", taskID).Delete(&models.Comment{}).Error
 }
+
+// AddReaction adds a user's emoji reaction to a comment, ignoring duplicates
+func (r *commentRepository) AddReaction(reaction *models.CommentReaction) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction).Error
+}
+
+// RemoveReaction removes a user's emoji reaction from a comment
+func (r *commentRepository) RemoveReaction(commentID, userID uuid.UUID, emoji string) error {
+	return r.db.Where("comment_id = ? AND user_id = ? AND emoji = ?", commentID, userID, emoji).
+		Delete(&models.CommentReaction{}).Error
+}
+
+// CountReactions counts the reactions per emoji of the given comments, in the order
+// each emoji was first used, and marks the ones the user reacted with
+func (r *commentRepository) CountReactions(commentIDs []uuid.UUID, userID uuid.UUID) (map[uuid.UUID][]models.ReactionCount, error) {
+	counts := make(map[uuid.UUID][]models.ReactionCount)
+	if len(commentIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		CommentID   uuid.UUID
+		Emoji       string
+		Count       int
+		ReactedByMe bool
+	}
+	err := r.db.Model(&models.CommentReaction{}).
+		Select("comment_id, emoji, COUNT(*) AS count, BOOL_OR(user_id = ?) AS reacted_by_me", userID).
+		Where("comment_id IN ?", commentIDs).
+		Group("comment_id, emoji").
+		Order("MIN(created_at), emoji").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.CommentID] = append(counts[row.CommentID], models.ReactionCount{
+			Emoji:       row.Emoji,
+			Count:       row.Count,
+			ReactedByMe: row.ReactedByMe,
+		})
+	}
+	return counts, nil
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateEscalationPolicy saves a new escalation policy
+func CreateEscalationPolicy(ctx context.Context, policy *models.EscalationPolicy) error {
+	return database(ctx).Create(policy).Error
+}
+
+// GetEscalationPolicyByID finds an escalation policy by its ID
+func GetEscalationPolicyByID(ctx context.Context, id uuid.UUID) (*models.EscalationPolicy, error) {
+	var policy models.EscalationPolicy
+	err := database(ctx).Where("id = ?", id).First(&policy).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// GetEscalationPoliciesByProjectID finds all escalation policies of a project
+func GetEscalationPoliciesByProjectID(ctx context.Context, projectID uuid.UUID) ([]models.EscalationPolicy, error) {
+	var policies []models.EscalationPolicy
+	err := database(ctx).Where("project_id = ?", projectID).Order("overdue_hours, created_at").Find(&policies).Error
+	return policies, err
+}
+
+// GetActiveEscalationPolicies finds the escalation policies of every project that are switched on
+func GetActiveEscalationPolicies(ctx context.Context) ([]models.EscalationPolicy, error) {
+	var policies []models.EscalationPolicy
+	err := database(ctx).Where("active = ?", true).Order("overdue_hours, created_at").Find(&policies).Error
+	return policies, err
+}
+
+// UpdateEscalationPolicy saves the changes to an escalation policy
+func UpdateEscalationPolicy(ctx context.Context, policy *models.EscalationPolicy) error {
+	return updated(database(ctx).Model(policy).
+		Select("name", "overdue_hours", "action", "assignee_id", "active", "updated_at").
+		Updates(policy))
+}
+
+// DeleteEscalationPolicy deletes an escalation policy and its history of escalated tasks
+func DeleteEscalationPolicy(ctx context.Context, id uuid.UUID) error {
+	return database(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("policy_id = ?", id).Delete(&models.TaskEscalation{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.EscalationPolicy{}).Error
+	})
+}
+
+// FindTasksToEscalate finds the unfinished tasks of the policy's project that are
+// overdue by at least the policy's hours and that the policy has not acted on yet
+func FindTasksToEscalate(ctx context.Context, policy models.EscalationPolicy, now time.Time, limit int) ([]models.Task, error) {
+	threshold := now.Add(-time.Duration(policy.OverdueHours) * time.Hour)
+
+	var tasks []models.Task
+	err := database(ctx).
+		Where("project_id = ? AND deadline IS NOT NULL AND deadline <= ? AND status <> ?", policy.ProjectID, threshold, models.StatusDone).
+		Where("NOT EXISTS (SELECT 1 FROM task_escalations e WHERE e.policy_id = ? AND e.task_id = tasks.id AND e.deadline = tasks.deadline)", policy.ID).
+		Order("deadline").
+		Limit(limit).
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// ApplyTaskEscalation records an escalation and applies its changes to the task in
+// one transaction. It returns false without changing anything if the escalation
+// was already recorded, e.g. by another backend instance.
+func ApplyTaskEscalation(ctx context.Context, escalation *models.TaskEscalation, updates map[string]interface{}) (bool, error) {
+	applied := false
+	err := database(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(escalation)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		applied = true
+
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&models.Task{}).Where("id = ?", escalation.TaskID).Updates(updates).Error
+	})
+	return applied, err
+}
@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EventsChannel is the PostgreSQL NOTIFY channel new event IDs are sent on
+const EventsChannel = "taskwise_events"
+
+// CreateEvent stores an event and notifies every listening backend instance
+func CreateEvent(ctx context.Context, event *models.Event) error {
+	return database(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+
+		// The notification is only delivered once the transaction commits. It
+		// touches no organization data, so the raw SQL may run unscoped.
+		return tx.WithContext(AllOrganizations(ctx)).Exec("SELECT pg_notify(?, ?)", EventsChannel, event.ID).Error
+	})
+}
+
+// GetEventByID finds an event by its ID
+func GetEventByID(ctx context.Context, id int64) (*models.Event, error) {
+	var event models.Event
+	err := database(ctx).Where("id = ?", id).First(&event).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// GetEventsAfter returns events with an ID greater than afterID, oldest first
+func GetEventsAfter(ctx context.Context, afterID int64, limit int) ([]models.Event, error) {
+	var events []models.Event
+	err := database(ctx).Where("id > ?", afterID).Order("id").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// GetTaskStatusTransitions returns the status changes of a task, oldest first
+func GetTaskStatusTransitions(ctx context.Context, taskID uuid.UUID) ([]models.StatusTransition, error) {
+	var transitions []models.StatusTransition
+	err := database(ctx).Model(&models.Event{}).
+		Select(`task_id, payload->>'from' AS "from", payload->>'to' AS "to", created_at AS at`).
+		Where("type = ? AND task_id = ?", models.EventTaskStatusChanged, taskID).
+		Order("id").
+		Scan(&transitions).Error
+	return transitions, err
+}
+
+// FindProjectEvents returns a page of a project's events, newest first, and the
+// total number of events matching the filter
+func FindProjectEvents(ctx context.Context, projectID uuid.UUID, filter models.ActivityFilter, page, size int) ([]models.Event, int64, error) {
+	query := database(ctx).Model(&models.Event{}).Where("project_id = ?", projectID)
+	if len(filter.Types) > 0 {
+		query = query.Where("type IN ?", filter.Types)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.TaskID != nil {
+		query = query.Where("task_id = ?", *filter.TaskID)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []models.Event
+	err := query.Order("id DESC").Offset((page - 1) * size).Limit(size).Find(&events).Error
+	return events, total, err
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetUserIdentity mencari identitas SSO berdasarkan provider dan subject
+func GetUserIdentity(ctx context.Context, provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := database(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+// CreateUserIdentity menghubungkan identitas SSO dengan pengguna
+func CreateUserIdentity(ctx context.Context, identity *models.UserIdentity) error {
+	return database(ctx).Create(identity).Error
+}
+
+// HasUserIdentities checks if a user signs in through single sign-on
+func HasUserIdentities(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := database(ctx).Model(&models.UserIdentity{}).Where("user_id = ?", userID).Count(&count).Error
+	return count > 0, err
+}
+
+// TouchUserIdentity records a sign-in with an identity
+func TouchUserIdentity(ctx context.Context, id uuid.UUID, email string, at time.Time) error {
+	return database(ctx).Model(&models.UserIdentity{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"email":         email,
+		"last_login_at": at,
+	}).Error
+}
+
+// CreateOIDCLoginState menyimpan state login SSO baru
+func CreateOIDCLoginState(ctx context.Context, state *models.OIDCLoginState) error {
+	return database(ctx).Create(state).Error
+}
+
+// ConsumeOIDCLoginState deletes and returns the login state with the given hash,
+// so that every state can be used only once. It returns nil if there is none.
+func ConsumeOIDCLoginState(ctx context.Context, stateHash string) (*models.OIDCLoginState, error) {
+	var states []models.OIDCLoginState
+	err := database(ctx).Clauses(clause.Returning{}).
+		Where("state_hash = ?", stateHash).
+		Delete(&states).Error
+	if err != nil || len(states) == 0 {
+		return nil, err
+	}
+	return &states[0], nil
+}
+
+// DeleteExpiredOIDCLoginStates menghapus state login SSO yang sudah kedaluwarsa
+func DeleteExpiredOIDCLoginStates(ctx context.Context, before time.Time) error {
+	return database(ctx).Where("expires_at < ?", before).Delete(&models.OIDCLoginState{}).Error
+}
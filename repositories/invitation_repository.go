@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateInvitation menyimpan undangan baru dan mencabut undangan lama yang masih
+// berlaku untuk email dan project yang sama
+func CreateInvitation(ctx context.Context, invitation *models.Invitation) error {
+	return database(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&models.Invitation{}).
+			Where("email = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitation.Email)
+		if invitation.ProjectID != nil {
+			query = query.Where("project_id = ?", *invitation.ProjectID)
+		} else {
+			query = query.Where("project_id IS NULL")
+		}
+		if err := query.Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(invitation).Error
+	})
+}
+
+// GetInvitationByID mencari undangan berdasarkan ID
+func GetInvitationByID(ctx context.Context, id uuid.UUID) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := database(ctx).Where("id = ?", id).First(&invitation).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// GetInvitationByHash mencari undangan berdasarkan hash token
+func GetInvitationByHash(ctx context.Context, hash string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := database(ctx).Where("token_hash = ?", hash).First(&invitation).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// GetPendingInvitations mengambil undangan yang belum diterima, dicabut atau
+// kedaluwarsa, opsional hanya dari satu pengundang atau untuk satu project
+func GetPendingInvitations(ctx context.Context, invitedBy, projectID *uuid.UUID, now time.Time) ([]models.Invitation, error) {
+	query := database(ctx).
+		Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", now)
+	if invitedBy != nil {
+		query = query.Where("invited_by = ?", *invitedBy)
+	}
+	if projectID != nil {
+		query = query.Where("project_id = ?", *projectID)
+	}
+
+	var invitations []models.Invitation
+	err := query.Order("created_at DESC").Find(&invitations).Error
+	return invitations, err
+}
+
+// RevokeInvitation mencabut undangan yang belum diterima
+func RevokeInvitation(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return database(ctx).Model(&models.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Update("revoked_at", at).Error
+}
+
+// MarkInvitationAccepted menandai undangan sebagai diterima oleh user. Mengembalikan
+// false jika undangan sudah dipakai atau dicabut.
+func MarkInvitationAccepted(ctx context.Context, id, userID uuid.UUID, at time.Time) (bool, error) {
+	result := database(ctx).Model(&models.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"accepted_at": at, "accepted_by": userID})
+	return result.RowsAffected > 0, result.Error
+}
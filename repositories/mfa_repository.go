@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetUserMFA mencari pengaturan 2FA pengguna
+func GetUserMFA(ctx context.Context, userID uuid.UUID) (*models.UserMFA, error) {
+	var mfa models.UserMFA
+	err := database(ctx).Where("user_id = ?", userID).First(&mfa).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &mfa, nil
+}
+
+// CreateUserMFA menyimpan pengaturan 2FA baru pengguna
+func CreateUserMFA(ctx context.Context, mfa *models.UserMFA) error {
+	return database(ctx).Create(mfa).Error
+}
+
+// UpdateUserMFA replaces the 2FA settings of a user, e.g. with a new enrollment
+func UpdateUserMFA(ctx context.Context, mfa *models.UserMFA) error {
+	return updated(database(ctx).Model(mfa).
+		Select("secret", "enabled_at", "last_used_step", "failed_attempts", "locked_until", "updated_at").
+		Updates(mfa))
+}
+
+// EnableUserMFA turns on a confirmed enrollment and replaces the recovery codes of
+// the user. It returns false if 2FA was already enabled, e.g. by a concurrent request.
+func EnableUserMFA(ctx context.Context, userID uuid.UUID, at time.Time, step int64, codes []models.MFARecoveryCode) (bool, error) {
+	enabled := false
+	err := database(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.UserMFA{}).
+			Where("user_id = ? AND enabled_at IS NULL", userID).
+			Updates(map[string]interface{}{"enabled_at": at, "last_used_step": step})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		enabled = true
+
+		return replaceRecoveryCodes(tx, userID, codes)
+	})
+	return enabled, err
+}
+
+// UseTOTPStep records that a code of the given time step was accepted. It returns
+// false if a code of that or a later step was already used.
+func UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	result := database(ctx).Model(&models.UserMFA{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// RecordMFAFailure counts a wrong code of a user and returns how many wrong codes
+// were sent since the last accepted one. Concurrent requests are counted one by
+// one, as the update locks the row.
+func RecordMFAFailure(ctx context.Context, userID uuid.UUID) (int, error) {
+	var mfa models.UserMFA
+	err := database(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.UserMFA{}).
+			Where("user_id = ?", userID).
+			UpdateColumn("failed_attempts", gorm.Expr("failed_attempts + 1")).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).First(&mfa).Error
+	})
+	return mfa.FailedAttempts, err
+}
+
+// LockUserMFA stops checking the codes of a user until the given moment
+func LockUserMFA(ctx context.Context, userID uuid.UUID, until time.Time) error {
+	return database(ctx).Model(&models.UserMFA{}).
+		Where("user_id = ?", userID).
+		UpdateColumn("locked_until", until).Error
+}
+
+// ResetMFAFailures forgets the wrong codes of a user after a code was accepted
+func ResetMFAFailures(ctx context.Context, userID uuid.UUID) error {
+	return database(ctx).Model(&models.UserMFA{}).
+		Where("user_id = ? AND failed_attempts > 0", userID).
+		UpdateColumns(map[string]interface{}{"failed_attempts": 0, "locked_until": nil}).Error
+}
+
+// ReplaceRecoveryCodes mengganti semua recovery code pengguna
+func ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codes []models.MFARecoveryCode) error {
+	return database(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codes)
+	})
+}
+
+// UseRecoveryCode marks an unused recovery code of a user as used. It returns
+// false if the user has no such unused code.
+func UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, at time.Time) (bool, error) {
+	result := database(ctx).Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CountUnusedRecoveryCodes menghitung recovery code yang belum dipakai
+func CountUnusedRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := database(ctx).Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteUserMFA menghapus 2FA pengguna beserta recovery code-nya
+func DeleteUserMFA(ctx context.Context, userID uuid.UUID) error {
+	return database(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.UserMFA{}).Error
+	})
+}
+
+// replaceRecoveryCodes deletes the recovery codes of a user and stores new ones
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID, codes []models.MFARecoveryCode) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return err
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateOrganization menyimpan organisasi baru dengan owner sebagai admin
+func CreateOrganization(ctx context.Context, organization *models.Organization, ownerID uuid.UUID) error {
+	return database(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(organization).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.OrganizationMember{
+			OrganizationID: organization.ID,
+			UserID:         ownerID,
+			Role:           models.RoleAdmin,
+		}).Error
+	})
+}
+
+// GetOrganizationByID mencari organisasi berdasarkan ID
+func GetOrganizationByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	var organization models.Organization
+	err := database(ctx).Where("id = ?", id).First(&organization).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
+
+// AddOrganizationMember menambahkan user ke organisasi, atau tidak melakukan
+// apa-apa jika user sudah menjadi anggota
+func AddOrganizationMember(ctx context.Context, member *models.OrganizationMember) error {
+	return database(ctx).
+		Where("organization_id = ? AND user_id = ?", member.OrganizationID, member.UserID).
+		FirstOrCreate(member).Error
+}
+
+// GetOrganizationMember mencari keanggotaan user di organisasi
+func GetOrganizationMember(ctx context.Context, organizationID, userID uuid.UUID) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	err := database(ctx).
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// SetMemberMFARequired menentukan apakah anggota organisasi wajib memakai 2FA
+func SetMemberMFARequired(ctx context.Context, organizationID, userID uuid.UUID, required bool) error {
+	return updated(database(ctx).Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		Update("mfa_required", required))
+}
+
+// IsMFARequiredInAnyOrganization checks if an organization the user belongs to
+// requires them to use 2FA
+func IsMFARequiredInAnyOrganization(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := database(ctx).Model(&models.OrganizationMember{}).
+		Where("user_id = ? AND mfa_required", userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// MoveMFARequirementToMembers requires 2FA in every organization of the users
+// that were required to use it before the requirement belonged to memberships
+func MoveMFARequirementToMembers(ctx context.Context) error {
+	return database(ctx).Exec(`UPDATE organization_members SET mfa_required = true
+		WHERE user_id IN (SELECT id FROM users WHERE mfa_required)`).Error
+}
+
+// GetFirstOrganizationMember mencari keanggotaan pertama user, yaitu organisasi
+// yang dibuka saat login
+func GetFirstOrganizationMember(ctx context.Context, userID uuid.UUID) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	err := database(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at").
+		First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetOrganizationMembersByUserID mengambil semua keanggotaan user beserta organisasinya
+func GetOrganizationMembersByUserID(ctx context.Context, userID uuid.UUID) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	err := database(ctx).
+		Preload("Organization").
+		Where("user_id = ?", userID).
+		Order("created_at").
+		Find(&members).Error
+	return members, err
+}
+
+// HasOrganizations memeriksa apakah sudah ada organisasi
+func HasOrganizations(ctx context.Context) (bool, error) {
+	var count int64
+	err := database(ctx).Model(&models.Organization{}).Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+// MoveExistingDataToOrganization memindahkan semua user dan data tanpa organisasi
+// ke organisasi yang diberikan, dengan role user sebagai role keanggotaan
+func MoveExistingDataToOrganization(ctx context.Context, organizationID uuid.UUID) error {
+	return database(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO organization_members (organization_id, user_id, role, created_at)
+			SELECT ?, id, role::text, created_at FROM users
+			ON CONFLICT DO NOTHING`, organizationID).Error
+		if err != nil {
+			return err
+		}
+
+		for table := range organizationTables {
+			err := tx.Exec("UPDATE "+table+" SET organization_id = ? WHERE organization_id IS NULL", organizationID).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateProject saves a new project and makes its owner a project admin
+func CreateProject(ctx context.Context, project *models.Project) error {
+	if err := project.Validate(); err != nil {
+		return err
+	}
+
+	return database(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(project).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.ProjectMember{
+			ProjectID: project.ID,
+			UserID:    project.OwnerID,
+			Role:      models.ProjectRoleAdmin,
+		}).Error
+	})
+}
+
+// GetProjectByID finds a project by its ID
+func GetProjectByID(ctx context.Context, id uuid.UUID) (*models.Project, error) {
+	var project models.Project
+	err := database(ctx).Preload("Members").Preload("Members.User").Where("id = ?", id).First(&project).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+// GetProjectsByUserID finds all projects the user is a member of
+func GetProjectsByUserID(ctx context.Context, userID uuid.UUID) ([]models.Project, error) {
+	var projects []models.Project
+	err := database(ctx).
+		Joins("JOIN project_members ON project_members.project_id = projects.id").
+		Where("project_members.user_id = ?", userID).
+		Order("projects.created_at DESC").
+		Find(&projects).Error
+	return projects, err
+}
+
+// GetProjectIDsByUserID returns the IDs of all projects the user is a member of
+func GetProjectIDsByUserID(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := database(ctx).Model(&models.ProjectMember{}).Where("user_id = ?", userID).Pluck("project_id", &ids).Error
+	return ids, err
+}
+
+// GetProjectAdminIDs returns the IDs of the admins (leads) of a project
+func GetProjectAdminIDs(ctx context.Context, projectID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := database(ctx).Model(&models.ProjectMember{}).
+		Where("project_id = ? AND role = ?", projectID, models.ProjectRoleAdmin).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// GetProjectMember finds the membership of a user in a project
+func GetProjectMember(ctx context.Context, projectID, userID uuid.UUID) (*models.ProjectMember, error) {
+	var member models.ProjectMember
+	err := database(ctx).Where("project_id = ? AND user_id = ?", projectID, userID).First(&member).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// AddProjectMember adds a user to a project, or updates the role of an existing member
+func AddProjectMember(ctx context.Context, member *models.ProjectMember) error {
+	if member.Role != models.ProjectRoleAdmin && member.Role != models.ProjectRoleMember {
+		return errors.New("invalid project role")
+	}
+
+	existing, err := GetProjectMember(ctx, member.ProjectID, member.UserID)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return database(ctx).Model(&models.ProjectMember{}).
+			Where("project_id = ? AND user_id = ?", member.ProjectID, member.UserID).
+			Update("role", member.Role).Error
+	}
+
+	return database(ctx).Create(member).Error
+}
@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetBusinessHours finds the business hours of a project
+func GetBusinessHours(ctx context.Context, projectID uuid.UUID) (*models.BusinessHours, error) {
+	var hours models.BusinessHours
+	err := database(ctx).Where("project_id = ?", projectID).First(&hours).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &hours, nil
+}
+
+// CreateBusinessHours sets the business hours of a project that has none yet
+func CreateBusinessHours(ctx context.Context, hours *models.BusinessHours) error {
+	return database(ctx).Create(hours).Error
+}
+
+// UpdateBusinessHours replaces the business hours of a project
+func UpdateBusinessHours(ctx context.Context, hours *models.BusinessHours) error {
+	return updated(database(ctx).Model(hours).
+		Select("timezone", "day_start", "day_end", "workdays", "holidays", "updated_at").
+		Updates(hours))
+}
+
+// GetSLAPolicyByID finds an SLA policy by its ID
+func GetSLAPolicyByID(ctx context.Context, id uuid.UUID) (*models.SLAPolicy, error) {
+	return findSLAPolicy(database(ctx).Where("id = ?", id))
+}
+
+// GetSLAPolicy finds the SLA policy of a project for a priority
+func GetSLAPolicy(ctx context.Context, projectID uuid.UUID, priority models.Priority) (*models.SLAPolicy, error) {
+	return findSLAPolicy(database(ctx).Where("project_id = ? AND priority = ?", projectID, priority))
+}
+
+// GetSLAPoliciesByProjectID finds all SLA policies of a project
+func GetSLAPoliciesByProjectID(ctx context.Context, projectID uuid.UUID) ([]models.SLAPolicy, error) {
+	var policies []models.SLAPolicy
+	err := database(ctx).Where("project_id = ?", projectID).Order("priority").Find(&policies).Error
+	return policies, err
+}
+
+// CreateSLAPolicy creates an SLA policy
+func CreateSLAPolicy(ctx context.Context, policy *models.SLAPolicy) error {
+	return database(ctx).Create(policy).Error
+}
+
+// UpdateSLAPolicy saves the changed targets of an SLA policy
+func UpdateSLAPolicy(ctx context.Context, policy *models.SLAPolicy) error {
+	return updated(database(ctx).Model(policy).
+		Select("response_target", "resolution_target", "updated_at").
+		Updates(policy))
+}
+
+// DeleteSLAPolicy deletes an SLA policy
+func DeleteSLAPolicy(ctx context.Context, id uuid.UUID) error {
+	return database(ctx).Where("id = ?", id).Delete(&models.SLAPolicy{}).Error
+}
+
+// findSLAPolicy runs a query for a single SLA policy
+func findSLAPolicy(query *gorm.DB) (*models.SLAPolicy, error) {
+	var policy models.SLAPolicy
+	err := query.First(&policy).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// GetTaskSLA finds the SLA clock of a task
+func GetTaskSLA(ctx context.Context, taskID uuid.UUID) (*models.TaskSLA, error) {
+	var sla models.TaskSLA
+	err := database(ctx).Where("task_id = ?", taskID).First(&sla).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &sla, nil
+}
+
+// GetOpenTaskSLAs finds the SLA clocks of a project's tasks that are not done
+func GetOpenTaskSLAs(ctx context.Context, projectID uuid.UUID) ([]models.TaskSLA, error) {
+	var slas []models.TaskSLA
+	err := database(ctx).
+		Where("project_id = ? AND resolved_at IS NULL", projectID).
+		Where("EXISTS (SELECT 1 FROM tasks WHERE tasks.id = task_slas.task_id AND tasks.deleted_at IS NULL)").
+		Find(&slas).Error
+	return slas, err
+}
+
+// CreateTaskSLA starts the SLA clock of a task
+func CreateTaskSLA(ctx context.Context, sla *models.TaskSLA) error {
+	return database(ctx).Create(sla).Error
+}
+
+// UpdateTaskSLA saves the changes to the SLA clock of a task
+func UpdateTaskSLA(ctx context.Context, sla *models.TaskSLA) error {
+	return updated(database(ctx).Model(sla).
+		Select("project_id", "policy_id", "response_due", "resolution_due", "responded_at", "resolved_at",
+			"response_breached_at", "resolution_breached_at", "updated_at").
+		Updates(sla))
+}
+
+// DeleteTaskSLA removes the SLA clock of a task
+func DeleteTaskSLA(ctx context.Context, taskID uuid.UUID) error {
+	return database(ctx).Where("task_id = ?", taskID).Delete(&models.TaskSLA{}).Error
+}
+
+// FindBreachedTaskSLAs finds SLA clocks with a target that was missed but not reported yet
+func FindBreachedTaskSLAs(ctx context.Context, now time.Time, limit int) ([]models.TaskSLA, error) {
+	var slas []models.TaskSLA
+	err := database(ctx).
+		Where("(response_breached_at IS NULL AND response_due < COALESCE(responded_at, ?)) OR "+
+			"(resolution_breached_at IS NULL AND resolution_due < COALESCE(resolved_at, ?))", now, now).
+		Limit(limit).
+		Find(&slas).Error
+	return slas, err
+}
+
+// MarkTaskSLABreached records that a target ("response" or "resolution") of a task was
+// missed. It returns false if the breach was already recorded, e.g. by another instance.
+func MarkTaskSLABreached(ctx context.Context, taskID uuid.UUID, target string, at time.Time) (bool, error) {
+	column := target + "_breached_at"
+	result := database(ctx).Model(&models.TaskSLA{}).
+		Where("task_id = ? AND "+column+" IS NULL", taskID).
+		Update(column, at)
+	return result.RowsAffected > 0, result.Error
+}
@@ -1,16 +1,19 @@
 package repositories
 
 import (
+	"context"
 	"errors"
+	"time"
 
-	"github.com/azka-art/taskwise-backend/config"
 	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/utils"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CreateTask menambahkan tugas baru ke database
-func CreateTask(task *models.Task) error {
+func CreateTask(ctx context.Context, task *models.Task) error {
 	// Set default values if needed
 	if task.Priority == "" {
 		task.Priority = models.PriorityMedium
@@ -30,41 +33,318 @@ func CreateTask(task *models.Task) error {
 		return err
 	}
 
-	return config.DB.Create(task).Error
+	return database(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := assignTaskRank(tx, task); err != nil {
+			return err
+		}
+		return tx.Create(task).Error
+	})
 }
 
 // GetAllTasks mengambil semua tugas
-func GetAllTasks() ([]models.Task, error) {
+func GetAllTasks(ctx context.Context) ([]models.Task, error) {
 	var tasks []models.Task
-	err := config.DB.Preload("User").Find(&tasks).Error
+	err := database(ctx).Preload("User").Find(&tasks).Error
 	return tasks, err
 }
 
+// taskSortColumns are the SQL expressions behind the task sort fields. Priority
+// and status sort by their level rather than alphabetically.
+var taskSortColumns = map[string]string{
+	"rank":       "tasks.rank",
+	"title":      "tasks.title",
+	"priority":   "CASE tasks.priority WHEN 'Low' THEN 1 WHEN 'Medium' THEN 2 WHEN 'High' THEN 3 END",
+	"status":     "CASE tasks.status WHEN 'Pending' THEN 1 WHEN 'In Progress' THEN 2 WHEN 'Done' THEN 3 END",
+	"deadline":   "tasks.deadline",
+	"created_at": "tasks.created_at",
+	"updated_at": "tasks.updated_at",
+}
+
+// FindTasks mengambil tugas yang cocok dengan filter, dalam urutan manual
+func FindTasks(ctx context.Context, filter models.TaskFilter) ([]models.Task, error) {
+	return FindSortedTasks(ctx, filter, nil)
+}
+
+// FindSortedTasks mengambil tugas yang cocok dengan filter dengan urutan tertentu.
+// Tanpa urutan, tugas diurutkan berdasarkan rank.
+func FindSortedTasks(ctx context.Context, filter models.TaskFilter, sort []models.TaskSort) ([]models.Task, error) {
+	query := ApplyTaskFilter(database(ctx).Model(&models.Task{}), filter).
+		Preload("User").
+		Preload("Labels")
+
+	for _, key := range sort {
+		column, ok := taskSortColumns[key.Field]
+		if !ok {
+			continue
+		}
+		if key.Desc {
+			query = query.Order(column + " DESC NULLS LAST")
+		} else {
+			query = query.Order(column + " ASC NULLS LAST")
+		}
+	}
+
+	var tasks []models.Task
+	err := query.
+		Order("tasks.rank").
+		Order("tasks.created_at DESC").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// FindTasksInBatches calls fn with consecutive batches of the tasks matching the filter,
+// so large result sets can be streamed without loading them at once
+func FindTasksInBatches(ctx context.Context, filter models.TaskFilter, batchSize int, fn func(tasks []models.Task) error) error {
+	var tasks []models.Task
+	return ApplyTaskFilter(database(ctx).Model(&models.Task{}), filter).
+		Preload("Labels").
+		FindInBatches(&tasks, batchSize, func(tx *gorm.DB, batch int) error {
+			return fn(tasks)
+		}).Error
+}
+
+// CreateTasks menyimpan beberapa tugas sekaligus; gagal satu, gagal semua
+func CreateTasks(ctx context.Context, tasks []models.Task) error {
+	return CreateTasksWithComments(ctx, tasks, nil)
+}
+
+// CreateTasksWithComments menyimpan tugas beserta komentarnya dalam satu transaksi
+func CreateTasksWithComments(ctx context.Context, tasks []models.Task, comments []models.Comment) error {
+	return database(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range tasks {
+			if err := assignTaskRank(tx, &tasks[i]); err != nil {
+				return err
+			}
+			if err := tx.Create(&tasks[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range comments {
+			if err := tx.Create(&comments[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetLastTaskRank mengambil rank terakhir di sebuah kolom status
+func GetLastTaskRank(ctx context.Context, projectID *uuid.UUID, status models.Status) (string, error) {
+	return lastTaskRank(database(ctx), projectID, status)
+}
+
+// GetAdjacentTask mengambil tugas tepat sebelum (before = true) atau sesudah
+// sebuah rank di kolom status, tanpa tugas excludeID. Rank kosong berarti
+// tugas terakhir atau pertama di kolom.
+func GetAdjacentTask(ctx context.Context, projectID *uuid.UUID, status models.Status, rank string, before bool, excludeID uuid.UUID) (*models.Task, error) {
+	query := taskColumn(database(ctx).Model(&models.Task{}), projectID, status).Where("id <> ?", excludeID)
+	if before {
+		if rank != "" {
+			query = query.Where("rank < ?", rank)
+		}
+		query = query.Order("rank DESC")
+	} else {
+		if rank != "" {
+			query = query.Where("rank > ?", rank)
+		}
+		query = query.Order("rank")
+	}
+
+	var task models.Task
+	if err := query.First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &task, nil
+}
+
+// UpdateTaskPosition memindahkan tugas ke kolom status dan rank baru
+func UpdateTaskPosition(ctx context.Context, id uuid.UUID, status models.Status, rank string) error {
+	return database(ctx).Model(&models.Task{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status": status,
+		"rank":   rank,
+	}).Error
+}
+
+// RebalanceTaskRanks memberi rank baru yang berjarak sama ke semua tugas di
+// sebuah kolom status, dengan urutan yang tetap sama
+func RebalanceTaskRanks(ctx context.Context, projectID *uuid.UUID, status models.Status) error {
+	return database(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uuid.UUID
+		err := taskColumn(tx.Model(&models.Task{}), projectID, status).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Order("rank = ''"). // Tasks without a rank yet go last
+			Order("rank").
+			Order("created_at").
+			Pluck("id", &ids).Error
+		if err != nil {
+			return err
+		}
+
+		for i, rank := range utils.EvenRanks(len(ids)) {
+			if err := tx.Model(&models.Task{}).Where("id = ?", ids[i]).Update("rank", rank).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetUnrankedTaskColumns mengambil kolom status (organisasi, project dan status) yang
+// masih memiliki tugas tanpa rank
+func GetUnrankedTaskColumns(ctx context.Context) ([]models.Task, error) {
+	var columns []models.Task
+	err := database(ctx).Model(&models.Task{}).
+		Distinct("organization_id", "project_id", "status").
+		Where("rank = ''").
+		Find(&columns).Error
+	return columns, err
+}
+
+// assignTaskRank puts a new task without a rank at the end of its status column
+func assignTaskRank(tx *gorm.DB, task *models.Task) error {
+	if task.Rank != "" {
+		return nil
+	}
+
+	status := task.Status
+	if status == "" {
+		status = models.StatusPending
+	}
+
+	last, err := lastTaskRank(tx, task.ProjectID, status)
+	if err != nil {
+		return err
+	}
+
+	task.Rank, err = utils.RankBetween(last, "")
+	return err
+}
+
+// lastTaskRank returns the highest rank in a status column
+func lastTaskRank(db *gorm.DB, projectID *uuid.UUID, status models.Status) (string, error) {
+	var ranks []string
+	err := taskColumn(db.Model(&models.Task{}), projectID, status).
+		Where("rank <> ''").
+		Order("rank DESC").
+		Limit(1).
+		Pluck("rank", &ranks).Error
+	if err != nil || len(ranks) == 0 {
+		return "", err
+	}
+	return ranks[0], nil
+}
+
+// taskColumn limits a query to the tasks of one status column. Tasks outside of
+// a project share one set of columns.
+func taskColumn(query *gorm.DB, projectID *uuid.UUID, status models.Status) *gorm.DB {
+	if projectID == nil {
+		query = query.Where("project_id IS NULL")
+	} else {
+		query = query.Where("project_id = ?", *projectID)
+	}
+	return query.Where("status = ?", status)
+}
+
+// ApplyTaskFilter adds the conditions of a task filter to a query on the tasks table
+func ApplyTaskFilter(query *gorm.DB, filter models.TaskFilter) *gorm.DB {
+	if filter.ProjectID != nil {
+		query = query.Where("tasks.project_id = ?", *filter.ProjectID)
+	}
+
+	if filter.AssigneeID != nil {
+		query = query.Where("tasks.assignee_id = ?", *filter.AssigneeID)
+	}
+
+	if filter.Label != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM task_labels WHERE task_labels.task_id = tasks.id AND task_labels.name = ?)", filter.Label)
+	}
+
+	if filter.Status != "" {
+		query = query.Where("tasks.status = ?", filter.Status)
+	}
+
+	if filter.Priority != "" {
+		query = query.Where("tasks.priority = ?", filter.Priority)
+	}
+
+	if filter.HasDeadline {
+		query = query.Where("tasks.deadline IS NOT NULL")
+	}
+
+	if filter.Overdue {
+		query = query.Where("tasks.deadline < ? AND tasks.status <> ?", time.Now(), models.StatusDone)
+	}
+
+	if filter.VisibleTo != nil {
+		query = query.Where(
+			"tasks.project_id IS NULL OR tasks.project_id IN (SELECT project_id FROM project_members WHERE user_id = ?)",
+			*filter.VisibleTo,
+		)
+	}
+
+	return query
+}
+
+// ReplaceTaskLabels mengganti semua label sebuah tugas
+func ReplaceTaskLabels(ctx context.Context, taskID uuid.UUID, names []string) ([]models.TaskLabel, error) {
+	labels := make([]models.TaskLabel, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		labels = append(labels, models.TaskLabel{TaskID: taskID, Name: name})
+	}
+
+	err := database(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("task_id = ?", taskID).Delete(&models.TaskLabel{}).Error; err != nil {
+			return err
+		}
+		if len(labels) == 0 {
+			return nil
+		}
+		return tx.Create(&labels).Error
+	})
+
+	return labels, err
+}
+
+// GetTaskLabels mengambil label sebuah tugas
+func GetTaskLabels(ctx context.Context, taskID uuid.UUID) ([]models.TaskLabel, error) {
+	var labels []models.TaskLabel
+	err := database(ctx).Where("task_id = ?", taskID).Order("name").Find(&labels).Error
+	return labels, err
+}
+
 // GetTasksByUserID mengambil tugas berdasarkan user ID
-func GetTasksByUserID(userID uuid.UUID) ([]models.Task, error) {
+func GetTasksByUserID(ctx context.Context, userID uuid.UUID) ([]models.Task, error) {
 	var tasks []models.Task
-	err := config.DB.Where("created_by = ?", userID).Preload("User").Find(&tasks).Error
+	err := database(ctx).Where("created_by = ?", userID).Preload("User").Find(&tasks).Error
 	return tasks, err
 }
 
 // GetTasksByStatus mengambil tugas berdasarkan status
-func GetTasksByStatus(status models.Status) ([]models.Task, error) {
+func GetTasksByStatus(ctx context.Context, status models.Status) ([]models.Task, error) {
 	var tasks []models.Task
-	err := config.DB.Where("status = ?", status).Preload("User").Find(&tasks).Error
+	err := database(ctx).Where("status = ?", status).Preload("User").Find(&tasks).Error
 	return tasks, err
 }
 
 // GetTasksByPriority mengambil tugas berdasarkan prioritas
-func GetTasksByPriority(priority models.Priority) ([]models.Task, error) {
+func GetTasksByPriority(ctx context.Context, priority models.Priority) ([]models.Task, error) {
 	var tasks []models.Task
-	err := config.DB.Where("priority = ?", priority).Preload("User").Find(&tasks).Error
+	err := database(ctx).Where("priority = ?", priority).Preload("User").Find(&tasks).Error
 	return tasks, err
 }
 
 // GetTaskWithComments mengambil tugas dengan komentar
-func GetTaskWithComments(id uuid.UUID) (*models.Task, error) {
+func GetTaskWithComments(ctx context.Context, id uuid.UUID) (*models.Task, error) {
 	var task models.Task
-	err := config.DB.Preload("Comments").Preload("Comments.User").Preload("User").Where("id = ?", id).First(&task).Error
+	err := database(ctx).Preload("Comments").Preload("Comments.User").Preload("User").Where("id = ?", id).First(&task).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -77,9 +357,9 @@ func GetTaskWithComments(id uuid.UUID) (*models.Task, error) {
 }
 
 // GetTaskByID mencari tugas berdasarkan ID
-func GetTaskByID(id uuid.UUID) (*models.Task, error) {
+func GetTaskByID(ctx context.Context, id uuid.UUID) (*models.Task, error) {
 	var task models.Task
-	err := config.DB.Preload("User").Where("id = ?", id).First(&task).Error
+	err := database(ctx).Preload("User").Where("id = ?", id).First(&task).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -92,40 +372,26 @@ func GetTaskByID(id uuid.UUID) (*models.Task, error) {
 }
 
 // UpdateTask memperbarui tugas berdasarkan ID
-func UpdateTask(task *models.Task) error {
-	// Check if task exists
-	var count int64
-	if err := config.DB.Model(&models.Task{}).Where("id = ?", task.ID).Count(&count).Error; err != nil {
-		return err
-	}
-
-	if count == 0 {
-		return errors.New("task not found")
-	}
-
+func UpdateTask(ctx context.Context, task *models.Task) error {
 	// Validate task before updating
 	if err := validateTask(task); err != nil {
 		return err
 	}
 
 	// Update only specific fields to prevent overwriting data that shouldn't be changed
-	return config.DB.Model(&models.Task{}).Where("id = ?", task.ID).Updates(map[string]interface{}{
-		"title":       task.Title,
-		"description": task.Description,
-		"priority":    task.Priority,
-		"status":      task.Status,
-		"deadline":    task.Deadline,
-	}).Error
+	return updated(database(ctx).Model(task).
+		Select("title", "description", "priority", "status", "rank", "deadline", "assignee_id", "updated_at").
+		Updates(task))
 }
 
 // UpdateTaskStatus memperbarui status tugas
-func UpdateTaskStatus(id uuid.UUID, status models.Status) error {
+func UpdateTaskStatus(ctx context.Context, id uuid.UUID, status models.Status) error {
 	// Validate status
 	if status != models.StatusPending && status != models.StatusInProgress && status != models.StatusDone {
 		return errors.New("invalid status value")
 	}
 
-	result := config.DB.Model(&models.Task{}).Where("id = ?", id).Update("status", status)
+	result := database(ctx).Model(&models.Task{}).Where("id = ?", id).Update("status", status)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -138,9 +404,9 @@ func UpdateTaskStatus(id uuid.UUID, status models.Status) error {
 }
 
 // DeleteTask menghapus tugas berdasarkan ID
-func DeleteTask(id uuid.UUID) error {
+func DeleteTask(ctx context.Context, id uuid.UUID) error {
 	// Begin a transaction to delete the task and its comments
-	tx := config.DB.Begin()
+	tx := database(ctx).Begin()
 
 	// Delete all comments associated with the task
 	if err := tx.Where("task_id = ?", id).Delete(&models.Comment{}).Error; err != nil {
@@ -164,19 +430,19 @@ func DeleteTask(id uuid.UUID) error {
 }
 
 // GetTaskCount menghitung jumlah total tugas
-func GetTaskCount() (int64, error) {
+func GetTaskCount(ctx context.Context) (int64, error) {
 	var count int64
-	err := config.DB.Model(&models.Task{}).Count(&count).Error
+	err := database(ctx).Model(&models.Task{}).Count(&count).Error
 	return count, err
 }
 
 // GetPaginatedTasks mengambil tugas dengan pagination
-func GetPaginatedTasks(page, size int) ([]models.Task, int64, error) {
+func GetPaginatedTasks(ctx context.Context, page, size int) ([]models.Task, int64, error) {
 	var tasks []models.Task
 	var total int64
 
 	// Count total records
-	if err := config.DB.Model(&models.Task{}).Count(&total).Error; err != nil {
+	if err := database(ctx).Model(&models.Task{}).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -184,7 +450,7 @@ func GetPaginatedTasks(page, size int) ([]models.Task, int64, error) {
 	offset := (page - 1) * size
 
 	// Get paginated data
-	err := config.DB.Preload("User").
+	err := database(ctx).Preload("User").
 		Offset(offset).
 		Limit(size).
 		Order("created_at DESC").
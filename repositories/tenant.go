@@ -0,0 +1,247 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNoOrganization is returned for queries on organization data whose context
+	// names neither an organization nor AllOrganizations
+	ErrNoOrganization = errors.New("query on organization data without an organization")
+	// ErrUnscopedQuery is returned for queries the tenant scope can't limit to an
+	// organization: on tables it doesn't know, and raw SQL outside of AllOrganizations
+	ErrUnscopedQuery = errors.New("query can't be limited to an organization")
+)
+
+// organizationTables are the tables whose rows belong to one organization through
+// their organization_id column
+var organizationTables = map[string]bool{
+	"projects":        true,
+	"tasks":           true,
+	"events":          true,
+	"saved_views":     true,
+	"calendar_tokens": true,
+	"api_keys":        true,
+	"invitations":     true,
+}
+
+// Conditions that limit a column to the rows of one organization, for tables
+// whose rows belong to an organization through another table
+const (
+	inOrganizationUsers    = "? IN (SELECT user_id FROM organization_members WHERE organization_id = ?)"
+	inOrganizationProjects = "? IN (SELECT id FROM projects WHERE organization_id = ?)"
+	inOrganizationTasks    = "? IN (SELECT id FROM tasks WHERE organization_id = ?)"
+	inOrganizationComments = "? IN (SELECT id FROM comments WHERE task_id IN (SELECT id FROM tasks WHERE organization_id = ?))"
+	inOrganizationWebhooks = "? IN (SELECT id FROM webhooks WHERE project_id IN (SELECT id FROM projects WHERE organization_id = ?))"
+)
+
+// memberTable is a table whose rows belong to an organization through another table
+type memberTable struct {
+	column    string // Column that links a row to the other table
+	condition string // Limits the column to one organization
+}
+
+// memberTables are the tables whose rows belong to an organization through
+// another table. Tokens and 2FA settings belong to a user, and so to every
+// organization of the user.
+var memberTables = map[string]memberTable{
+	"users":                     {"id", inOrganizationUsers},
+	"project_members":           {"project_id", inOrganizationProjects},
+	"task_labels":               {"task_id", inOrganizationTasks},
+	"comments":                  {"task_id", inOrganizationTasks},
+	"comment_reactions":         {"comment_id", inOrganizationComments},
+	"comment_revisions":         {"comment_id", inOrganizationComments},
+	"webhooks":                  {"project_id", inOrganizationProjects},
+	"webhook_deliveries":        {"webhook_id", inOrganizationWebhooks},
+	"escalation_policies":       {"project_id", inOrganizationProjects},
+	"task_escalations":          {"task_id", inOrganizationTasks},
+	"business_hours":            {"project_id", inOrganizationProjects},
+	"sla_policies":              {"project_id", inOrganizationProjects},
+	"task_slas":                 {"task_id", inOrganizationTasks},
+	"refresh_tokens":            {"user_id", inOrganizationUsers},
+	"revoked_tokens":            {"user_id", inOrganizationUsers},
+	"user_token_revocations":    {"user_id", inOrganizationUsers},
+	"password_reset_tokens":     {"user_id", inOrganizationUsers},
+	"email_verification_tokens": {"user_id", inOrganizationUsers},
+	"user_mfas":                 {"user_id", inOrganizationUsers},
+	"mfa_recovery_codes":        {"user_id", inOrganizationUsers},
+	"user_identities":           {"user_id", inOrganizationUsers},
+}
+
+// sharedTables are the tables that don't belong to one organization: the
+// organizations and their members, and single sign-ons that were started
+var sharedTables = map[string]bool{
+	"organizations":        true,
+	"organization_members": true,
+	"o_id_c_login_states":  true,
+}
+
+type tenantKey struct{}
+
+// tenant is the organization queries of a context are limited to
+type tenant struct {
+	organizationID uuid.UUID
+	all            bool
+}
+
+// WithOrganization limits the queries run with the returned context to the data
+// of one organization
+func WithOrganization(ctx context.Context, organizationID uuid.UUID) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant{organizationID: organizationID})
+}
+
+// AllOrganizations lets the queries run with the returned context see the data
+// of every organization. It is meant for background workers and for signing in,
+// before the organization is known.
+func AllOrganizations(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant{all: true})
+}
+
+// OrganizationFromContext returns the organization set with WithOrganization
+func OrganizationFromContext(ctx context.Context) (uuid.UUID, bool) {
+	t, ok := ctx.Value(tenantKey{}).(tenant)
+	if !ok || t.all {
+		return uuid.Nil, false
+	}
+	return t.organizationID, true
+}
+
+// RegisterTenantScope adds callbacks to db that limit every query, update and
+// delete on organization data to the organization of the statement's context
+// (see WithOrganization), and that set the organization of new rows. Statements
+// without an organization fail with ErrNoOrganization instead of seeing the data
+// of every organization, unless their context comes from AllOrganizations.
+// Statements on tables that are not listed here fail with ErrUnscopedQuery, and
+// so does raw SQL, which can't be limited, unless it runs with AllOrganizations.
+func RegisterTenantScope(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Raw().Before("gorm:raw").Register("tenant:raw", scopeToTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:query", scopeToTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenant:row", scopeToTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:update", scopeToTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("tenant:delete", scopeToTenant); err != nil {
+		return err
+	}
+	return callbacks.Create().Before("gorm:create").Register("tenant:create", assignTenant)
+}
+
+// statementTenant returns the tenant of a statement on organization data, or
+// false if the statement does not need to be limited or fails
+func statementTenant(db *gorm.DB) (tenant, bool) {
+	t, ok := db.Statement.Context.Value(tenantKey{}).(tenant)
+	table := db.Statement.Table
+
+	switch {
+	case db.Statement.SQL.Len() > 0 || table == "":
+		// SQL is only built after the callbacks, so SQL that is already there is raw.
+		// Savepoints of nested transactions touch no data.
+		sql := db.Statement.SQL.String()
+		savepoint := strings.HasPrefix(sql, "SAVEPOINT ") || strings.HasPrefix(sql, "ROLLBACK TO SAVEPOINT ")
+		if (!ok || !t.all) && !savepoint {
+			db.AddError(fmt.Errorf("%w: raw SQL needs AllOrganizations", ErrUnscopedQuery))
+		}
+		return tenant{}, false
+	case sharedTables[table]:
+		return tenant{}, false
+	case !organizationTables[table] && memberTables[table].column == "":
+		db.AddError(fmt.Errorf("%w: unknown table %s", ErrUnscopedQuery, table))
+		return tenant{}, false
+	case !ok:
+		db.AddError(fmt.Errorf("%w (table %s)", ErrNoOrganization, table))
+		return tenant{}, false
+	}
+	return t, !t.all
+}
+
+// scopeToTenant adds the condition that limits a statement to its organization
+func scopeToTenant(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	t, ok := statementTenant(db)
+	if !ok {
+		return
+	}
+	// Statements that are run several times (e.g. Count and then Find) get the condition once
+	if _, scoped := db.Statement.Settings.LoadOrStore("tenant:scoped", true); scoped {
+		return
+	}
+
+	table := db.Statement.Table
+	var condition clause.Expression
+	if organizationTables[table] {
+		condition = clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: "organization_id"},
+			Value:  t.organizationID,
+		}
+	} else {
+		member := memberTables[table]
+		condition = clause.Expr{
+			SQL:  member.condition,
+			Vars: []interface{}{clause.Column{Table: clause.CurrentTable, Name: member.column}, t.organizationID},
+		}
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{condition}})
+}
+
+// assignTenant sets the organization of new rows to the one of the context and
+// refuses rows of another organization. Rows of member tables only need an
+// organization in the context; the rows they link to are checked by the services.
+func assignTenant(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	// Fails for rows without an organization in the context, or of unknown tables
+	if statementTenant(db); db.Error != nil || db.Statement.Schema == nil || !organizationTables[db.Statement.Table] {
+		return
+	}
+	t, _ := db.Statement.Context.Value(tenantKey{}).(tenant)
+
+	field := db.Statement.Schema.LookUpField("OrganizationID")
+	if field == nil {
+		db.AddError(fmt.Errorf("model of table %s has no OrganizationID field", db.Statement.Table))
+		return
+	}
+
+	ctx := db.Statement.Context
+	assign := func(row reflect.Value) {
+		value, zero := field.ValueOf(ctx, row)
+		switch {
+		case t.all && zero:
+			db.AddError(fmt.Errorf("%w: new rows of %s need an organization", ErrNoOrganization, db.Statement.Table))
+		case t.all:
+		case zero:
+			if err := field.Set(ctx, row, t.organizationID); err != nil {
+				db.AddError(err)
+			}
+		case value != t.organizationID:
+			db.AddError(fmt.Errorf("can't create rows of %s for another organization", db.Statement.Table))
+		}
+	}
+
+	rows := reflect.Indirect(db.Statement.ReflectValue)
+	switch rows.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rows.Len(); i++ {
+			assign(reflect.Indirect(rows.Index(i)))
+		}
+	case reflect.Struct:
+		assign(rows)
+	}
+}
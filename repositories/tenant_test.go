@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunDB builds SQL with the tenant scope without running it, so no database is needed
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterTenantScope(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestTenantScopeLimitsMemberTables(t *testing.T) {
+	db := dryRunDB(t)
+	organizationID := uuid.New()
+	ctx := WithOrganization(context.Background(), organizationID)
+
+	tests := []struct {
+		model interface{}
+		want  string
+	}{
+		{&[]models.Comment{}, `"comments"."task_id" IN (SELECT id FROM tasks WHERE organization_id = $1)`},
+		{&[]models.CommentReaction{}, `"comment_reactions"."comment_id" IN (SELECT id FROM comments`},
+		{&[]models.WebhookDelivery{}, `"webhook_deliveries"."webhook_id" IN (SELECT id FROM webhooks`},
+		{&[]models.BusinessHours{}, `"business_hours"."project_id" IN (SELECT id FROM projects WHERE organization_id = $1)`},
+		{&[]models.UserMFA{}, `"user_mfas"."user_id" IN (SELECT user_id FROM organization_members WHERE organization_id = $1)`},
+		{&[]models.RefreshToken{}, `"refresh_tokens"."user_id" IN (SELECT user_id FROM organization_members`},
+	}
+	for _, tt := range tests {
+		stmt := db.WithContext(ctx).Find(tt.model).Statement
+		if err := stmt.Error; err != nil {
+			t.Errorf("%T: %v", tt.model, err)
+			continue
+		}
+		if sql := stmt.SQL.String(); !strings.Contains(sql, tt.want) {
+			t.Errorf("%T: SQL %q does not contain %q", tt.model, sql, tt.want)
+		}
+	}
+}
+
+func TestTenantScopeFailsClosed(t *testing.T) {
+	db := dryRunDB(t)
+	scoped := WithOrganization(context.Background(), uuid.New())
+
+	type unknownRow struct{ ID uuid.UUID }
+	var rows []unknownRow
+	if err := db.WithContext(scoped).Find(&rows).Error; !errors.Is(err, ErrUnscopedQuery) {
+		t.Errorf("query on an unknown table: err = %v, want ErrUnscopedQuery", err)
+	}
+
+	if err := db.WithContext(scoped).Exec("DELETE FROM comments").Error; !errors.Is(err, ErrUnscopedQuery) {
+		t.Errorf("raw SQL in an organization: err = %v, want ErrUnscopedQuery", err)
+	}
+	var count int64
+	if err := db.WithContext(scoped).Raw("SELECT count(*) FROM comments").Scan(&count).Error; !errors.Is(err, ErrUnscopedQuery) {
+		t.Errorf("raw query in an organization: err = %v, want ErrUnscopedQuery", err)
+	}
+	if err := db.WithContext(AllOrganizations(context.Background())).Exec("DELETE FROM comments").Error; err != nil {
+		t.Errorf("raw SQL with AllOrganizations: %v", err)
+	}
+
+	var comments []models.Comment
+	if err := db.WithContext(context.Background()).Find(&comments).Error; !errors.Is(err, ErrNoOrganization) {
+		t.Errorf("query without an organization: err = %v, want ErrNoOrganization", err)
+	}
+	if err := db.WithContext(context.Background()).Create(&models.TaskLabel{TaskID: uuid.New(), Name: "bug"}).Error; !errors.Is(err, ErrNoOrganization) {
+		t.Errorf("insert without an organization: err = %v, want ErrNoOrganization", err)
+	}
+}
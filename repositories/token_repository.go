@@ -0,0 +1,197 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateRefreshToken menyimpan refresh token baru
+func CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	return database(ctx).Create(token).Error
+}
+
+// GetRefreshTokenByHash mencari refresh token berdasarkan hash
+func GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := database(ctx).Where("token_hash = ?", hash).First(&token).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed marks a refresh token as exchanged. It returns false if the
+// token was already used or revoked, e.g. by a concurrent request.
+func MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	result := database(ctx).Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+// RevokeRefreshTokenFamily revokes every token of a refresh token family
+func RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID, at time.Time) error {
+	return database(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
+
+// RevokeUserRefreshTokens revokes every refresh token of a user
+func RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	return database(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+// RevokeAccessToken menyimpan access token yang dicabut
+func RevokeAccessToken(ctx context.Context, token *models.RevokedToken) error {
+	return database(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error
+}
+
+// IsAccessTokenRevoked checks if an access token was revoked, either by itself or
+// with every token of its user issued before a moment
+func IsAccessTokenRevoked(ctx context.Context, tokenID, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	var count int64
+	err := database(ctx).Model(&models.RevokedToken{}).Where("token_id = ?", tokenID).Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+
+	err = database(ctx).Model(&models.UserTokenRevocation{}).
+		Where("user_id = ? AND revoked_before > ?", userID, issuedAt).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// RevokeUserAccessTokens revokes every access token of a user issued before the given moment
+func RevokeUserAccessTokens(ctx context.Context, userID uuid.UUID, before time.Time) error {
+	return database(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_before", "updated_at"}),
+	}).Create(&models.UserTokenRevocation{UserID: userID, RevokedBefore: before}).Error
+}
+
+// DeleteExpiredRevokedTokens menghapus token yang dicabut dan sudah kedaluwarsa
+func DeleteExpiredRevokedTokens(ctx context.Context, before time.Time) error {
+	return database(ctx).Where("expires_at < ?", before).Delete(&models.RevokedToken{}).Error
+}
+
+// DeleteExpiredRefreshTokens menghapus refresh token yang sudah kedaluwarsa
+func DeleteExpiredRefreshTokens(ctx context.Context, before time.Time) error {
+	return database(ctx).Where("expires_at < ?", before).Delete(&models.RefreshToken{}).Error
+}
+
+// CreatePasswordResetToken menyimpan token reset password baru. Token lama pengguna
+// yang belum dipakai tidak berlaku lagi, sehingga hanya link terakhir yang bisa dipakai.
+func CreatePasswordResetToken(ctx context.Context, token *models.PasswordResetToken) error {
+	return database(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", token.UserID).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// GetPasswordResetTokenByHash mencari token reset password berdasarkan hash
+func GetPasswordResetTokenByHash(ctx context.Context, hash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := database(ctx).Where("token_hash = ?", hash).First(&token).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// MarkPasswordResetTokenUsed marks a reset token as used. It returns false if the
+// token was already used, e.g. by a concurrent request.
+func MarkPasswordResetTokenUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	result := database(ctx).Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteExpiredPasswordResetTokens menghapus token reset password yang sudah kedaluwarsa
+func DeleteExpiredPasswordResetTokens(ctx context.Context, before time.Time) error {
+	return database(ctx).Where("expires_at < ?", before).Delete(&models.PasswordResetToken{}).Error
+}
+
+// CreateEmailVerificationToken menyimpan token verifikasi email baru. Token lama
+// pengguna tidak berlaku lagi, sehingga hanya link terakhir yang bisa dipakai.
+func CreateEmailVerificationToken(ctx context.Context, token *models.EmailVerificationToken) error {
+	return database(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", token.UserID).Delete(&models.EmailVerificationToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// GetLatestEmailVerificationToken mencari token verifikasi email terakhir pengguna
+func GetLatestEmailVerificationToken(ctx context.Context, userID uuid.UUID) (*models.EmailVerificationToken, error) {
+	var token models.EmailVerificationToken
+	err := database(ctx).Where("user_id = ?", userID).Order("created_at DESC").First(&token).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// GetEmailVerificationTokenByHash mencari token verifikasi email berdasarkan hash
+func GetEmailVerificationTokenByHash(ctx context.Context, hash string) (*models.EmailVerificationToken, error) {
+	var token models.EmailVerificationToken
+	err := database(ctx).Where("token_hash = ?", hash).First(&token).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// ConsumeEmailVerificationToken deletes a verification token and marks the email
+// of its user as verified. It returns false if the token was already used.
+func ConsumeEmailVerificationToken(ctx context.Context, token *models.EmailVerificationToken, at time.Time) (bool, error) {
+	consumed := false
+	err := database(ctx).WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", token.ID).Delete(&models.EmailVerificationToken{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		consumed = true
+
+		return tx.Model(&models.User{}).
+			Where("id = ? AND email_verified_at IS NULL", token.UserID).
+			Update("email_verified_at", at).Error
+	})
+	return consumed, err
+}
+
+// DeleteExpiredEmailVerificationTokens menghapus token verifikasi email yang sudah kedaluwarsa
+func DeleteExpiredEmailVerificationTokens(ctx context.Context, before time.Time) error {
+	return database(ctx).Where("expires_at < ?", before).Delete(&models.EmailVerificationToken{}).Error
+}
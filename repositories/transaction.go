@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/azka-art/taskwise-backend/config"
+	"gorm.io/gorm"
+)
+
+type transactionKey struct{}
+
+// WithTransaction runs fn in a database transaction. Repository functions called
+// with the context fn gets take part in it, so their changes are kept or undone
+// together; an error returned by fn undoes them.
+func WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, transactionKey{}, tx))
+	})
+}
+
+// database returns the database for the queries of a context, which is the
+// transaction of WithTransaction if there is one
+func database(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(transactionKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return config.DB.WithContext(ctx)
+}
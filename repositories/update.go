@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotUpdated is returned by updates that matched no row, e.g. because the row
+// was deleted in the meantime or belongs to another organization
+var ErrNotUpdated = errors.New("record not found")
+
+// updated returns the error of an update, or ErrNotUpdated if it changed no row
+func updated(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotUpdated
+	}
+	return nil
+}
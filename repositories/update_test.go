@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/azka-art/taskwise-backend/config"
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestUpdatesOfMissingRowsFail(t *testing.T) {
+	db := dryRunDB(t)
+	inserted := false
+	if err := db.Callback().Create().Before("gorm:create").Register("test:create", func(*gorm.DB) { inserted = true }); err != nil {
+		t.Fatal(err)
+	}
+	previous := config.DB
+	config.DB = db
+	t.Cleanup(func() { config.DB = previous })
+
+	// A dry run changes no row, just like an update of a row that is gone
+	ctx := WithOrganization(context.Background(), uuid.New())
+	updates := map[string]func() error{
+		"task": func() error {
+			return UpdateTask(ctx, &models.Task{ID: uuid.New(), Title: "Task", CreatedBy: uuid.New()})
+		},
+		"view":              func() error { return UpdateSavedView(ctx, &models.SavedView{ID: uuid.New(), Name: "View"}) },
+		"escalation policy": func() error { return UpdateEscalationPolicy(ctx, &models.EscalationPolicy{ID: uuid.New()}) },
+		"MFA":               func() error { return UpdateUserMFA(ctx, &models.UserMFA{UserID: uuid.New(), Secret: "secret"}) },
+		"business hours":    func() error { return UpdateBusinessHours(ctx, &models.BusinessHours{ProjectID: uuid.New()}) },
+		"SLA policy":        func() error { return UpdateSLAPolicy(ctx, &models.SLAPolicy{ID: uuid.New()}) },
+		"task SLA":          func() error { return UpdateTaskSLA(ctx, &models.TaskSLA{TaskID: uuid.New()}) },
+	}
+	for name, update := range updates {
+		if err := update(); !errors.Is(err, ErrNotUpdated) {
+			t.Errorf("%s: err = %v, want ErrNotUpdated", name, err)
+		}
+	}
+	if inserted {
+		t.Error("an update inserted a row")
+	}
+}
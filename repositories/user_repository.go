@@ -1,9 +1,10 @@
 package repositories
 
 import (
+	"context"
 	"errors"
+	"time"
 
-	"github.com/azka-art/taskwise-backend/config"
 	"github.com/azka-art/taskwise-backend/models"
 	"github.com/azka-art/taskwise-backend/utils"
 	"github.com/google/uuid"
@@ -11,7 +12,7 @@ import (
 )
 
 // CreateUser menyimpan user baru ke database
-func CreateUser(user *models.User) error {
+func CreateUser(ctx context.Context, user *models.User) error {
 	// Set default values
 	if user.Role == "" {
 		user.Role = models.RoleMember
@@ -27,32 +28,34 @@ func CreateUser(user *models.User) error {
 		return err
 	}
 
-	// Check if email already exists
-	existingUser, err := GetUserByEmail(user.Email)
+	// Check if email already exists, in any organization
+	existingUser, err := GetUserByEmail(AllOrganizations(ctx), user.Email)
 	if err == nil && existingUser != nil {
 		return errors.New("email already in use")
 	}
 
 	// Check if username already exists
-	existingUser, err = GetUserByUsername(user.Username)
+	existingUser, err = GetUserByUsername(AllOrganizations(ctx), user.Username)
 	if err == nil && existingUser != nil {
 		return errors.New("username already taken")
 	}
 
-	// Hash password before storing
-	hashedPassword, err := utils.HashPassword(user.Password)
-	if err != nil {
-		return err
+	// Hash password before storing, unless the caller already did
+	if !utils.IsHashedPassword(user.Password) {
+		hashedPassword, err := utils.HashPassword(user.Password)
+		if err != nil {
+			return err
+		}
+		user.Password = hashedPassword
 	}
-	user.Password = hashedPassword
 
-	return config.DB.Create(user).Error
+	return database(ctx).Create(user).Error
 }
 
 // GetUserByID mencari user berdasarkan ID
-func GetUserByID(id uuid.UUID) (*models.User, error) {
+func GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	var user models.User
-	err := config.DB.Where("id = ?", id).First(&user).Error
+	err := database(ctx).Where("id = ?", id).First(&user).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -64,10 +67,20 @@ func GetUserByID(id uuid.UUID) (*models.User, error) {
 	return &user, nil
 }
 
+// GetUsersByIDs mengambil beberapa user berdasarkan ID
+func GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]models.User, error) {
+	var users []models.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := database(ctx).Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
 // GetUserByEmail mencari user berdasarkan email
-func GetUserByEmail(email string) (*models.User, error) {
+func GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := config.DB.Where("email = ?", email).First(&user).Error
+	err := database(ctx).Where("email = ?", email).First(&user).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -80,9 +93,9 @@ func GetUserByEmail(email string) (*models.User, error) {
 }
 
 // GetUserByUsername mencari user berdasarkan username
-func GetUserByUsername(username string) (*models.User, error) {
+func GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
 	var user models.User
-	err := config.DB.Where("username = ?", username).First(&user).Error
+	err := database(ctx).Where("username = ?", username).First(&user).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -95,19 +108,19 @@ func GetUserByUsername(username string) (*models.User, error) {
 }
 
 // GetAllUsers mengambil semua pengguna
-func GetAllUsers() ([]models.User, error) {
+func GetAllUsers(ctx context.Context) ([]models.User, error) {
 	var users []models.User
-	err := config.DB.Find(&users).Error
+	err := database(ctx).Find(&users).Error
 	return users, err
 }
 
 // GetPaginatedUsers mengambil pengguna dengan pagination
-func GetPaginatedUsers(page, size int) ([]models.User, int64, error) {
+func GetPaginatedUsers(ctx context.Context, page, size int) ([]models.User, int64, error) {
 	var users []models.User
 	var total int64
 
 	// Count total records
-	if err := config.DB.Model(&models.User{}).Count(&total).Error; err != nil {
+	if err := database(ctx).Model(&models.User{}).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -115,7 +128,7 @@ func GetPaginatedUsers(page, size int) ([]models.User, int64, error) {
 	offset := (page - 1) * size
 
 	// Get paginated data
-	err := config.DB.
+	err := database(ctx).
 		Offset(offset).
 		Limit(size).
 		Order("created_at DESC").
@@ -125,9 +138,9 @@ func GetPaginatedUsers(page, size int) ([]models.User, int64, error) {
 }
 
 // UpdateUser memperbarui data pengguna
-func UpdateUser(user *models.User) error {
+func UpdateUser(ctx context.Context, user *models.User) error {
 	// Check if user exists
-	existingUser, err := GetUserByID(user.ID)
+	existingUser, err := GetUserByID(ctx, user.ID)
 	if err != nil {
 		return err
 	}
@@ -143,7 +156,7 @@ func UpdateUser(user *models.User) error {
 
 	// Check if email is unique (if changed)
 	if existingUser.Email != user.Email {
-		emailUser, err := GetUserByEmail(user.Email)
+		emailUser, err := GetUserByEmail(AllOrganizations(ctx), user.Email)
 		if err == nil && emailUser != nil && emailUser.ID != user.ID {
 			return errors.New("email already in use")
 		}
@@ -151,28 +164,33 @@ func UpdateUser(user *models.User) error {
 
 	// Check if username is unique (if changed)
 	if existingUser.Username != user.Username {
-		usernameUser, err := GetUserByUsername(user.Username)
+		usernameUser, err := GetUserByUsername(AllOrganizations(ctx), user.Username)
 		if err == nil && usernameUser != nil && usernameUser.ID != user.ID {
 			return errors.New("username already taken")
 		}
 	}
 
 	// Update only these fields, not password or role
-	return config.DB.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+	updates := map[string]interface{}{
 		"username": user.Username,
 		"email":    user.Email,
-	}).Error
+	}
+	// A new email address has to be verified again
+	if existingUser.Email != user.Email {
+		updates["email_verified_at"] = nil
+	}
+	return database(ctx).Model(&models.User{}).Where("id = ?", user.ID).Updates(updates).Error
 }
 
 // UpdateUserRole memperbarui role pengguna (admin only)
-func UpdateUserRole(userID uuid.UUID, role models.UserRole) error {
+func UpdateUserRole(ctx context.Context, userID uuid.UUID, role models.UserRole) error {
 	// Validate role
 	if role != models.RoleAdmin && role != models.RoleMember {
 		return errors.New("invalid role value")
 	}
 
 	// Check if user exists
-	existingUser, err := GetUserByID(userID)
+	existingUser, err := GetUserByID(ctx, userID)
 	if err != nil {
 		return err
 	}
@@ -181,13 +199,13 @@ func UpdateUserRole(userID uuid.UUID, role models.UserRole) error {
 		return errors.New("user not found")
 	}
 
-	return config.DB.Model(&models.User{}).Where("id = ?", userID).Update("role", role).Error
+	return database(ctx).Model(&models.User{}).Where("id = ?", userID).Update("role", role).Error
 }
 
 // UpdatePassword memperbarui password pengguna
-func UpdatePassword(userID uuid.UUID, currentPassword, newPassword string) error {
+func UpdatePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword string) error {
 	// Get user
-	user, err := GetUserByID(userID)
+	user, err := GetUserByID(ctx, userID)
 	if err != nil {
 		return err
 	}
@@ -213,13 +231,32 @@ func UpdatePassword(userID uuid.UUID, currentPassword, newPassword string) error
 	}
 
 	// Update password
-	return config.DB.Model(&models.User{}).Where("id = ?", userID).Update("password", hashedPassword).Error
+	return database(ctx).Model(&models.User{}).Where("id = ?", userID).Update("password", hashedPassword).Error
+}
+
+// SetPasswordHash menyimpan hash password baru pengguna
+func SetPasswordHash(ctx context.Context, userID uuid.UUID, hashedPassword string) error {
+	return database(ctx).Model(&models.User{}).Where("id = ?", userID).Update("password", hashedPassword).Error
+}
+
+// MarkEmailVerified menandai email pengguna sebagai terverifikasi
+func MarkEmailVerified(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	return database(ctx).Model(&models.User{}).
+		Where("id = ? AND email_verified_at IS NULL", userID).
+		Update("email_verified_at", at).Error
+}
+
+// MarkExistingUsersVerified menandai email semua pengguna lama sebagai terverifikasi
+func MarkExistingUsersVerified(ctx context.Context) error {
+	return database(ctx).Model(&models.User{}).
+		Where("email_verified_at IS NULL").
+		Update("email_verified_at", gorm.Expr("created_at")).Error
 }
 
 // DeleteUser menghapus pengguna berdasarkan ID
-func DeleteUser(id uuid.UUID) error {
+func DeleteUser(ctx context.Context, id uuid.UUID) error {
 	// Check if user exists
-	existingUser, err := GetUserByID(id)
+	existingUser, err := GetUserByID(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -229,7 +266,7 @@ func DeleteUser(id uuid.UUID) error {
 	}
 
 	// Begin a transaction
-	tx := config.DB.Begin()
+	tx := database(ctx).Begin()
 
 	// Delete user's comments
 	if err := tx.Where("user_id = ?", id).Delete(&models.Comment{}).Error; err != nil {
@@ -268,9 +305,9 @@ func DeleteUser(id uuid.UUID) error {
 }
 
 // GetUserCount returns the total number of users
-func GetUserCount() (int64, error) {
+func GetUserCount(ctx context.Context) (int64, error) {
 	var count int64
-	err := config.DB.Model(&models.User{}).Count(&count).Error
+	err := database(ctx).Model(&models.User{}).Count(&count).Error
 	return count, err
 }
 
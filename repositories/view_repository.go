@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateSavedView saves a new view
+func CreateSavedView(ctx context.Context, view *models.SavedView) error {
+	return database(ctx).Create(view).Error
+}
+
+// GetSavedViewByID finds a view by its ID
+func GetSavedViewByID(ctx context.Context, id uuid.UUID) (*models.SavedView, error) {
+	var view models.SavedView
+	err := database(ctx).Where("id = ?", id).First(&view).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &view, nil
+}
+
+// GetSavedViewsForUser finds the personal views of a user and the views shared
+// with the projects the user is a member of
+func GetSavedViewsForUser(ctx context.Context, userID uuid.UUID, projectID *uuid.UUID) ([]models.SavedView, error) {
+	query := database(ctx).Where(
+		"(owner_id = ? AND project_id IS NULL) OR project_id IN (SELECT project_id FROM project_members WHERE user_id = ?)",
+		userID, userID,
+	)
+	if projectID != nil {
+		query = query.Where("project_id = ?", *projectID)
+	}
+
+	var views []models.SavedView
+	err := query.Order("name").Find(&views).Error
+	return views, err
+}
+
+// UpdateSavedView saves the changes to a view
+func UpdateSavedView(ctx context.Context, view *models.SavedView) error {
+	return updated(database(ctx).Model(view).
+		Select("name", "project_id", "filters", "sort", "fields", "updated_at").
+		Updates(view))
+}
+
+// DeleteSavedView deletes a view
+func DeleteSavedView(ctx context.Context, id uuid.UUID) error {
+	return database(ctx).Where("id = ?", id).Delete(&models.SavedView{}).Error
+}
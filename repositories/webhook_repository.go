@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateWebhook saves a new webhook
+func CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	if err := webhook.Validate(); err != nil {
+		return err
+	}
+
+	return database(ctx).Create(webhook).Error
+}
+
+// GetWebhookByID finds a webhook by its ID
+func GetWebhookByID(ctx context.Context, id uuid.UUID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := database(ctx).Where("id = ?", id).First(&webhook).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// GetWebhooksByProjectID finds all webhooks registered for a project
+func GetWebhooksByProjectID(ctx context.Context, projectID uuid.UUID) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := database(ctx).Where("project_id = ?", projectID).Order("created_at DESC").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// GetActiveWebhooksByProjectID finds the webhooks of a project that should receive events
+func GetActiveWebhooksByProjectID(ctx context.Context, projectID uuid.UUID) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := database(ctx).Where("project_id = ? AND active = ?", projectID, true).Find(&webhooks).Error
+	return webhooks, err
+}
+
+// DeleteWebhook removes a webhook
+func DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	result := database(ctx).Where("id = ?", id).Delete(&models.Webhook{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New("webhook not found")
+	}
+
+	return nil
+}
+
+// CreateWebhookDelivery queues a delivery
+func CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return database(ctx).Create(delivery).Error
+}
+
+// GetWebhookDeliveryByID finds a delivery by its ID
+func GetWebhookDeliveryByID(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := database(ctx).Where("id = ?", id).First(&delivery).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Return nil, nil when no record is found
+		}
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// GetPaginatedWebhookDeliveries returns the delivery log of a webhook, newest first
+func GetPaginatedWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, page, size int) ([]models.WebhookDelivery, int64, error) {
+	var deliveries []models.WebhookDelivery
+	var total int64
+
+	query := database(ctx).Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * size
+
+	err := query.Offset(offset).Limit(size).Order("created_at DESC").Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// ClaimDueWebhookDeliveries locks pending deliveries that are due and pushes their
+// next attempt back by lease, so that other instances don't send them concurrently
+func ClaimDueWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	err := database(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+		}
+
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+
+	return deliveries, err
+}
+
+// UpdateWebhookDeliveryResult stores the outcome of a delivery attempt
+func UpdateWebhookDeliveryResult(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return database(ctx).Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(map[string]interface{}{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"response_code":   delivery.ResponseCode,
+		"error":           delivery.Error,
+		"next_attempt_at": delivery.NextAttemptAt,
+	}).Error
+}
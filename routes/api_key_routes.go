@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/azka-art/taskwise-backend/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAPIKeyRoutes sets up the routes to manage API keys
+func RegisterAPIKeyRoutes(router *gin.RouterGroup) {
+	apiKeys := router.Group("/api-keys")
+	{
+		apiKeys.POST("/", controllers.CreateAPIKey)
+		apiKeys.GET("/", controllers.GetAPIKeys)
+		apiKeys.DELETE("/:id", controllers.RevokeAPIKey)
+	}
+}
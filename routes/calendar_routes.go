@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/azka-art/taskwise-backend/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterCalendarRoutes sets up the routes to manage calendar feeds
+func RegisterCalendarRoutes(router *gin.RouterGroup) {
+	calendar := router.Group("/calendar/tokens")
+	{
+		calendar.POST("/", controllers.CreateCalendarToken)
+		calendar.GET("/", controllers.GetCalendarTokens)
+		calendar.POST("/:id/regenerate", controllers.RegenerateCalendarToken)
+		calendar.DELETE("/:id", controllers.RevokeCalendarToken)
+	}
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/azka-art/taskwise-backend/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterInvitationRoutes sets up the routes to invite people into the organization or a project
+func RegisterInvitationRoutes(router *gin.RouterGroup) {
+	invitations := router.Group("/invitations")
+	{
+		invitations.POST("/", controllers.CreateInvitation)
+		invitations.GET("/", controllers.GetInvitations)
+		invitations.DELETE("/:id", controllers.RevokeInvitation)
+	}
+}
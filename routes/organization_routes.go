@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/azka-art/taskwise-backend/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterOrganizationRoutes sets up the routes to list, create and switch organizations
+func RegisterOrganizationRoutes(router *gin.RouterGroup) {
+	organizations := router.Group("/organizations")
+	{
+		organizations.GET("/", controllers.GetOrganizations)
+		organizations.POST("/", controllers.CreateOrganization)
+		organizations.POST("/:id/switch", controllers.SwitchOrganization) // Returns tokens for the organization
+	}
+}
@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"github.com/azka-art/taskwise-backend/controllers"
+	"github.com/azka-art/taskwise-backend/middleware"
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterProjectRoutes sets up project-related routes
+func RegisterProjectRoutes(router *gin.RouterGroup) {
+	projects := router.Group("/projects")
+	{
+		projects.POST("/", middleware.RequirePermission(models.PermProjectCreate), controllers.CreateProject)
+		projects.GET("/", controllers.GetProjects)
+		projects.GET("/:id", controllers.GetProject)
+		projects.POST("/:id/members", middleware.RequirePermission(models.PermProjectMembers), controllers.AddProjectMember) // Project admins of the project only
+		projects.POST("/:id/import", middleware.RequirePermission(models.PermTaskCreate), controllers.ImportExternalTasks)
+		projects.GET("/:id/activity", controllers.GetProjectActivity)
+
+		// Analytics
+		projects.GET("/:id/analytics/burndown", controllers.GetBurndown)
+		projects.GET("/:id/analytics/cumulative-flow", controllers.GetCumulativeFlow)
+		projects.GET("/:id/analytics/cycle-time", controllers.GetFlowTimes)
+
+		// Escalation policies (project admins only)
+		projects.POST("/:id/escalation-policies", controllers.CreateEscalationPolicy)
+		projects.GET("/:id/escalation-policies", controllers.GetEscalationPolicies)
+		projects.PUT("/:id/escalation-policies/:policy_id", controllers.UpdateEscalationPolicy)
+		projects.DELETE("/:id/escalation-policies/:policy_id", controllers.DeleteEscalationPolicy)
+
+		// SLAs (changes are for project admins only)
+		projects.GET("/:id/business-hours", controllers.GetBusinessHours)
+		projects.PUT("/:id/business-hours", controllers.SetBusinessHours)
+		projects.POST("/:id/sla-policies", controllers.SetSLAPolicy)
+		projects.GET("/:id/sla-policies", controllers.GetSLAPolicies)
+		projects.DELETE("/:id/sla-policies/:policy_id", controllers.DeleteSLAPolicy)
+		projects.GET("/:id/sla", controllers.GetProjectSLAStatuses)
+
+		// Webhooks (project admins only)
+		projects.POST("/:id/webhooks", controllers.CreateWebhook)
+		projects.GET("/:id/webhooks", controllers.GetWebhooks)
+		projects.DELETE("/:id/webhooks/:webhook_id", controllers.DeleteWebhook)
+		projects.GET("/:id/webhooks/:webhook_id/deliveries", controllers.GetWebhookDeliveries)
+		projects.POST("/:id/webhooks/:webhook_id/deliveries/:delivery_id/redeliver", controllers.RedeliverWebhook)
+	}
+}
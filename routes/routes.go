@@ -3,22 +3,62 @@ package routes
 import (
 	"github.com/azka-art/taskwise-backend/controllers"
 	"github.com/azka-art/taskwise-backend/middleware"
+	"github.com/azka-art/taskwise-backend/models"
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRoutes registers all API routes
 func SetupRoutes(router *gin.Engine) {
 	// Public Routes (No Authentication Required)
-	router.POST("/api/register", controllers.RegisterUser)
+	router.POST("/api/register", controllers.RegisterUser) // Can be turned off with OPEN_REGISTRATION=false
+	router.POST("/api/invitations/accept", controllers.AcceptInvitation)
 	router.POST("/api/login", controllers.LoginUser)
+	router.POST("/api/login/mfa", controllers.CompleteMFALogin) // Second step for users with two-factor authentication
+	router.POST("/api/token/refresh", controllers.RefreshToken)
+	router.POST("/api/password/forgot", controllers.ForgotPassword)
+	router.POST("/api/password/reset", controllers.ResetPassword)
+	router.GET("/api/verify-email", controllers.VerifyEmail) // /api/verify-email?token=<token>
+	router.POST("/api/verify-email/resend", controllers.ResendVerificationEmail)
+
+	// Single sign-on with OpenID Connect providers
+	router.GET("/api/auth/oidc/providers", controllers.GetOIDCProviders)
+	router.GET("/api/auth/oidc/:provider/login", controllers.StartOIDCLogin)
+	router.GET("/api/auth/oidc/:provider/callback", controllers.OIDCCallback)
+
+	// Calendar feeds are protected by the token in the URL, so calendar apps can subscribe
+	router.GET("/api/calendar/:token", controllers.GetCalendarFeed) // /api/calendar/<token>.ics
 
 	// Protected Routes with JWT Middleware
 	protected := router.Group("/api")
 	protected.Use(middleware.JWTAuthMiddleware())
 	{
-		protected.GET("/users", controllers.GetUsers)
+		protected.POST("/logout", controllers.Logout)        // Revokes the current access token and its refresh token
+		protected.POST("/logout-all", controllers.LogoutAll) // Revokes every token of the current user
+
+		// Two-factor authentication
+		protected.GET("/mfa", controllers.GetMFAStatus)
+		protected.POST("/mfa/enroll", controllers.EnrollMFA) // Returns the secret and otpauth:// URI for the QR code
+		protected.POST("/mfa/confirm", controllers.ConfirmMFA)
+		protected.POST("/mfa/disable", controllers.DisableMFA)
+		protected.POST("/mfa/recovery-codes", controllers.RegenerateRecoveryCodes)
+	}
+
+	// Users who have not verified their email address may be limited to reading, and
+	// users who must use two-factor authentication can't do anything before setting it up
+	verified := protected.Group("")
+	verified.Use(middleware.RequireMFASetup(), middleware.RequireVerifiedEmail())
+	{
+		verified.GET("/users", middleware.RequirePermission(models.PermUserManage), controllers.GetUsers)
+		verified.PUT("/users/:id/mfa-required", middleware.RequirePermission(models.PermUserManage), controllers.SetMFARequired)
+		verified.GET("/stream", controllers.StreamEvents) // Realtime task & comment events (SSE)
 	}
 
 	// Register Task Routes (Inside Protected API)
-	RegisterTaskRoutes(protected)
+	RegisterTaskRoutes(verified)
+	RegisterProjectRoutes(verified)
+	RegisterCalendarRoutes(verified)
+	RegisterViewRoutes(verified)
+	RegisterAPIKeyRoutes(verified)
+	RegisterOrganizationRoutes(verified)
+	RegisterInvitationRoutes(verified)
 }
@@ -2,6 +2,8 @@ package routes
 
 import (
 	"github.com/azka-art/taskwise-backend/controllers"
+	"github.com/azka-art/taskwise-backend/middleware"
+	"github.com/azka-art/taskwise-backend/models"
 	"github.com/gin-gonic/gin"
 )
 
@@ -9,9 +11,24 @@ import (
 func RegisterTaskRoutes(router *gin.RouterGroup) {
 	tasks := router.Group("/tasks") // ✅ Uses the protected API group
 	{
-		tasks.POST("/", controllers.CreateTask)
+		tasks.POST("/", middleware.RequirePermission(models.PermTaskCreate), controllers.CreateTask)
 		tasks.GET("/", controllers.GetTasks)
-		tasks.PUT("/:id", controllers.UpdateTask)
-		tasks.DELETE("/:id", controllers.DeleteTask)
+		tasks.GET("/export.csv", controllers.ExportTasksCSV)
+		tasks.POST("/import", middleware.RequirePermission(models.PermTaskCreate), controllers.ImportTasks)
+		tasks.PUT("/:id", middleware.RequirePermission(models.PermTaskUpdate), controllers.UpdateTask)
+		tasks.POST("/:id/move", middleware.RequirePermission(models.PermTaskMove), controllers.MoveTask)
+		tasks.POST("/:id/duplicate", middleware.RequirePermission(models.PermTaskCreate), controllers.DuplicateTask)
+		tasks.GET("/:id/sla", controllers.GetTaskSLAStatus)
+		tasks.DELETE("/:id", middleware.RequirePermission(models.PermTaskDelete), controllers.DeleteTask) // Own tasks, or any with task.delete.any
+
+		// Comments
+		tasks.POST("/:id/comments", controllers.AddComment)
+		tasks.GET("/:id/comments", controllers.GetComments)
+		tasks.PUT("/:id/comments/:comment_id", controllers.UpdateComment)
+		tasks.DELETE("/:id/comments/:comment_id", controllers.DeleteComment)
+		tasks.GET("/:id/comments/:comment_id/revisions", controllers.GetCommentRevisions)
+		tasks.POST("/:id/comments/:comment_id/replies", controllers.ReplyToComment)
+		tasks.POST("/:id/comments/:comment_id/reactions", controllers.AddReaction)
+		tasks.DELETE("/:id/comments/:comment_id/reactions/:emoji", controllers.RemoveReaction)
 	}
 }
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/azka-art/taskwise-backend/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterViewRoutes sets up the routes for saved task views
+func RegisterViewRoutes(router *gin.RouterGroup) {
+	views := router.Group("/views")
+	{
+		views.POST("/", controllers.CreateSavedView)
+		views.GET("/", controllers.GetSavedViews)
+		views.GET("/:id", controllers.GetSavedView)
+		views.PUT("/:id", controllers.UpdateSavedView)
+		views.DELETE("/:id", controllers.DeleteSavedView)
+		views.GET("/:id/tasks", controllers.GetSavedViewTasks)
+	}
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/google/uuid"
+)
+
+// fakeAccountRepository keeps accounts and identities in memory, so that the
+// account logic of the services runs in tests without a database
+type fakeAccountRepository struct {
+	mu            sync.Mutex
+	users         map[uuid.UUID]models.User
+	organizations map[uuid.UUID]models.Organization // By the ID of their admin
+	identities    map[uuid.UUID]models.UserIdentity
+	mfaDeleted    map[uuid.UUID]bool      // Users whose 2FA settings were deleted
+	revoked       map[uuid.UUID]time.Time // When the sessions of a user were revoked
+}
+
+// useFakeAccounts makes the services use an empty fake account repository until
+// the test ends
+func useFakeAccounts(t *testing.T) *fakeAccountRepository {
+	t.Helper()
+
+	fake := &fakeAccountRepository{
+		users:         make(map[uuid.UUID]models.User),
+		organizations: make(map[uuid.UUID]models.Organization),
+		identities:    make(map[uuid.UUID]models.UserIdentity),
+		mfaDeleted:    make(map[uuid.UUID]bool),
+		revoked:       make(map[uuid.UUID]time.Time),
+	}
+	previous := accountRepository
+	accountRepository = func(context.Context) repositories.AccountRepository { return fake }
+	t.Cleanup(func() { accountRepository = previous })
+	return fake
+}
+
+// addUser stores an account with a made-up username
+func (r *fakeAccountRepository) addUser(email string, verified bool) models.User {
+	user := models.User{
+		ID:       uuid.New(),
+		Username: "user_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12],
+		Email:    email,
+		Password: "unusable",
+		Role:     models.RoleMember,
+	}
+	if verified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.ID] = user
+	return user
+}
+
+// identitiesOf returns the identities linked to a user
+func (r *fakeAccountRepository) identitiesOf(userID uuid.UUID) []models.UserIdentity {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var identities []models.UserIdentity
+	for _, identity := range r.identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities
+}
+
+func (r *fakeAccountRepository) FindByID(id uuid.UUID) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user, ok := r.users[id]; ok {
+		return &user, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeAccountRepository) FindByEmail(email string) (*models.User, error) {
+	return r.find(func(user models.User) bool { return user.Email == email })
+}
+
+func (r *fakeAccountRepository) FindByUsername(username string) (*models.User, error) {
+	return r.find(func(user models.User) bool { return user.Username == username })
+}
+
+func (r *fakeAccountRepository) find(match func(user models.User) bool) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if match(user) {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeAccountRepository) CreateWithOrganization(user *models.User, organization *models.Organization) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Email == user.Email || existing.Username == user.Username {
+			return errors.New("duplicate user")
+		}
+	}
+	if user.ID == uuid.Nil {
+		user.ID = uuid.New()
+	}
+	organization.ID = uuid.New()
+	r.users[user.ID] = *user
+	r.organizations[user.ID] = *organization
+	return nil
+}
+
+func (r *fakeAccountRepository) SetPassword(userID uuid.UUID, hashedPassword string) error {
+	return r.update(userID, func(user *models.User) { user.Password = hashedPassword })
+}
+
+func (r *fakeAccountRepository) MarkEmailVerified(userID uuid.UUID, at time.Time) error {
+	return r.update(userID, func(user *models.User) { user.EmailVerifiedAt = &at })
+}
+
+func (r *fakeAccountRepository) update(userID uuid.UUID, change func(user *models.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return repositories.ErrNotUpdated
+	}
+	change(&user)
+	r.users[userID] = user
+	return nil
+}
+
+func (r *fakeAccountRepository) DeleteMFA(userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mfaDeleted[userID] = true
+	return nil
+}
+
+func (r *fakeAccountRepository) RevokeSessions(userID uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revoked[userID] = at
+	return nil
+}
+
+func (r *fakeAccountRepository) FindIdentity(provider, subject string) (*models.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeAccountRepository) CreateIdentity(identity *models.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if identity.ID == uuid.Nil {
+		identity.ID = uuid.New()
+	}
+	r.identities[identity.ID] = *identity
+	return nil
+}
+
+func (r *fakeAccountRepository) TouchIdentity(id uuid.UUID, email string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	identity, ok := r.identities[id]
+	if !ok {
+		return errors.New("identity not found")
+	}
+	identity.Email, identity.LastLoginAt = email, at
+	r.identities[id] = identity
+	return nil
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/google/uuid"
+)
+
+// systemActorName describes changes made by background workers rather than a user
+const systemActorName = "TaskWise"
+
+// GetProjectActivity returns a page of everything that happened in a project, newest first
+func GetProjectActivity(ctx context.Context, projectID, userID uuid.UUID, role models.UserRole, filter models.ActivityFilter, page, size int) ([]models.ActivityEntry, int64, error) {
+	if _, err := GetProject(ctx, projectID, userID, role); err != nil {
+		return nil, 0, err
+	}
+
+	for _, eventType := range filter.Types {
+		if !models.IsValidEventType(eventType) {
+			return nil, 0, invalidInput(fmt.Errorf("invalid event type %q", eventType))
+		}
+	}
+
+	events, total, err := repositories.FindProjectEvents(ctx, projectID, filter, page, size)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var actorIDs []uuid.UUID
+	for _, event := range events {
+		if event.ActorID != nil {
+			actorIDs = append(actorIDs, *event.ActorID)
+		}
+	}
+	users, err := repositories.GetUsersByIDs(ctx, actorIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	usernames := make(map[uuid.UUID]string, len(users))
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+
+	entries := make([]models.ActivityEntry, len(events))
+	for i, event := range events {
+		entry := models.ActivityEntry{
+			ID:        event.ID,
+			Type:      event.Type,
+			TaskID:    event.TaskID,
+			Summary:   event.Summary,
+			Data:      event.Data,
+			CreatedAt: event.CreatedAt,
+		}
+		if event.ActorID != nil {
+			entry.Actor = &models.ActivityActor{ID: *event.ActorID, Username: usernames[*event.ActorID]}
+		}
+
+		// Events stored before summaries were recorded get a generic one
+		if entry.Summary == "" {
+			actorName := systemActorName
+			if entry.Actor != nil {
+				actorName = entry.Actor.Username
+				if actorName == "" {
+					actorName = "Someone"
+				}
+			}
+			entry.Summary = describeTaskEvent(ctx, event.Type, models.Task{}, actorName, nil)
+		}
+
+		entries[i] = entry
+	}
+	return entries, total, nil
+}
+
+// summarizeTaskEvent describes an event about a task for people, e.g. in the activity feed
+func summarizeTaskEvent(ctx context.Context, eventType models.EventType, task models.Task, actorID uuid.UUID, data interface{}) string {
+	return describeTaskEvent(ctx, eventType, task, usernameOf(ctx, actorID, systemActorName), data)
+}
+
+// describeTaskEvent builds the human-readable summary of an event
+func describeTaskEvent(ctx context.Context, eventType models.EventType, task models.Task, actorName string, data interface{}) string {
+	title := "a task"
+	if task.Title != "" {
+		title = fmt.Sprintf("%q", task.Title)
+	}
+
+	switch eventType {
+	case models.EventTaskCreated:
+		return fmt.Sprintf("%s created %s", actorName, title)
+	case models.EventTaskUpdated:
+		return fmt.Sprintf("%s updated %s", actorName, title)
+	case models.EventTaskDeleted:
+		return fmt.Sprintf("%s deleted %s", actorName, title)
+	case models.EventTaskStatusChanged:
+		if change, ok := data.(models.StatusChange); ok {
+			return fmt.Sprintf("%s moved %s from %s to %s", actorName, title, change.From, change.To)
+		}
+		return fmt.Sprintf("%s changed the status of %s", actorName, title)
+	case models.EventTaskAssigned:
+		if change, ok := data.(models.AssigneeChange); ok {
+			if change.To == nil {
+				return fmt.Sprintf("%s unassigned %s", actorName, title)
+			}
+			return fmt.Sprintf("%s assigned %s to %s", actorName, title, usernameOf(ctx, *change.To, "someone"))
+		}
+		return fmt.Sprintf("%s changed the assignee of %s", actorName, title)
+	case models.EventTaskEscalated:
+		if escalation, ok := data.(models.Escalation); ok {
+			return fmt.Sprintf("%s was escalated by the policy %q", title, escalation.PolicyName)
+		}
+		return fmt.Sprintf("%s was escalated", title)
+	case models.EventTaskSLABreached:
+		if breach, ok := data.(models.SLABreach); ok {
+			return fmt.Sprintf("%s missed its %s SLA", title, breach.Target)
+		}
+		return fmt.Sprintf("%s missed an SLA target", title)
+	case models.EventCommentCreated:
+		return fmt.Sprintf("%s commented on %s", actorName, title)
+	case models.EventCommentUpdated:
+		return fmt.Sprintf("%s edited a comment on %s", actorName, title)
+	case models.EventCommentDeleted:
+		return fmt.Sprintf("%s deleted a comment on %s", actorName, title)
+	default:
+		return fmt.Sprintf("%s: %s", eventType, title)
+	}
+}
+
+// usernameOf returns the username of a user, or fallback if it is unknown
+func usernameOf(ctx context.Context, userID uuid.UUID, fallback string) string {
+	if userID == uuid.Nil {
+		return fallback
+	}
+
+	user, err := repositories.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Printf("❌ Failed to load user %s: %v", userID, err)
+		return fallback
+	}
+	if user == nil {
+		return fallback
+	}
+	return user.Username
+}
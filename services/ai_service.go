@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -94,7 +95,7 @@ func ConvertLevelToPriority(level int) models.Priority {
 }
 
 // GetTaskPriorities generates AI recommended priorities for a list of tasks
-func GetTaskPriorities(taskIDs []uuid.UUID) (map[uuid.UUID]models.Priority, error) {
+func GetTaskPriorities(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID]models.Priority, error) {
 	result := make(map[uuid.UUID]models.Priority)
 
 	// Return empty result if no task IDs provided
@@ -104,7 +105,7 @@ func GetTaskPriorities(taskIDs []uuid.UUID) (map[uuid.UUID]models.Priority, erro
 
 	// Get all tasks
 	for _, taskID := range taskIDs {
-		task, err := repositories.GetTaskByID(taskID)
+		task, err := repositories.GetTaskByID(ctx, taskID)
 		if err != nil || task == nil {
 			// Skip tasks that can't be found
 			continue
@@ -138,7 +139,7 @@ func GetTaskPriorities(taskIDs []uuid.UUID) (map[uuid.UUID]models.Priority, erro
 }
 
 // PrioritizeTasks sorts a list of tasks based on AI recommendations
-func PrioritizeTasks(tasks []models.Task) ([]models.Task, error) {
+func PrioritizeTasks(ctx context.Context, tasks []models.Task) ([]models.Task, error) {
 	if len(tasks) == 0 {
 		return tasks, nil
 	}
@@ -154,7 +155,7 @@ func PrioritizeTasks(tasks []models.Task) ([]models.Task, error) {
 	}
 
 	// Get AI recommendations
-	recommendations, err := GetTaskPriorities(taskIDs)
+	recommendations, err := GetTaskPriorities(ctx, taskIDs)
 	if err != nil {
 		return nil, err
 	}
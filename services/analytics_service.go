@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/google/uuid"
+)
+
+const (
+	analyticsDateFormat  = "2006-01-02"
+	analyticsDefaultDays = 30  // Range used when no start date is given
+	analyticsMaxDays     = 366 // Longest range a report may cover
+)
+
+// AnalyticsRange is a range of whole UTC days, both ends included
+type AnalyticsRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// NewAnalyticsRange parses an optional from and to date (YYYY-MM-DD). The range
+// defaults to the last 30 days up to today.
+func NewAnalyticsRange(from, to string) (AnalyticsRange, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	r := AnalyticsRange{To: today}
+
+	if to != "" {
+		parsed, err := time.Parse(analyticsDateFormat, to)
+		if err != nil {
+			return r, invalidInput(errors.New("to must be a date in the format YYYY-MM-DD"))
+		}
+		r.To = parsed
+	}
+
+	r.From = r.To.AddDate(0, 0, -(analyticsDefaultDays - 1))
+	if from != "" {
+		parsed, err := time.Parse(analyticsDateFormat, from)
+		if err != nil {
+			return r, invalidInput(errors.New("from must be a date in the format YYYY-MM-DD"))
+		}
+		r.From = parsed
+	}
+
+	if r.From.After(r.To) {
+		return r, invalidInput(errors.New("from must not be after to"))
+	}
+	if r.days() > analyticsMaxDays {
+		return r, invalidInput(errors.New("the date range can cover at most 366 days"))
+	}
+	return r, nil
+}
+
+// days returns the number of days in the range
+func (r AnalyticsRange) days() int {
+	return int(r.To.Sub(r.From).Hours()/24) + 1
+}
+
+// end returns the first moment after the range
+func (r AnalyticsRange) end() time.Time {
+	return r.To.AddDate(0, 0, 1)
+}
+
+// GetBurndown returns the number of open and done tasks of a project at the end of every day
+func GetBurndown(ctx context.Context, projectID, userID uuid.UUID, role models.UserRole, r AnalyticsRange) ([]models.BurndownPoint, error) {
+	flow, err := GetCumulativeFlow(ctx, projectID, userID, role, r)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]models.BurndownPoint, len(flow))
+	for i, day := range flow {
+		open := day.Counts[models.StatusPending] + day.Counts[models.StatusInProgress]
+		done := day.Counts[models.StatusDone]
+		points[i] = models.BurndownPoint{Date: day.Date, Total: open + done, Open: open, Done: done}
+	}
+	return points, nil
+}
+
+// GetCumulativeFlow returns the number of tasks of a project in every status at the end of every day
+func GetCumulativeFlow(ctx context.Context, projectID, userID uuid.UUID, role models.UserRole, r AnalyticsRange) ([]models.CumulativeFlowPoint, error) {
+	timelines, err := loadTaskTimelines(ctx, projectID, userID, role, r.end())
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	points := make([]models.CumulativeFlowPoint, 0, r.days())
+	for day := r.From; !day.After(r.To); day = day.AddDate(0, 0, 1) {
+		at := day.AddDate(0, 0, 1)
+		if at.After(now) {
+			at = now // Today (and later) shows the current state
+		}
+
+		counts := map[models.Status]int{
+			models.StatusPending:    0,
+			models.StatusInProgress: 0,
+			models.StatusDone:       0,
+		}
+		for _, timeline := range timelines {
+			if status, exists := timeline.statusAt(at); exists {
+				counts[status]++
+			}
+		}
+
+		points = append(points, models.CumulativeFlowPoint{Date: day.Format(analyticsDateFormat), Counts: counts})
+	}
+	return points, nil
+}
+
+// GetFlowTimes returns the cycle time (first In Progress to Done) and lead time
+// (created to Done) of the project's tasks that were completed in the range
+func GetFlowTimes(ctx context.Context, projectID, userID uuid.UUID, role models.UserRole, r AnalyticsRange) (models.FlowTimeReport, error) {
+	report := models.FlowTimeReport{
+		From: r.From.Format(analyticsDateFormat),
+		To:   r.To.Format(analyticsDateFormat),
+	}
+
+	timelines, err := loadTaskTimelines(ctx, projectID, userID, role, r.end())
+	if err != nil {
+		return report, err
+	}
+
+	var cycleTimes, leadTimes []time.Duration
+	for _, timeline := range timelines {
+		doneAt, startedAt, ok := timeline.completion(r.From, r.end())
+		if !ok {
+			continue
+		}
+
+		report.Completed++
+		leadTimes = append(leadTimes, doneAt.Sub(timeline.createdAt))
+		if startedAt != nil {
+			cycleTimes = append(cycleTimes, doneAt.Sub(*startedAt))
+		}
+	}
+
+	report.CycleTime = durationStats(cycleTimes)
+	report.LeadTime = durationStats(leadTimes)
+	return report, nil
+}
+
+// taskTimeline is the status history of a single task
+type taskTimeline struct {
+	createdAt time.Time
+	deletedAt *time.Time
+	initial   models.Status
+	changes   []models.StatusTransition // Oldest first
+}
+
+// loadTaskTimelines rebuilds the status history of every task a project had before until
+func loadTaskTimelines(ctx context.Context, projectID, userID uuid.UUID, role models.UserRole, until time.Time) ([]taskTimeline, error) {
+	if _, err := GetProject(ctx, projectID, userID, role); err != nil {
+		return nil, err
+	}
+
+	tasks, transitions, err := repositories.GetProjectTaskHistory(ctx, projectID, until)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[uuid.UUID][]models.StatusTransition)
+	for _, transition := range transitions {
+		changes[transition.TaskID] = append(changes[transition.TaskID], transition)
+	}
+
+	timelines := make([]taskTimeline, 0, len(tasks))
+	for _, task := range tasks {
+		timeline := taskTimeline{
+			createdAt: task.CreatedAt,
+			initial:   task.Status,
+			changes:   changes[task.ID],
+		}
+		if task.DeletedAt.Valid {
+			deletedAt := task.DeletedAt.Time
+			timeline.deletedAt = &deletedAt
+		}
+
+		// Tasks changed before history was recorded start in the status they first left
+		if len(timeline.changes) > 0 {
+			timeline.initial = timeline.changes[0].From
+		}
+		if timeline.initial == "" {
+			timeline.initial = models.StatusPending
+		}
+
+		timelines = append(timelines, timeline)
+	}
+	return timelines, nil
+}
+
+// statusAt returns the status of the task at a moment, and false if it did not exist then
+func (t taskTimeline) statusAt(at time.Time) (models.Status, bool) {
+	if at.Before(t.createdAt) || (t.deletedAt != nil && !at.Before(*t.deletedAt)) {
+		return "", false
+	}
+
+	status := t.initial
+	for _, change := range t.changes {
+		if change.At.After(at) {
+			break
+		}
+		status = change.To
+	}
+	return status, true
+}
+
+// completion finds the last time the task was moved to Done within [from, until)
+// and when work on it first started before that. Tasks that were reopened
+// afterwards or deleted are not counted.
+func (t taskTimeline) completion(from, until time.Time) (time.Time, *time.Time, bool) {
+	if t.deletedAt != nil && t.deletedAt.Before(until) {
+		return time.Time{}, nil, false
+	}
+
+	doneIndex := -1
+	for i, change := range t.changes {
+		if change.At.Before(from) || !change.At.Before(until) {
+			continue
+		}
+		if change.To == models.StatusDone {
+			doneIndex = i
+		} else if doneIndex >= 0 && change.From == models.StatusDone {
+			doneIndex = -1 // Reopened
+		}
+	}
+	if doneIndex < 0 {
+		return time.Time{}, nil, false
+	}
+
+	var startedAt *time.Time
+	for _, change := range t.changes[:doneIndex] {
+		if change.To == models.StatusInProgress {
+			at := change.At
+			startedAt = &at
+			break
+		}
+	}
+	return t.changes[doneIndex].At, startedAt, true
+}
+
+// durationStats computes the average and median of durations in hours
+func durationStats(durations []time.Duration) models.DurationStats {
+	stats := models.DurationStats{Count: len(durations)}
+	if len(durations) == 0 {
+		return stats
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	stats.AverageHours = roundHours(total.Hours() / float64(len(durations)))
+
+	middle := len(durations) / 2
+	if len(durations)%2 == 0 {
+		stats.MedianHours = roundHours((durations[middle-1] + durations[middle]).Hours() / 2)
+	} else {
+		stats.MedianHours = roundHours(durations[middle].Hours())
+	}
+	return stats
+}
+
+// roundHours rounds hours to two decimals
+func roundHours(hours float64) float64 {
+	return math.Round(hours*100) / 100
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/azka-art/taskwise-backend/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	maxAPIKeysPerUser  = 50
+	apiKeyPrefixLength = 8 // Characters of the key after APIKeyPrefix that are stored to recognize it
+)
+
+// CreateAPIKey creates a named API key with the given scopes for a user
+func CreateAPIKey(ctx context.Context, userID uuid.UUID, req models.APIKeyRequest) (models.APIKeyResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return models.APIKeyResponse{}, invalidInput(errors.New("name is required"))
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return models.APIKeyResponse{}, invalidInput(errors.New("expires_at must be in the future"))
+	}
+
+	scopes, err := normalizeAPIKeyScopes(req.Scopes)
+	if err != nil {
+		return models.APIKeyResponse{}, invalidInput(err)
+	}
+
+	existing, err := repositories.GetAPIKeysByUserID(ctx, userID)
+	if err != nil {
+		return models.APIKeyResponse{}, err
+	}
+	if len(existing) >= maxAPIKeysPerUser {
+		return models.APIKeyResponse{}, invalidInput(errors.New("too many API keys, revoke unused ones first"))
+	}
+
+	secret, err := utils.GenerateSecureToken()
+	if err != nil {
+		return models.APIKeyResponse{}, err
+	}
+	key := models.APIKeyPrefix + secret
+
+	apiKey := models.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    key[:len(models.APIKeyPrefix)+apiKeyPrefixLength],
+		KeyHash:   utils.HashToken(key),
+		Scopes:    scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := repositories.CreateAPIKey(ctx, &apiKey); err != nil {
+		return models.APIKeyResponse{}, err
+	}
+
+	return models.APIKeyResponse{APIKey: apiKey, Key: key}, nil
+}
+
+// GetAPIKeys lists the API keys of a user that have not been revoked
+func GetAPIKeys(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error) {
+	return repositories.GetAPIKeysByUserID(ctx, userID)
+}
+
+// RevokeAPIKey disables an API key of a user
+func RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error {
+	apiKey, err := repositories.GetAPIKeyByID(ctx, keyID)
+	if err != nil {
+		return err
+	}
+	if apiKey == nil || apiKey.UserID != userID || apiKey.RevokedAt != nil {
+		return ErrAPIKeyNotFound
+	}
+
+	return repositories.RevokeAPIKey(ctx, keyID)
+}
+
+// AuthenticateAPIKey checks an API key and returns the user it acts for, like
+// AuthenticateToken does for JWTs, and the key with its scopes
+func AuthenticateAPIKey(ctx context.Context, key, clientIP string) (models.AccessClaims, *models.APIKey, error) {
+	// The key names the organization it acts in
+	apiKey, err := repositories.GetAPIKeyByHash(repositories.AllOrganizations(ctx), utils.HashToken(key))
+	if err != nil {
+		return models.AccessClaims{}, nil, err
+	}
+
+	now := time.Now()
+	if apiKey == nil || !apiKey.IsActive(now) {
+		return models.AccessClaims{}, nil, ErrInvalidAPIKey
+	}
+	ctx = repositories.WithOrganization(ctx, apiKey.OrganizationID)
+
+	// Keys of users who left the organization stop working
+	member, err := repositories.GetOrganizationMember(ctx, apiKey.OrganizationID, apiKey.UserID)
+	if err != nil {
+		return models.AccessClaims{}, nil, err
+	}
+	user, err := repositories.GetUserByID(ctx, apiKey.UserID)
+	if err != nil {
+		return models.AccessClaims{}, nil, err
+	}
+	if member == nil || user == nil {
+		return models.AccessClaims{}, nil, ErrInvalidAPIKey
+	}
+
+	mfaSetupRequired, err := NeedsMFASetup(ctx, member.OrganizationID, user.ID)
+	if err != nil {
+		return models.AccessClaims{}, nil, err
+	}
+
+	if err := repositories.TouchAPIKey(ctx, apiKey.ID, clientIP); err != nil {
+		log.Printf("❌ Failed to record use of API key %s: %v", apiKey.ID, err)
+	}
+
+	claims := models.AccessClaims{
+		UserID:           user.ID,
+		OrganizationID:   member.OrganizationID,
+		Role:             member.Role,
+		EmailVerified:    user.IsEmailVerified(),
+		MFASetupRequired: mfaSetupRequired,
+		TokenID:          apiKey.ID,
+		IssuedAt:         apiKey.CreatedAt,
+	}
+	if apiKey.ExpiresAt != nil {
+		claims.ExpiresAt = *apiKey.ExpiresAt
+	}
+	return claims, apiKey, nil
+}
+
+// normalizeAPIKeyScopes validates scopes and removes duplicates
+func normalizeAPIKeyScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if err := models.ValidateAPIKeyScope(scope); err != nil {
+			return nil, err
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
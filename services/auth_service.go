@@ -1,7 +1,9 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"log"
 	"os"
 	"time"
 
@@ -12,37 +14,62 @@ import (
 	"github.com/google/uuid"
 )
 
-// RegisterUser registers a new user with validation
-func RegisterUser(user models.User) (models.User, error) {
+// accountRepository returns the repository accounts are found and created in.
+// Tests replace it with one that needs no database.
+var accountRepository = func(ctx context.Context) repositories.AccountRepository {
+	return repositories.NewAccountRepository(ctx)
+}
+
+// RegisterUser registers a new user with validation and emails them a link to
+// verify their email address. Registering without an invitation can be turned off
+// with OPEN_REGISTRATION=false.
+func RegisterUser(ctx context.Context, req models.RegisterRequest) (models.User, error) {
+	if !IsOpenRegistrationEnabled() {
+		return models.User{}, ErrRegistrationClosed
+	}
+	return registerUser(ctx, req, nil)
+}
+
+// IsOpenRegistrationEnabled checks if anyone may register without an invitation
+func IsOpenRegistrationEnabled() bool {
+	return os.Getenv("OPEN_REGISTRATION") != "false"
+}
+
+// registerUser creates an account. Users who register on their own get a personal
+// organization; invited users join the organization of their invitation instead,
+// which AcceptInvitation takes care of.
+func registerUser(ctx context.Context, req models.RegisterRequest, invitation *models.Invitation) (models.User, error) {
+	// Email addresses are unique across organizations
+	ctx = repositories.AllOrganizations(ctx)
+
 	// Validate email
-	if err := utils.ValidateEmail(user.Email); err != nil {
-		return models.User{}, err
+	if err := utils.ValidateEmail(req.Email); err != nil {
+		return models.User{}, invalidInput(err)
 	}
 
 	// Validate username
-	if err := utils.ValidateUsername(user.Username); err != nil {
-		return models.User{}, err
+	if err := utils.ValidateUsername(req.Username); err != nil {
+		return models.User{}, invalidInput(err)
 	}
 
 	// Validate password
-	if err := utils.ValidatePassword(user.Password); err != nil {
-		return models.User{}, err
+	if err := utils.ValidatePassword(req.Password); err != nil {
+		return models.User{}, invalidInput(err)
 	}
 
-	// Set default role if not provided
-	if user.Role == "" {
-		user.Role = models.RoleMember
-	}
-
-	// Generate UUID if not provided
-	if user.ID == uuid.Nil {
-		user.ID = uuid.New()
+	// Admins are made by other admins, never by registering
+	user := models.User{
+		ID:       uuid.New(),
+		Username: req.Username,
+		Email:    req.Email,
+		Password: req.Password,
+		Role:     models.RoleMember,
 	}
 
 	// Check if email already exists
-	existingUser, _ := repositories.GetUserByEmail(user.Email)
+	existingUser, _ := repositories.GetUserByEmail(ctx, user.Email)
 	if existingUser != nil {
-		return models.User{}, errors.New("email already in use")
+		return models.User{}, invalidInput(errors.New("email already in use"))
 	}
 
 	// Hash password before saving
@@ -51,74 +78,251 @@ func RegisterUser(user models.User) (models.User, error) {
 		return models.User{}, err
 	}
 	user.Password = hashedPassword
+	user.EmailVerifiedAt = nil
+	if invitation != nil {
+		// The invitation was emailed to the address, which confirms it
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
 
-	// Save to database
-	if err := repositories.CreateUser(&user); err != nil {
+	// Save to database, with the personal organization unless the user is invited
+	err = repositories.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := repositories.CreateUser(ctx, &user); err != nil {
+			return err
+		}
+		if invitation != nil {
+			return nil
+		}
+		return createPersonalOrganization(ctx, &user)
+	})
+	if err != nil {
 		return models.User{}, err
 	}
+	if invitation != nil {
+		user.Password = ""
+		return user, nil
+	}
+
+	// The account exists either way; the user can ask for another link
+	if err := sendVerificationEmail(ctx, &user); err != nil {
+		log.Printf("❌ Failed to send verification email to user %s: %v", user.ID, err)
+	}
 
 	// Clear password before returning
 	user.Password = ""
 	return user, nil
 }
 
-// LoginUser verifies user credentials and returns a JWT token
-func LoginUser(credentials models.LoginRequest) (string, models.User, error) {
+const (
+	accessTokenTTL  = 15 * time.Minute    // Lifetime of a JWT access token
+	refreshTokenTTL = 30 * 24 * time.Hour // Lifetime of a refresh token
+)
+
+// LoginUser verifies user credentials and returns an access and a refresh token.
+// Users with two-factor authentication get an mfa token instead, to be completed
+// with CompleteMFALogin.
+func LoginUser(ctx context.Context, credentials models.LoginRequest) (models.LoginResult, models.User, error) {
+	// The organization is only known once the user signed in
+	ctx = repositories.AllOrganizations(ctx)
+
 	// Validate email format
 	if err := utils.ValidateEmail(credentials.Email); err != nil {
-		return "", models.User{}, err
+		return models.LoginResult{}, models.User{}, err
 	}
 
-	user, err := repositories.GetUserByEmail(credentials.Email)
+	user, err := repositories.GetUserByEmail(ctx, credentials.Email)
 	if err != nil || user == nil {
-		return "", models.User{}, errors.New("invalid email or password")
+		return models.LoginResult{}, models.User{}, errors.New("invalid email or password")
 	}
 
 	// Verify password (Changed `CheckPassword` → `CheckPasswordHash`)
 	if !utils.CheckPasswordHash(credentials.Password, user.Password) {
-		return "", models.User{}, errors.New("invalid email or password")
+		return models.LoginResult{}, models.User{}, errors.New("invalid email or password")
+	}
+
+	if err := checkLoginAllowed(user); err != nil {
+		return models.LoginResult{}, models.User{}, err
 	}
 
-	// Generate JWT token
-	token, err := generateToken(user)
+	result, err := startSession(ctx, user)
 	if err != nil {
-		return "", models.User{}, err
+		return models.LoginResult{}, models.User{}, err
 	}
 
 	// Clear password before returning
 	user.Password = ""
-	return token, *user, nil
+	return result, *user, nil
+}
+
+// startSession logs in a user whose identity was confirmed, asking for a code
+// first if they use two-factor authentication
+func startSession(ctx context.Context, user *models.User) (models.LoginResult, error) {
+	mfa, err := repositories.GetUserMFA(ctx, user.ID)
+	if err != nil {
+		return models.LoginResult{}, err
+	}
+	if mfa.IsEnabled() {
+		return startMFALogin(ctx, user)
+	}
+
+	// Every login starts a new refresh token family, in the user's first organization
+	tokens, err := issueTokens(ctx, user, uuid.New(), uuid.Nil)
+	if err != nil {
+		return models.LoginResult{}, err
+	}
+	return models.LoginResult{Tokens: tokens}, nil
+}
+
+// RefreshTokens exchanges a refresh token for a new access and refresh token.
+// Refresh tokens can only be used once: presenting one again revokes every token
+// issued since the same login, because it may have been stolen.
+func RefreshTokens(ctx context.Context, refreshToken string) (models.AuthTokens, error) {
+	ctx = repositories.AllOrganizations(ctx)
+
+	stored, err := repositories.GetRefreshTokenByHash(ctx, utils.HashToken(refreshToken))
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+	if stored == nil {
+		return models.AuthTokens{}, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	if stored.RevokedAt != nil {
+		return models.AuthTokens{}, ErrInvalidRefreshToken
+	}
+	if stored.UsedAt != nil {
+		return models.AuthTokens{}, revokeReusedRefreshToken(ctx, stored, now)
+	}
+	if now.After(stored.ExpiresAt) {
+		return models.AuthTokens{}, ErrInvalidRefreshToken
+	}
+
+	// A concurrent request may have used the token in the meantime
+	marked, err := repositories.MarkRefreshTokenUsed(ctx, stored.ID, now)
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+	if !marked {
+		return models.AuthTokens{}, revokeReusedRefreshToken(ctx, stored, now)
+	}
+
+	user, err := repositories.GetUserByID(ctx, stored.UserID)
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+	if user == nil {
+		return models.AuthTokens{}, ErrInvalidRefreshToken
+	}
+	if err := checkLoginAllowed(user); err != nil {
+		return models.AuthTokens{}, err
+	}
+
+	// Tokens issued before organizations existed continue in the first organization
+	organizationID := uuid.Nil
+	if stored.OrganizationID != nil {
+		organizationID = *stored.OrganizationID
+	}
+	return issueTokens(ctx, user, stored.FamilyID, organizationID)
 }
 
-// generateToken creates a JWT for a given user
-func generateToken(user *models.User) (string, error) {
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your_secret_key"
+// revokeReusedRefreshToken revokes the family of a refresh token that was presented again
+func revokeReusedRefreshToken(ctx context.Context, token *models.RefreshToken, now time.Time) error {
+	log.Printf("⚠️ Refresh token of user %s was reused, revoking its family %s", token.UserID, token.FamilyID)
+	if err := repositories.RevokeRefreshTokenFamily(ctx, token.FamilyID, now); err != nil {
+		return err
+	}
+	return ErrInvalidRefreshToken
+}
+
+// issueTokens creates an access token for an organization of the user and a
+// refresh token in the given family. Without an organization, the first one the
+// user joined is used.
+func issueTokens(ctx context.Context, user *models.User, familyID, organizationID uuid.UUID) (models.AuthTokens, error) {
+	var member *models.OrganizationMember
+	var err error
+	if organizationID == uuid.Nil {
+		member, err = repositories.GetFirstOrganizationMember(ctx, user.ID)
+	} else {
+		member, err = repositories.GetOrganizationMember(ctx, organizationID, user.ID)
+	}
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+	if member == nil {
+		return models.AuthTokens{}, ErrNotOrganizationMember
+	}
+
+	now := time.Now()
+	tokens := models.AuthTokens{
+		AccessExpiresAt:  now.Add(accessTokenTTL),
+		RefreshExpiresAt: now.Add(refreshTokenTTL),
+		OrganizationID:   member.OrganizationID,
+		Role:             member.Role,
+	}
+
+	mfaSetupRequired, err := NeedsMFASetup(ctx, member.OrganizationID, user.ID)
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+
+	accessToken, err := generateToken(user, member, tokens.AccessExpiresAt, mfaSetupRequired)
+	if err != nil {
+		return models.AuthTokens{}, err
 	}
+	tokens.AccessToken = accessToken
+
+	refreshToken, err := utils.GenerateSecureToken()
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+	tokens.RefreshToken = refreshToken
+
+	if err := repositories.CreateRefreshToken(ctx, &models.RefreshToken{
+		UserID:         user.ID,
+		FamilyID:       familyID,
+		OrganizationID: &member.OrganizationID,
+		TokenHash:      utils.HashToken(refreshToken),
+		ExpiresAt:      tokens.RefreshExpiresAt,
+	}); err != nil {
+		return models.AuthTokens{}, err
+	}
+
+	return tokens, nil
+}
 
+// generateToken creates a JWT access token for a given user in one of their organizations
+func generateToken(user *models.User, member *models.OrganizationMember, expiresAt time.Time, mfaSetupRequired bool) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID.String(),
-		"role":    user.Role,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
-		"iat":     time.Now().Unix(),
+		"jti":            uuid.NewString(),
+		"user_id":        user.ID.String(),
+		"org_id":         member.OrganizationID.String(),
+		"role":           member.Role,
+		"email_verified": user.IsEmailVerified(),
+		"mfa_setup":      mfaSetupRequired, // Only two-factor authentication can be set up
+		"exp":            expiresAt.Unix(),
+		"iat":            time.Now().Unix(),
 	})
 
-	return token.SignedString([]byte(jwtSecret))
+	return token.SignedString([]byte(jwtSecret()))
 }
 
-// ValidateToken verifies a JWT token and returns claims
-func ValidateToken(tokenString string) (jwt.MapClaims, error) {
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your_secret_key"
+// jwtSecret returns the key access tokens are signed with
+func jwtSecret() string {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		secret = "your_secret_key"
 	}
+	return secret
+}
 
+// ValidateToken verifies a JWT token and returns claims
+func ValidateToken(tokenString string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
-		return []byte(jwtSecret), nil
+		return []byte(jwtSecret()), nil
 	})
 
 	if err != nil {
@@ -133,14 +337,14 @@ func ValidateToken(tokenString string) (jwt.MapClaims, error) {
 }
 
 // ChangePassword allows users to update their password
-func ChangePassword(userID uuid.UUID, oldPassword, newPassword string) error {
+func ChangePassword(ctx context.Context, userID uuid.UUID, oldPassword, newPassword string) error {
 	// Validate new password
 	if err := utils.ValidatePassword(newPassword); err != nil {
 		return err
 	}
 
-	user, err := repositories.GetUserByID(userID)
-	if err != nil {
+	user, err := repositories.GetUserByID(ctx, userID)
+	if err != nil || user == nil {
 		return errors.New("user not found")
 	}
 
@@ -155,7 +359,11 @@ func ChangePassword(userID uuid.UUID, oldPassword, newPassword string) error {
 		return err
 	}
 
-	// Update password
-	user.Password = hashedPassword
-	return repositories.UpdateUser(user)
+	// Update password (UpdateUser leaves the password alone)
+	if err := repositories.SetPasswordHash(ctx, userID, hashedPassword); err != nil {
+		return err
+	}
+
+	// Sessions that may have been opened with the old password end
+	return LogoutAll(ctx, userID)
 }
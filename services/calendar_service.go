@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/azka-art/taskwise-backend/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	icsTimeFormat    = "20060102T150405Z"
+	icsLineLimit     = 75               // Maximum octets per content line (RFC 5545 3.1)
+	icsEventDuration = "PT30M"          // Length of the event shown at a task's deadline
+	icsRefreshPeriod = "PT1H"           // How often calendar clients should refresh the feed
+	calendarFeedPath = "/api/calendar/" // Feed URLs are calendarFeedPath + token + ".ics"
+)
+
+// CreateCalendarToken creates a new iCalendar feed for a user
+func CreateCalendarToken(ctx context.Context, userID uuid.UUID, role models.UserRole, req models.CalendarTokenRequest) (models.CalendarTokenResponse, error) {
+	if req.ProjectID != nil {
+		canView, err := CanViewProject(ctx, *req.ProjectID, userID, role)
+		if err != nil {
+			return models.CalendarTokenResponse{}, err
+		}
+		if !canView {
+			return models.CalendarTokenResponse{}, ErrForbidden
+		}
+	}
+
+	token, err := utils.GenerateSecureToken()
+	if err != nil {
+		return models.CalendarTokenResponse{}, err
+	}
+
+	calendarToken := models.CalendarToken{
+		UserID:       userID,
+		Name:         req.Name,
+		TokenHash:    utils.HashToken(token),
+		AssignedToMe: req.AssignedToMe,
+		ProjectID:    req.ProjectID,
+		Label:        req.Label,
+	}
+	if calendarToken.Name == "" {
+		calendarToken.Name = "TaskWise deadlines"
+	}
+
+	if err := repositories.CreateCalendarToken(ctx, &calendarToken); err != nil {
+		return models.CalendarTokenResponse{}, err
+	}
+
+	return models.CalendarTokenResponse{
+		CalendarToken: calendarToken,
+		Token:         token,
+		URL:           calendarFeedPath + token + ".ics",
+	}, nil
+}
+
+// GetCalendarTokens lists the active calendar feeds of a user
+func GetCalendarTokens(ctx context.Context, userID uuid.UUID) ([]models.CalendarToken, error) {
+	return repositories.GetCalendarTokensByUserID(ctx, userID)
+}
+
+// RegenerateCalendarToken replaces the token of a feed, so the old URL stops working
+func RegenerateCalendarToken(ctx context.Context, userID, tokenID uuid.UUID) (models.CalendarTokenResponse, error) {
+	calendarToken, err := getUserCalendarToken(ctx, userID, tokenID)
+	if err != nil {
+		return models.CalendarTokenResponse{}, err
+	}
+
+	token, err := utils.GenerateSecureToken()
+	if err != nil {
+		return models.CalendarTokenResponse{}, err
+	}
+
+	calendarToken.TokenHash = utils.HashToken(token)
+	if err := repositories.UpdateCalendarTokenHash(ctx, calendarToken.ID, calendarToken.TokenHash); err != nil {
+		return models.CalendarTokenResponse{}, err
+	}
+
+	return models.CalendarTokenResponse{
+		CalendarToken: *calendarToken,
+		Token:         token,
+		URL:           calendarFeedPath + token + ".ics",
+	}, nil
+}
+
+// RevokeCalendarToken disables a feed
+func RevokeCalendarToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	if _, err := getUserCalendarToken(ctx, userID, tokenID); err != nil {
+		return err
+	}
+
+	return repositories.RevokeCalendarToken(ctx, tokenID)
+}
+
+// GetCalendarFeed renders the iCalendar feed that belongs to a token
+func GetCalendarFeed(ctx context.Context, token string) (string, error) {
+	// The feed is read without signing in; the token names the organization
+	calendarToken, err := repositories.GetActiveCalendarTokenByHash(repositories.AllOrganizations(ctx), utils.HashToken(token))
+	if err != nil {
+		return "", err
+	}
+	if calendarToken == nil {
+		return "", ErrCalendarTokenNotFound
+	}
+	ctx = repositories.WithOrganization(ctx, calendarToken.OrganizationID)
+
+	member, err := repositories.GetOrganizationMember(ctx, calendarToken.OrganizationID, calendarToken.UserID)
+	if err != nil {
+		return "", err
+	}
+	if member == nil {
+		return "", ErrCalendarTokenNotFound
+	}
+
+	filter := calendarToken.Filter().ForUser(member.UserID, member.Role)
+	tasks, err := repositories.FindTasks(ctx, filter)
+	if err != nil {
+		return "", err
+	}
+
+	if err := repositories.TouchCalendarToken(ctx, calendarToken.ID); err != nil {
+		return "", err
+	}
+
+	return renderCalendar(calendarToken.Name, tasks), nil
+}
+
+// getUserCalendarToken loads a feed token owned by the user
+func getUserCalendarToken(ctx context.Context, userID, tokenID uuid.UUID) (*models.CalendarToken, error) {
+	calendarToken, err := repositories.GetCalendarTokenByID(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if calendarToken == nil || calendarToken.UserID != userID || calendarToken.RevokedAt != nil {
+		return nil, ErrCalendarTokenNotFound
+	}
+	return calendarToken, nil
+}
+
+// renderCalendar builds an RFC 5545 calendar with one event per task deadline
+func renderCalendar(name string, tasks []models.Task) string {
+	var b strings.Builder
+
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//TaskWise//Task Deadlines//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "METHOD:PUBLISH")
+	writeICSLine(&b, "X-WR-CALNAME:"+escapeICSText(name))
+	writeICSLine(&b, "REFRESH-INTERVAL;VALUE=DURATION:"+icsRefreshPeriod)
+	writeICSLine(&b, "X-PUBLISHED-TTL:"+icsRefreshPeriod)
+
+	for _, task := range tasks {
+		if task.Deadline == nil {
+			continue
+		}
+
+		summary := task.Title
+		if task.Status == models.StatusDone {
+			summary = "✔ " + summary
+		}
+
+		description := fmt.Sprintf("Priority: %s\nStatus: %s", task.Priority, task.Status)
+		if task.Description != "" {
+			description = task.Description + "\n\n" + description
+		}
+
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, "UID:"+task.ID.String()+"@taskwise")
+		writeICSLine(&b, "DTSTAMP:"+icsTimestamp(task.UpdatedAt))
+		writeICSLine(&b, "LAST-MODIFIED:"+icsTimestamp(task.UpdatedAt))
+		writeICSLine(&b, "DTSTART:"+icsTimestamp(*task.Deadline))
+		writeICSLine(&b, "DURATION:"+icsEventDuration)
+		writeICSLine(&b, "SUMMARY:"+escapeICSText(summary))
+		writeICSLine(&b, "DESCRIPTION:"+escapeICSText(description))
+		if labels := task.LabelNames(); len(labels) > 0 {
+			for i, label := range labels {
+				labels[i] = escapeICSText(label)
+			}
+			writeICSLine(&b, "CATEGORIES:"+strings.Join(labels, ","))
+		}
+		writeICSLine(&b, "TRANSP:TRANSPARENT")
+		writeICSLine(&b, "END:VEVENT")
+	}
+
+	writeICSLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+// escapeICSText escapes a TEXT property value (RFC 5545 3.3.11)
+func escapeICSText(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(value)
+}
+
+// writeICSLine writes a content line, folding it at 75 octets without splitting
+// UTF-8 characters (RFC 5545 3.1)
+func writeICSLine(b *strings.Builder, line string) {
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = icsLineLimit - 1 // Continuation lines start with a space
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// icsTimestamp formats a time in the UTC form used by the feed
+func icsTimestamp(t time.Time) string {
+	return t.UTC().Format(icsTimeFormat)
+}
@@ -1,53 +1,241 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"github.com/azka-art/taskwise-backend/config"
 	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
 	"github.com/google/uuid"
 )
 
-// CreateComment adds a new comment to a task
-func CreateComment(comment models.Comment) (models.Comment, error) {
+// commentRepository returns the repository comments are stored in
+func commentRepository(ctx context.Context) repositories.CommentRepository {
+	return repositories.NewCommentRepository(config.DB.WithContext(ctx))
+}
+
+// CreateComment adds a new comment to a task the user can see. Replies to a
+// reply are added to the thread of the top-level comment.
+func CreateComment(ctx context.Context, comment models.Comment, role models.UserRole) (models.Comment, error) {
 	// Ensure TaskID and UserID are valid
 	if comment.TaskID == uuid.Nil || comment.UserID == uuid.Nil {
 		return models.Comment{}, errors.New("task ID and user ID are required")
 	}
 
+	// Make sure the task exists and find the project it belongs to
+	task, err := getVisibleTask(ctx, comment.TaskID, comment.UserID, role)
+	if err != nil {
+		return models.Comment{}, err
+	}
+
+	if comment.ParentID != nil {
+		parent, err := getTaskComment(ctx, comment.TaskID, *comment.ParentID)
+		if err != nil {
+			return models.Comment{}, err
+		}
+		if parent.ParentID != nil {
+			comment.ParentID = parent.ParentID
+		}
+	}
+
+	if err := comment.Validate(); err != nil {
+		return models.Comment{}, invalidInput(err)
+	}
+
 	// Save to database
-	if err := config.DB.Create(&comment).Error; err != nil {
+	if err := commentRepository(ctx).Create(&comment); err != nil {
 		return models.Comment{}, err
 	}
+
+	publishTaskEvent(ctx, models.EventCommentCreated, *task, comment.UserID, commentEventData(ctx, comment, comment.UserID))
 	return comment, nil
 }
 
-// GetCommentsByTaskID retrieves all comments for a specific task
-func GetCommentsByTaskID(taskID uuid.UUID) ([]models.Comment, error) {
-	var comments []models.Comment
-	if err := config.DB.Where("task_id = ?", taskID).Find(&comments).Error; err != nil {
+// ReplyToComment adds a reply to a comment of a task
+func ReplyToComment(ctx context.Context, taskID, parentID, userID uuid.UUID, role models.UserRole, content string) (models.Comment, error) {
+	return CreateComment(ctx, models.Comment{
+		TaskID:   taskID,
+		UserID:   userID,
+		ParentID: &parentID,
+		Content:  content,
+	}, role)
+}
+
+// GetCommentsByTaskID retrieves a page of the comment threads of a task with their reactions
+func GetCommentsByTaskID(ctx context.Context, taskID, userID uuid.UUID, role models.UserRole, page, size int) ([]models.Comment, int64, error) {
+	if _, err := getVisibleTask(ctx, taskID, userID, role); err != nil {
+		return nil, 0, err
+	}
+
+	repo := commentRepository(ctx)
+	threads, total, err := repo.FindThreadsByTaskID(taskID, page, size)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var ids []uuid.UUID
+	for _, thread := range threads {
+		ids = append(ids, thread.ID)
+		for _, reply := range thread.Replies {
+			ids = append(ids, reply.ID)
+		}
+	}
+
+	reactions, err := repo.CountReactions(ids, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range threads {
+		threads[i].Reactions = reactions[threads[i].ID]
+		for j := range threads[i].Replies {
+			threads[i].Replies[j].Reactions = reactions[threads[i].Replies[j].ID]
+		}
+	}
+	return threads, total, nil
+}
+
+// UpdateComment changes the content of a comment (its author only), keeping the
+// previous content as a revision
+func UpdateComment(ctx context.Context, taskID, commentID, userID uuid.UUID, role models.UserRole, content string) (models.Comment, error) {
+	task, err := getVisibleTask(ctx, taskID, userID, role)
+	if err != nil {
+		return models.Comment{}, err
+	}
+
+	comment, err := getTaskComment(ctx, taskID, commentID)
+	if err != nil {
+		return models.Comment{}, err
+	}
+	if comment.UserID != userID {
+		return models.Comment{}, ErrForbidden
+	}
+
+	if content == comment.Content {
+		return withReactions(ctx, *comment, userID)
+	}
+
+	revision := models.CommentRevision{CommentID: comment.ID, Content: comment.Content, EditedBy: userID}
+	now := time.Now()
+	comment.Content = content
+	comment.EditedAt = &now
+
+	if err := comment.Validate(); err != nil {
+		return models.Comment{}, invalidInput(err)
+	}
+	if err := commentRepository(ctx).UpdateWithRevision(comment, &revision); err != nil {
+		return models.Comment{}, err
+	}
+
+	publishTaskEvent(ctx, models.EventCommentUpdated, *task, userID, commentEventData(ctx, *comment, userID))
+	return withReactions(ctx, *comment, userID)
+}
+
+// GetCommentRevisions lists the previous contents of a comment, newest first
+func GetCommentRevisions(ctx context.Context, taskID, commentID, userID uuid.UUID, role models.UserRole) ([]models.CommentRevision, error) {
+	comment, err := getVisibleComment(ctx, taskID, commentID, userID, role)
+	if err != nil {
 		return nil, err
 	}
-	return comments, nil
+
+	return commentRepository(ctx).FindRevisions(comment.ID)
+}
+
+// AddReaction adds the user's emoji reaction to a comment and returns the comment
+// with its updated reactions
+func AddReaction(ctx context.Context, taskID, commentID, userID uuid.UUID, role models.UserRole, emoji string) (models.Comment, error) {
+	if err := models.ValidateEmoji(emoji); err != nil {
+		return models.Comment{}, invalidInput(err)
+	}
+
+	comment, err := getVisibleComment(ctx, taskID, commentID, userID, role)
+	if err != nil {
+		return models.Comment{}, err
+	}
+
+	reaction := models.CommentReaction{CommentID: comment.ID, UserID: userID, Emoji: emoji}
+	if err := commentRepository(ctx).AddReaction(&reaction); err != nil {
+		return models.Comment{}, err
+	}
+
+	return withReactions(ctx, *comment, userID)
+}
+
+// RemoveReaction removes the user's emoji reaction from a comment and returns the
+// comment with its updated reactions
+func RemoveReaction(ctx context.Context, taskID, commentID, userID uuid.UUID, role models.UserRole, emoji string) (models.Comment, error) {
+	comment, err := getVisibleComment(ctx, taskID, commentID, userID, role)
+	if err != nil {
+		return models.Comment{}, err
+	}
+
+	if err := commentRepository(ctx).RemoveReaction(comment.ID, userID, emoji); err != nil {
+		return models.Comment{}, err
+	}
+
+	return withReactions(ctx, *comment, userID)
 }
 
-// DeleteComment removes a comment by its ID
-func DeleteComment(commentID uuid.UUID, userID uuid.UUID) error {
-	var comment models.Comment
+// DeleteComment removes a comment and its replies. Comments can be deleted by
+// their author and, for moderation, by project admins and users who may delete any comment.
+func DeleteComment(ctx context.Context, taskID, commentID, userID uuid.UUID, role models.UserRole) error {
+	task, err := getVisibleTask(ctx, taskID, userID, role)
+	if err != nil {
+		return err
+	}
 
-	// Check if comment exists
-	if err := config.DB.Where("id = ?", commentID).First(&comment).Error; err != nil {
-		return errors.New("comment not found")
+	comment, err := getTaskComment(ctx, taskID, commentID)
+	if err != nil {
+		return err
 	}
 
-	// Ensure only the comment owner can delete it
 	if comment.UserID != userID {
-		return errors.New("unauthorized to delete this comment")
+		canModerate := role.Can(models.PermCommentDeleteAny)
+		if task.ProjectID != nil {
+			if canModerate, err = CanManageProject(ctx, *task.ProjectID, userID, role); err != nil {
+				return err
+			}
+		}
+		if !canModerate {
+			return ErrForbidden
+		}
 	}
 
-	// Delete comment
-	if err := config.DB.Delete(&comment).Error; err != nil {
+	if err := commentRepository(ctx).Delete(comment.ID); err != nil {
 		return err
 	}
+
+	publishTaskEvent(ctx, models.EventCommentDeleted, *task, userID, commentEventData(ctx, *comment, userID))
 	return nil
 }
+
+// getVisibleComment loads a comment of a task the user can see
+func getVisibleComment(ctx context.Context, taskID, commentID, userID uuid.UUID, role models.UserRole) (*models.Comment, error) {
+	if _, err := getVisibleTask(ctx, taskID, userID, role); err != nil {
+		return nil, err
+	}
+	return getTaskComment(ctx, taskID, commentID)
+}
+
+// getTaskComment loads a comment that belongs to a task
+func getTaskComment(ctx context.Context, taskID, commentID uuid.UUID) (*models.Comment, error) {
+	comment, err := commentRepository(ctx).FindByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil || comment.TaskID != taskID {
+		return nil, ErrCommentNotFound
+	}
+	return comment, nil
+}
+
+// withReactions attaches the reaction counts, as seen by the user, to a comment
+func withReactions(ctx context.Context, comment models.Comment, userID uuid.UUID) (models.Comment, error) {
+	reactions, err := commentRepository(ctx).CountReactions([]uuid.UUID{comment.ID}, userID)
+	if err != nil {
+		return models.Comment{}, err
+	}
+	comment.Reactions = reactions[comment.ID]
+	return comment, nil
+}
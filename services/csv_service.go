@@ -0,0 +1,367 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/google/uuid"
+)
+
+const (
+	csvExportBatchSize = 500  // Tasks loaded and written at a time while exporting
+	csvImportMaxRows   = 5000 // Rows accepted by a single import
+	csvLabelSeparator  = ";"  // Separates labels within one CSV cell
+)
+
+// csvExportColumns are the columns written by ExportTasksCSV
+var csvExportColumns = []string{
+	"id", "title", "description", "priority", "status", "deadline",
+	"labels", "project_id", "assignee_id", "created_by", "created_at", "updated_at",
+}
+
+// csvImportFields are the task fields an import can map CSV columns onto
+var csvImportFields = []string{"title", "description", "priority", "status", "deadline", "labels", "assignee"}
+
+// TaskImportOptions controls how a CSV import creates tasks
+type TaskImportOptions struct {
+	UserID    uuid.UUID
+	ProjectID *uuid.UUID        // Project the imported tasks are added to
+	Mapping   map[string]string // Task field -> CSV column header
+	DryRun    bool              // Only validate and preview, don't insert
+}
+
+// ExportTasksCSV streams the tasks matching the filter as CSV
+func ExportTasksCSV(ctx context.Context, w io.Writer, filter models.TaskFilter) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvExportColumns); err != nil {
+		return err
+	}
+
+	err := repositories.FindTasksInBatches(ctx, filter, csvExportBatchSize, func(tasks []models.Task) error {
+		for _, task := range tasks {
+			if err := writer.Write(taskToCSVRecord(task)); err != nil {
+				return err
+			}
+		}
+
+		// Send every batch to the client as soon as it is written
+		writer.Flush()
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		return writer.Error()
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// ImportTasksCSV validates every row of a CSV file and, unless it is a dry run,
+// inserts all tasks in one transaction. Nothing is inserted if any row is invalid.
+func ImportTasksCSV(ctx context.Context, r io.Reader, opts TaskImportOptions) (models.TaskImportResult, error) {
+	result := models.TaskImportResult{DryRun: opts.DryRun}
+
+	if opts.ProjectID != nil {
+		member, err := repositories.GetProjectMember(ctx, *opts.ProjectID, opts.UserID)
+		if err != nil {
+			return result, err
+		}
+		if member == nil {
+			return result, ErrForbidden
+		}
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // Rows with missing trailing cells are reported per row
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return result, invalidInput(errors.New("the CSV file has no header row"))
+	}
+
+	columns, err := resolveCSVMapping(header, opts.Mapping)
+	if err != nil {
+		return result, invalidInput(err)
+	}
+
+	var tasks []models.Task
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// Parse errors name the line they were found on
+			return result, invalidInput(err)
+		}
+		if isBlankCSVRecord(record) {
+			continue
+		}
+
+		result.Total++
+		if result.Total > csvImportMaxRows {
+			return result, invalidInput(fmt.Errorf("an import can contain at most %d rows", csvImportMaxRows))
+		}
+
+		// Quoted cells can span lines, so rows are numbered by the line they start on
+		line, _ := reader.FieldPos(0)
+		task, rowErrors := csvRecordToTask(ctx, record, columns, opts)
+		row := models.ImportRowResult{Row: line, Task: &task, Errors: rowErrors}
+		if len(rowErrors) == 0 {
+			result.Valid++
+			tasks = append(tasks, task)
+		} else {
+			result.Invalid++
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	if opts.DryRun || result.Invalid > 0 || len(tasks) == 0 {
+		return result, nil
+	}
+
+	if err := repositories.CreateTasks(ctx, tasks); err != nil {
+		return result, err
+	}
+	result.Created = len(tasks)
+
+	for _, task := range tasks {
+		publishTaskEvent(ctx, models.EventTaskCreated, task, opts.UserID, taskEventData(ctx, task, opts.UserID))
+	}
+	return result, nil
+}
+
+// resolveCSVMapping finds the column index of every mapped task field. Without an
+// explicit mapping, columns named like a task field are used.
+func resolveCSVMapping(header []string, mapping map[string]string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	columns := make(map[string]int)
+	if len(mapping) == 0 {
+		for _, field := range csvImportFields {
+			if i, ok := index[field]; ok {
+				columns[field] = i
+			}
+		}
+	} else {
+		for field, column := range mapping {
+			if !isCSVImportField(field) {
+				return nil, fmt.Errorf("unknown task field %q in mapping", field)
+			}
+			i, ok := index[strings.ToLower(strings.TrimSpace(column))]
+			if !ok {
+				return nil, fmt.Errorf("column %q not found in the CSV header", column)
+			}
+			columns[field] = i
+		}
+	}
+
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New("a column must be mapped to title")
+	}
+	return columns, nil
+}
+
+// csvRecordToTask builds a task from a CSV row and collects all validation errors
+func csvRecordToTask(ctx context.Context, record []string, columns map[string]int, opts TaskImportOptions) (models.Task, []string) {
+	value := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	task := models.Task{
+		ID:          uuid.New(),
+		Title:       value("title"),
+		Description: value("description"),
+		ProjectID:   opts.ProjectID,
+		CreatedBy:   opts.UserID,
+	}
+
+	var rowErrors []string
+
+	if priority := value("priority"); priority != "" {
+		parsed, ok := parsePriority(priority)
+		if !ok {
+			rowErrors = append(rowErrors, fmt.Sprintf("invalid priority %q", priority))
+		}
+		task.Priority = parsed
+	}
+
+	if status := value("status"); status != "" {
+		parsed, ok := parseStatus(status)
+		if !ok {
+			rowErrors = append(rowErrors, fmt.Sprintf("invalid status %q", status))
+		}
+		task.Status = parsed
+	}
+
+	if deadline := value("deadline"); deadline != "" {
+		parsed, err := parseDeadline(deadline)
+		if err != nil {
+			rowErrors = append(rowErrors, err.Error())
+		} else {
+			task.Deadline = &parsed
+		}
+	}
+
+	if labels := value("labels"); labels != "" {
+		for _, name := range strings.Split(labels, csvLabelSeparator) {
+			if name = strings.TrimSpace(name); name != "" {
+				task.Labels = append(task.Labels, models.TaskLabel{TaskID: task.ID, Name: name})
+			}
+		}
+	}
+
+	if assignee := value("assignee"); assignee != "" {
+		assigneeID, err := resolveAssignee(ctx, assignee)
+		if err != nil {
+			rowErrors = append(rowErrors, err.Error())
+		} else {
+			task.AssigneeID = &assigneeID
+			problem, err := assigneeProblem(ctx, task)
+			if err != nil {
+				rowErrors = append(rowErrors, err.Error())
+			} else if problem != "" {
+				rowErrors = append(rowErrors, problem)
+			}
+		}
+	}
+
+	task.SetDefaults()
+	if len(rowErrors) == 0 {
+		if err := task.Validate(); err != nil {
+			rowErrors = append(rowErrors, err.Error())
+		}
+	}
+
+	return task, rowErrors
+}
+
+// taskToCSVRecord converts a task to a row of the CSV export
+func taskToCSVRecord(task models.Task) []string {
+	formatID := func(id *uuid.UUID) string {
+		if id == nil {
+			return ""
+		}
+		return id.String()
+	}
+
+	deadline := ""
+	if task.Deadline != nil {
+		deadline = task.Deadline.UTC().Format(time.RFC3339)
+	}
+
+	record := []string{
+		task.ID.String(),
+		task.Title,
+		task.Description,
+		string(task.Priority),
+		string(task.Status),
+		deadline,
+		strings.Join(task.LabelNames(), csvLabelSeparator),
+		formatID(task.ProjectID),
+		formatID(task.AssigneeID),
+		task.CreatedBy.String(),
+		task.CreatedAt.UTC().Format(time.RFC3339),
+		task.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	for i, cell := range record {
+		record[i] = escapeCSVFormula(cell)
+	}
+	return record
+}
+
+// escapeCSVFormula prefixes cells that spreadsheets would run as a formula with a
+// quote, so they are shown as text
+func escapeCSVFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// resolveAssignee finds the user referenced by an email address or user ID
+func resolveAssignee(ctx context.Context, value string) (uuid.UUID, error) {
+	if id, err := uuid.Parse(value); err == nil {
+		return id, nil
+	}
+
+	user, err := repositories.GetUserByEmail(ctx, value)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if user == nil {
+		return uuid.Nil, fmt.Errorf("no user with email %q", value)
+	}
+	return user.ID, nil
+}
+
+// parsePriority matches a priority case-insensitively
+func parsePriority(value string) (models.Priority, bool) {
+	for _, p := range []models.Priority{models.PriorityLow, models.PriorityMedium, models.PriorityHigh} {
+		if strings.EqualFold(value, string(p)) {
+			return p, true
+		}
+	}
+	return models.Priority(value), false
+}
+
+// parseStatus matches a status case-insensitively, ignoring spaces, dashes and underscores
+func parseStatus(value string) (models.Status, bool) {
+	normalize := strings.NewReplacer(" ", "", "-", "", "_", "")
+	for _, s := range []models.Status{models.StatusPending, models.StatusInProgress, models.StatusDone} {
+		if strings.EqualFold(normalize.Replace(value), normalize.Replace(string(s))) {
+			return s, true
+		}
+	}
+	return models.Status(value), false
+}
+
+// parseDeadline accepts RFC 3339 timestamps and plain dates (end of that day, UTC)
+func parseDeadline(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid deadline %q, expected YYYY-MM-DD or RFC 3339", value)
+}
+
+// isCSVImportField checks if a task field can be mapped by an import
+func isCSVImportField(field string) bool {
+	for _, f := range csvImportFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// isBlankCSVRecord checks if every cell of a row is empty
+func isBlankCSVRecord(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/azka-art/taskwise-backend/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	emailVerificationTTL    = 24 * time.Hour  // How long a verification link can be used
+	verificationResendDelay = time.Minute     // Minimum time between two verification emails
+	verifyEmailPath         = "/verify-email" // Page of the app the verification link opens
+)
+
+// GetUnverifiedEmailPolicy returns what users with an unverified email address can
+// do, configured with UNVERIFIED_EMAIL_POLICY. It defaults to read_only.
+func GetUnverifiedEmailPolicy() models.UnverifiedEmailPolicy {
+	policy := models.UnverifiedEmailPolicy(strings.TrimSpace(os.Getenv("UNVERIFIED_EMAIL_POLICY")))
+	switch policy {
+	case models.UnverifiedAllow, models.UnverifiedReadOnly, models.UnverifiedBlock:
+		return policy
+	case "":
+		return models.UnverifiedReadOnly
+	default:
+		log.Printf("⚠️ Unknown UNVERIFIED_EMAIL_POLICY %q, using %s", policy, models.UnverifiedReadOnly)
+		return models.UnverifiedReadOnly
+	}
+}
+
+// IsEmailVerified checks if a user verified their email address
+func IsEmailVerified(ctx context.Context, userID uuid.UUID) (bool, error) {
+	user, err := repositories.GetUserByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user != nil && user.IsEmailVerified(), nil
+}
+
+// checkLoginAllowed refuses tokens to users with an unverified email address when
+// the policy blocks them
+func checkLoginAllowed(user *models.User) error {
+	if !user.IsEmailVerified() && GetUnverifiedEmailPolicy() == models.UnverifiedBlock {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// ResendVerificationEmail emails a new verification link to the user with the
+// given email. Like RequestPasswordReset, it does nothing for unknown or already
+// verified emails without telling the caller, and sends at most one email per
+// verificationResendDelay.
+func ResendVerificationEmail(ctx context.Context, email string) error {
+	// Checked first, so that the error doesn't tell whether the account exists
+	if _, err := appURL(); err != nil {
+		return err
+	}
+	if err := CheckMailer(); err != nil {
+		return err
+	}
+
+	// Accounts are found by email address across organizations
+	ctx = repositories.AllOrganizations(ctx)
+
+	user, err := repositories.GetUserByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		return err
+	}
+	if user == nil || user.IsEmailVerified() {
+		return nil
+	}
+
+	latest, err := repositories.GetLatestEmailVerificationToken(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if latest != nil && time.Since(latest.CreatedAt) < verificationResendDelay {
+		return nil
+	}
+
+	return sendVerificationEmail(ctx, user)
+}
+
+// VerifyEmail marks the email address of the user a verification token was sent to as verified
+func VerifyEmail(ctx context.Context, token string) error {
+	// Email addresses are verified without signing in to an organization
+	ctx = repositories.AllOrganizations(ctx)
+
+	stored, err := repositories.GetEmailVerificationTokenByHash(ctx, utils.HashToken(token))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if stored == nil || now.After(stored.ExpiresAt) {
+		return ErrInvalidVerificationToken
+	}
+
+	consumed, err := repositories.ConsumeEmailVerificationToken(ctx, stored, now)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidVerificationToken
+	}
+	return nil
+}
+
+// sendVerificationEmail emails a link that verifies the user's email address.
+// The link points to the app on APP_URL, which verifies the token with the API.
+func sendVerificationEmail(ctx context.Context, user *models.User) error {
+	baseURL, err := appURL()
+	if err != nil {
+		return err
+	}
+	if err := CheckMailer(); err != nil {
+		return err
+	}
+
+	token, err := utils.GenerateSecureToken()
+	if err != nil {
+		return err
+	}
+
+	if err := repositories.CreateEmailVerificationToken(ctx, &models.EmailVerificationToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+	}); err != nil {
+		return err
+	}
+
+	link := baseURL + verifyEmailPath + "?token=" + url.QueryEscape(token)
+	return sendMailAsync(MailMessage{
+		To:      user.Email,
+		Subject: "Verify your TaskWise email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Please confirm that this is your email address by opening this link:\n\n"+
+			"%s\n\n"+
+			"The link expires in %d hours. If you did not sign up for TaskWise, you can ignore this email.\n",
+			user.Username, link, int(emailVerificationTTL.Hours())),
+	})
+}
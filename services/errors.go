@@ -0,0 +1,78 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrAPIKeyNotFound is returned when an API key does not exist, is revoked or belongs to someone else
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	// ErrAppURLNotConfigured is returned when an email with a link would be sent but APP_URL is not set
+	ErrAppURLNotConfigured = errors.New("APP_URL is not configured")
+	// ErrCalendarTokenNotFound is returned when a calendar feed token is unknown or revoked
+	ErrCalendarTokenNotFound = errors.New("calendar feed not found")
+	// ErrCommentNotFound is returned when a comment does not exist or does not belong to the task
+	ErrCommentNotFound = errors.New("comment not found")
+	// ErrEmailNotVerified is returned when the policy requires a verified email address
+	ErrEmailNotVerified = errors.New("email address is not verified")
+	// ErrEscalationPolicyNotFound is returned when an escalation policy does not exist
+	ErrEscalationPolicyNotFound = errors.New("escalation policy not found")
+	// ErrForbidden is returned when the user is not allowed to perform an action
+	ErrForbidden = errors.New("forbidden")
+	// ErrInvalidAPIKey is returned when an API key is unknown, expired or revoked
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	// ErrInvalidInput wraps validation errors that should be reported back to the client
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrInvalidInvitation is returned when an invitation token is unknown, expired, revoked or used
+	ErrInvalidInvitation = errors.New("invalid invitation")
+	// ErrInvalidMFACode is returned when a TOTP or recovery code is wrong or was already used
+	ErrInvalidMFACode = errors.New("invalid two-factor authentication code")
+	// ErrInvalidMFAToken is returned when the token between the two login steps is invalid, expired or used up
+	ErrInvalidMFAToken = errors.New("invalid mfa token")
+	// ErrInvalidRefreshToken is returned when a refresh token is unknown, expired, used or revoked
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrInvalidResetToken is returned when a password reset token is unknown, expired or used
+	ErrInvalidResetToken = errors.New("invalid password reset token")
+	// ErrInvalidVerificationToken is returned when an email verification token is unknown, expired or used
+	ErrInvalidVerificationToken = errors.New("invalid email verification token")
+	// ErrInvalidOIDCState is returned when a single sign-on callback does not belong to a sign-in that was started here
+	ErrInvalidOIDCState = errors.New("invalid or expired sign-in state")
+	// ErrInvitationNotFound is returned when an invitation does not exist, is no longer pending or is not visible to the user
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrMFALocked is returned when codes of a user are not checked for a while after too many wrong ones
+	ErrMFALocked = errors.New("too many wrong two-factor authentication codes")
+	// ErrMailNotConfigured is returned when an email would be sent but no mailer is configured
+	ErrMailNotConfigured = errors.New("sending emails is not configured")
+	// ErrNotOrganizationMember is returned when a user signs in to or switches to an organization they don't belong to
+	ErrNotOrganizationMember = errors.New("not a member of the organization")
+	// ErrOIDCAccountNotAllowed is returned when a single sign-on identity can't be linked to an account
+	ErrOIDCAccountNotAllowed = errors.New("account can't sign in")
+	// ErrOIDCLoginFailed is returned when talking to a single sign-on provider failed
+	ErrOIDCLoginFailed = errors.New("single sign-on failed")
+	// ErrOIDCProviderNotFound is returned when no single sign-on provider with the name is configured
+	ErrOIDCProviderNotFound = errors.New("sign-in provider not found")
+	// ErrOrganizationNotFound is returned when an organization does not exist
+	ErrOrganizationNotFound = errors.New("organization not found")
+	// ErrProjectNotFound is returned when a project does not exist
+	ErrProjectNotFound = errors.New("project not found")
+	// ErrRegistrationClosed is returned when self-registration is turned off
+	ErrRegistrationClosed = errors.New("registration is by invitation only")
+	// ErrSLANotFound is returned when no SLA applies to a task or an SLA policy does not exist
+	ErrSLANotFound = errors.New("SLA not found")
+	// ErrTaskNotFound is returned when a task does not exist or is not visible to the user
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrTokenRevoked is returned for access tokens that were revoked by logging out
+	ErrTokenRevoked = errors.New("token has been revoked")
+	// ErrUserNotFound is returned when a user does not exist
+	ErrUserNotFound = errors.New("user not found")
+	// ErrViewNotFound is returned when a saved view does not exist or is not visible to the user
+	ErrViewNotFound = errors.New("view not found")
+	// ErrWebhookNotFound is returned when a webhook or delivery does not exist
+	ErrWebhookNotFound = errors.New("webhook not found")
+)
+
+// invalidInput marks a validation error as ErrInvalidInput, keeping its message
+func invalidInput(err error) error {
+	return fmt.Errorf("%w: %s", ErrInvalidInput, err.Error())
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/google/uuid"
+)
+
+const (
+	escalationInterval  = time.Minute // How often overdue tasks are checked
+	escalationBatchSize = 100         // Tasks escalated per policy and run
+)
+
+// StartEscalationWorker starts the background worker that applies the escalation
+// policies of every project to overdue tasks
+func StartEscalationWorker() {
+	ctx := repositories.AllOrganizations(context.Background())
+	go func() {
+		ticker := time.NewTicker(escalationInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			runEscalationPolicies(ctx)
+		}
+	}()
+}
+
+// CreateEscalationPolicy adds an escalation policy to a project (project admins only)
+func CreateEscalationPolicy(ctx context.Context, projectID, actorID uuid.UUID, actorRole models.UserRole, req models.EscalationPolicyRequest) (models.EscalationPolicy, error) {
+	if err := requireProjectAdmin(ctx, projectID, actorID, actorRole); err != nil {
+		return models.EscalationPolicy{}, err
+	}
+
+	policy := models.EscalationPolicy{ProjectID: projectID, CreatedBy: actorID, Active: true}
+	if err := applyEscalationPolicyRequest(ctx, &policy, req); err != nil {
+		return models.EscalationPolicy{}, err
+	}
+
+	if err := repositories.CreateEscalationPolicy(ctx, &policy); err != nil {
+		return models.EscalationPolicy{}, err
+	}
+	return policy, nil
+}
+
+// GetEscalationPolicies lists the escalation policies of a project (project admins only)
+func GetEscalationPolicies(ctx context.Context, projectID, actorID uuid.UUID, actorRole models.UserRole) ([]models.EscalationPolicy, error) {
+	if err := requireProjectAdmin(ctx, projectID, actorID, actorRole); err != nil {
+		return nil, err
+	}
+
+	return repositories.GetEscalationPoliciesByProjectID(ctx, projectID)
+}
+
+// UpdateEscalationPolicy changes an escalation policy (project admins only)
+func UpdateEscalationPolicy(ctx context.Context, projectID, policyID, actorID uuid.UUID, actorRole models.UserRole, req models.EscalationPolicyRequest) (models.EscalationPolicy, error) {
+	policy, err := getProjectEscalationPolicy(ctx, projectID, policyID, actorID, actorRole)
+	if err != nil {
+		return models.EscalationPolicy{}, err
+	}
+
+	if err := applyEscalationPolicyRequest(ctx, policy, req); err != nil {
+		return models.EscalationPolicy{}, err
+	}
+
+	if err := repositories.UpdateEscalationPolicy(ctx, policy); err != nil {
+		if errors.Is(err, repositories.ErrNotUpdated) {
+			return models.EscalationPolicy{}, ErrEscalationPolicyNotFound
+		}
+		return models.EscalationPolicy{}, err
+	}
+	return *policy, nil
+}
+
+// DeleteEscalationPolicy removes an escalation policy (project admins only)
+func DeleteEscalationPolicy(ctx context.Context, projectID, policyID, actorID uuid.UUID, actorRole models.UserRole) error {
+	if _, err := getProjectEscalationPolicy(ctx, projectID, policyID, actorID, actorRole); err != nil {
+		return err
+	}
+
+	return repositories.DeleteEscalationPolicy(ctx, policyID)
+}
+
+// getProjectEscalationPolicy loads a policy of a project the actor may manage
+func getProjectEscalationPolicy(ctx context.Context, projectID, policyID, actorID uuid.UUID, actorRole models.UserRole) (*models.EscalationPolicy, error) {
+	if err := requireProjectAdmin(ctx, projectID, actorID, actorRole); err != nil {
+		return nil, err
+	}
+
+	policy, err := repositories.GetEscalationPolicyByID(ctx, policyID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil || policy.ProjectID != projectID {
+		return nil, ErrEscalationPolicyNotFound
+	}
+	return policy, nil
+}
+
+// applyEscalationPolicyRequest copies and validates the requested settings of a policy
+func applyEscalationPolicyRequest(ctx context.Context, policy *models.EscalationPolicy, req models.EscalationPolicyRequest) error {
+	policy.Name = req.Name
+	policy.OverdueHours = req.OverdueHours
+	policy.Action = req.Action
+	policy.AssigneeID = nil
+	if req.Action == models.EscalationReassign {
+		policy.AssigneeID = req.AssigneeID
+	}
+	if req.Active != nil {
+		policy.Active = *req.Active
+	}
+
+	if err := policy.Validate(); err != nil {
+		return invalidInput(err)
+	}
+
+	if policy.AssigneeID != nil {
+		member, err := repositories.GetProjectMember(ctx, policy.ProjectID, *policy.AssigneeID)
+		if err != nil {
+			return err
+		}
+		if member == nil {
+			return invalidInput(errors.New("assignee is not a member of the project"))
+		}
+	}
+	return nil
+}
+
+// runEscalationPolicies applies every active policy to the tasks it has not acted on yet
+func runEscalationPolicies(ctx context.Context) {
+	policies, err := repositories.GetActiveEscalationPolicies(ctx)
+	if err != nil {
+		log.Printf("❌ Failed to load escalation policies: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, policy := range policies {
+		tasks, err := repositories.FindTasksToEscalate(ctx, policy, now, escalationBatchSize)
+		if err != nil {
+			log.Printf("❌ Failed to find overdue tasks for policy %s: %v", policy.ID, err)
+			continue
+		}
+
+		for _, task := range tasks {
+			if err := escalateTask(ctx, policy, task, now); err != nil {
+				log.Printf("❌ Failed to escalate task %s with policy %s: %v", task.ID, policy.ID, err)
+			}
+		}
+	}
+}
+
+// escalateTask applies a policy's action to an overdue task and records it in the task history
+func escalateTask(ctx context.Context, policy models.EscalationPolicy, task models.Task, now time.Time) error {
+	escalation := models.Escalation{
+		PolicyID:     policy.ID,
+		PolicyName:   policy.Name,
+		Action:       policy.Action,
+		OverdueHours: now.Sub(*task.Deadline).Hours(),
+	}
+	updates := make(map[string]interface{})
+
+	switch policy.Action {
+	case models.EscalationRaisePriority:
+		escalation.FromPriority = task.Priority
+		escalation.ToPriority = raisedPriority(task.Priority)
+		if escalation.ToPriority != task.Priority {
+			updates["priority"] = escalation.ToPriority
+			task.Priority = escalation.ToPriority
+		}
+
+	case models.EscalationReassign:
+		// The assignee may have left the project since the policy was saved
+		member, err := repositories.GetProjectMember(ctx, policy.ProjectID, *policy.AssigneeID)
+		if err != nil {
+			return err
+		}
+		escalation.FromAssignee = task.AssigneeID
+		if member != nil && (task.AssigneeID == nil || *task.AssigneeID != *policy.AssigneeID) {
+			escalation.ToAssignee = policy.AssigneeID
+			updates["assignee_id"] = *policy.AssigneeID
+			task.AssigneeID = policy.AssigneeID
+		}
+
+	case models.EscalationNotifyLead:
+		leads, err := repositories.GetProjectAdminIDs(ctx, policy.ProjectID)
+		if err != nil {
+			return err
+		}
+		escalation.Notified = leads
+	}
+
+	applied, err := repositories.ApplyTaskEscalation(ctx, &models.TaskEscalation{
+		PolicyID: policy.ID,
+		TaskID:   task.ID,
+		Deadline: *task.Deadline,
+	}, updates)
+	if err != nil || !applied {
+		return err
+	}
+
+	// Policies that had nothing to change leave no trace in the task history
+	if len(updates) == 0 && len(escalation.Notified) == 0 {
+		return nil
+	}
+
+	escalation.Task = taskEventData(ctx, task, uuid.Nil)
+	publishTaskEvent(ctx, models.EventTaskEscalated, task, uuid.Nil, escalation)
+	if len(escalation.Notified) > 0 {
+		notifyLeads(ctx, escalation, task)
+	}
+	return nil
+}
+
+// notifyLeads emails the project admins of a notify_lead escalation about the
+// overdue task
+func notifyLeads(ctx context.Context, escalation models.Escalation, task models.Task) {
+	leads, err := repositories.GetUsersByIDs(ctx, escalation.Notified)
+	if err != nil {
+		log.Printf("❌ Failed to load project admins to notify about task %s: %v", task.ID, err)
+		return
+	}
+
+	for _, lead := range leads {
+		err := sendMailAsync(MailMessage{
+			To:      lead.Email,
+			Subject: "Overdue task: " + task.Title,
+			Body: fmt.Sprintf("Hi %s,\n\n"+
+				"The task \"%s\" (%s priority) is %.0f hours past its deadline of %s.\n"+
+				"You are notified as a project admin by the escalation policy \"%s\".\n",
+				lead.Username, task.Title, task.Priority, escalation.OverdueHours,
+				task.Deadline.UTC().Format(time.RFC1123), escalation.PolicyName),
+		})
+		if err != nil {
+			log.Printf("❌ Failed to notify project admins about task %s: %v", task.ID, err)
+			return
+		}
+	}
+}
+
+// raisedPriority returns the next higher priority
+func raisedPriority(priority models.Priority) models.Priority {
+	switch priority {
+	case models.PriorityLow:
+		return models.PriorityMedium
+	default:
+		return models.PriorityHigh
+	}
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/google/uuid"
+)
+
+// EventHandler is called for every event published by the services layer, with
+// the context of the request that published it
+type EventHandler func(ctx context.Context, event models.Event)
+
+var (
+	eventHandlersMu sync.RWMutex
+	eventHandlers   []EventHandler
+)
+
+// SubscribeEvents registers a handler that receives all published events
+func SubscribeEvents(handler EventHandler) {
+	eventHandlersMu.Lock()
+	defer eventHandlersMu.Unlock()
+	eventHandlers = append(eventHandlers, handler)
+}
+
+// PublishEvent stores an event, which notifies the realtime stream on every
+// backend instance, and hands it to every subscribed handler
+func PublishEvent(ctx context.Context, event models.Event) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	payload, err := json.Marshal(event.Data)
+	if err != nil {
+		log.Printf("❌ Failed to encode %s event: %v", event.Type, err)
+		return
+	}
+	event.Payload = string(payload)
+
+	if err := repositories.CreateEvent(ctx, &event); err != nil {
+		log.Printf("❌ Failed to store %s event: %v", event.Type, err)
+	}
+
+	eventHandlersMu.RLock()
+	handlers := make([]EventHandler, len(eventHandlers))
+	copy(handlers, eventHandlers)
+	eventHandlersMu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(ctx, event)
+	}
+}
+
+// publishTaskEvent publishes an event about a task
+func publishTaskEvent(ctx context.Context, eventType models.EventType, task models.Task, actorID uuid.UUID, data interface{}) {
+	taskID := task.ID
+	event := models.Event{
+		Type:           eventType,
+		OrganizationID: task.OrganizationID,
+		ProjectID:      task.ProjectID,
+		TaskID:         &taskID,
+		Summary:        summarizeTaskEvent(ctx, eventType, task, actorID, data),
+		Data:           data,
+	}
+	if actorID != uuid.Nil {
+		event.ActorID = &actorID
+	}
+
+	PublishEvent(ctx, event)
+}
+
+// eventActor returns the user who caused an event, or nil for automatic changes
+func eventActor(ctx context.Context, actorID uuid.UUID) *models.ActivityActor {
+	if actorID == uuid.Nil {
+		return nil
+	}
+	return &models.ActivityActor{ID: actorID, Username: usernameOf(ctx, actorID, "")}
+}
+
+// taskEventData returns a task as event data, without the accounts it is loaded with
+func taskEventData(ctx context.Context, task models.Task, actorID uuid.UUID) *models.TaskEventData {
+	return models.NewTaskEventData(task, eventActor(ctx, actorID))
+}
+
+// commentEventData returns a comment as event data, without its author's account
+func commentEventData(ctx context.Context, comment models.Comment, actorID uuid.UUID) *models.CommentEventData {
+	return models.NewCommentEventData(comment, eventActor(ctx, actorID))
+}
@@ -0,0 +1,642 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/google/uuid"
+)
+
+// externalImportMaxSize is the largest export file accepted by the importers
+const externalImportMaxSize = 50 << 20 // 50 MB
+
+// Status names used by Trello lists and Jira workflows, lowercased
+var (
+	externalDoneStatuses       = []string{"done", "closed", "resolved", "complete", "completed", "finished", "shipped", "released"}
+	externalInProgressStatuses = []string{"in progress", "doing", "in review", "review", "code review", "wip", "in development", "testing", "qa"}
+	externalPendingStatuses    = []string{"to do", "todo", "backlog", "open", "new", "selected for development", "ideas", "reopened"}
+)
+
+// Date formats found in Jira exports
+var jiraDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"02/Jan/06 3:04 PM",
+	"2/Jan/06 3:04 PM",
+	"02/Jan/06",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RFC3339,
+}
+
+// ExternalImportOptions controls how an external export is imported
+type ExternalImportOptions struct {
+	UserID    uuid.UUID
+	ProjectID uuid.UUID
+	Source    models.ImportSource
+	DryRun    bool
+}
+
+// externalItem is a Trello card or Jira issue in a tool independent form
+type externalItem struct {
+	SourceID    string
+	Title       string
+	Description string
+	Status      string
+	Priority    string
+	Deadline    *time.Time
+	Labels      []string
+	Assignee    externalMember
+	Archived    bool
+	Comments    []externalComment
+}
+
+// externalComment is a comment on a Trello card or Jira issue
+type externalComment struct {
+	Author    externalMember
+	Text      string
+	CreatedAt time.Time
+}
+
+// externalMember identifies a user of the source tool
+type externalMember struct {
+	Name  string
+	Email string
+}
+
+// ImportExternalTasks imports a Trello board JSON export or a Jira CSV/XML export
+// into a project. Cards or issues become tasks, lists or statuses become task
+// statuses, comments are kept and members are matched to users by email.
+func ImportExternalTasks(ctx context.Context, r io.Reader, opts ExternalImportOptions) (models.ExternalImportReport, error) {
+	report := models.ExternalImportReport{Source: opts.Source, DryRun: opts.DryRun}
+
+	member, err := repositories.GetProjectMember(ctx, opts.ProjectID, opts.UserID)
+	if err != nil {
+		return report, err
+	}
+	if member == nil {
+		return report, ErrForbidden
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, externalImportMaxSize+1))
+	if err != nil {
+		return report, err
+	}
+	if len(data) > externalImportMaxSize {
+		return report, invalidInput(errors.New("the export file is too large"))
+	}
+
+	var items []externalItem
+	switch opts.Source {
+	case models.ImportSourceTrello:
+		items, err = parseTrelloExport(data)
+	case models.ImportSourceJira:
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '<' {
+			items, err = parseJiraXML(data)
+		} else {
+			items, err = parseJiraCSV(data)
+		}
+	default:
+		return report, invalidInput(fmt.Errorf("unknown import source %q", opts.Source))
+	}
+	if err != nil {
+		return report, invalidInput(err)
+	}
+
+	importer := &externalImporter{
+		opts:     opts,
+		users:    make(map[string]*models.User),
+		unmapped: make(map[string]*models.ImportUnmapped),
+	}
+
+	var tasks []models.Task
+	var comments []models.Comment
+	for _, item := range items {
+		task, taskComments, skipReason := importer.convert(ctx, item)
+		if skipReason != "" {
+			report.Skipped = append(report.Skipped, models.ImportSkipped{
+				SourceID: item.SourceID,
+				Title:    item.Title,
+				Reason:   skipReason,
+			})
+			continue
+		}
+
+		tasks = append(tasks, task)
+		comments = append(comments, taskComments...)
+		report.Created = append(report.Created, models.ImportedTask{
+			SourceID: item.SourceID,
+			TaskID:   task.ID,
+			Title:    task.Title,
+			Status:   task.Status,
+			Comments: len(taskComments),
+		})
+	}
+	report.Unmapped = importer.unmappedList()
+
+	if opts.DryRun || len(tasks) == 0 {
+		return report, nil
+	}
+
+	if err := repositories.CreateTasksWithComments(ctx, tasks, comments); err != nil {
+		return report, err
+	}
+
+	for _, task := range tasks {
+		publishTaskEvent(ctx, models.EventTaskCreated, task, opts.UserID, taskEventData(ctx, task, opts.UserID))
+	}
+	return report, nil
+}
+
+// externalImporter converts external items to tasks, remembering user lookups
+// and everything that could not be mapped
+type externalImporter struct {
+	opts     ExternalImportOptions
+	users    map[string]*models.User // Email -> user, nil when no user has that email
+	unmapped map[string]*models.ImportUnmapped
+}
+
+// convert builds a task and its comments from an external item, or returns why it was skipped
+func (im *externalImporter) convert(ctx context.Context, item externalItem) (models.Task, []models.Comment, string) {
+	if item.Archived {
+		return models.Task{}, nil, "archived"
+	}
+	if strings.TrimSpace(item.Title) == "" {
+		return models.Task{}, nil, "no title"
+	}
+
+	projectID := im.opts.ProjectID
+	task := models.Task{
+		ID:          uuid.New(),
+		Title:       strings.TrimSpace(item.Title),
+		Description: item.Description,
+		Status:      im.mapStatus(item.Status),
+		Priority:    im.mapPriority(item.Priority),
+		Deadline:    item.Deadline,
+		ProjectID:   &projectID,
+		CreatedBy:   im.opts.UserID,
+	}
+
+	for _, label := range item.Labels {
+		if label = strings.TrimSpace(label); label != "" {
+			task.Labels = append(task.Labels, models.TaskLabel{TaskID: task.ID, Name: label})
+		}
+	}
+
+	if item.Assignee != (externalMember{}) {
+		if user := im.mapMember(ctx, item.Assignee); user != nil {
+			task.AssigneeID = &user.ID
+			if problem, err := assigneeProblem(ctx, task); err != nil || problem != "" {
+				task.AssigneeID = nil
+				im.addUnmapped("member", item.Assignee.display(), "unassigned (not a project member)")
+			}
+		}
+	}
+
+	// Exported deadlines are often in the past, which Validate rejects for new tasks
+	check := task
+	check.Deadline = nil
+	if err := check.Validate(); err != nil {
+		return models.Task{}, nil, err.Error()
+	}
+
+	comments := make([]models.Comment, 0, len(item.Comments))
+	for _, c := range item.Comments {
+		if strings.TrimSpace(c.Text) == "" {
+			continue
+		}
+
+		comment := models.Comment{
+			ID:        uuid.New(),
+			TaskID:    task.ID,
+			UserID:    im.opts.UserID,
+			Content:   c.Text,
+			CreatedAt: c.CreatedAt,
+		}
+
+		if user := im.mapMember(ctx, c.Author); user != nil {
+			comment.UserID = user.ID
+		} else if name := c.Author.display(); name != "" {
+			// Keep the original author visible on comments posted as the importing user
+			comment.Content = fmt.Sprintf("%s (imported): %s", name, c.Text)
+		}
+
+		comments = append(comments, comment)
+	}
+
+	return task, comments, ""
+}
+
+// mapStatus maps a Trello list or Jira status name onto a task status
+func (im *externalImporter) mapStatus(value string) models.Status {
+	name := strings.ToLower(strings.TrimSpace(value))
+	switch {
+	case name == "":
+		return models.StatusPending
+	case containsString(externalDoneStatuses, name):
+		return models.StatusDone
+	case containsString(externalInProgressStatuses, name):
+		return models.StatusInProgress
+	case containsString(externalPendingStatuses, name):
+		return models.StatusPending
+	}
+
+	im.addUnmapped("status", value, string(models.StatusPending))
+	return models.StatusPending
+}
+
+// mapPriority maps a Jira priority onto a task priority
+func (im *externalImporter) mapPriority(value string) models.Priority {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "":
+		return models.PriorityMedium
+	case "highest", "high", "critical", "blocker", "urgent", "major":
+		return models.PriorityHigh
+	case "medium", "normal":
+		return models.PriorityMedium
+	case "low", "lowest", "minor", "trivial":
+		return models.PriorityLow
+	}
+
+	im.addUnmapped("priority", value, string(models.PriorityMedium))
+	return models.PriorityMedium
+}
+
+// mapMember finds the existing user with the member's email address
+func (im *externalImporter) mapMember(ctx context.Context, member externalMember) *models.User {
+	email := strings.ToLower(strings.TrimSpace(member.Email))
+	if email == "" {
+		if member.Name != "" {
+			im.addUnmapped("member", member.display(), "importing user (no email in export)")
+		}
+		return nil
+	}
+
+	user, cached := im.users[email]
+	if !cached {
+		user, _ = repositories.GetUserByEmail(ctx, email)
+		im.users[email] = user
+	}
+
+	if user == nil {
+		im.addUnmapped("member", member.display(), "importing user (no user with this email)")
+	}
+	return user
+}
+
+// addUnmapped counts a value that had no TaskWise counterpart
+func (im *externalImporter) addUnmapped(kind, value, fallback string) {
+	key := kind + "\x00" + value
+	if entry, ok := im.unmapped[key]; ok {
+		entry.Count++
+		return
+	}
+	im.unmapped[key] = &models.ImportUnmapped{Kind: kind, Value: value, Fallback: fallback, Count: 1}
+}
+
+// unmappedList returns the unmapped values sorted by kind and value
+func (im *externalImporter) unmappedList() []models.ImportUnmapped {
+	list := make([]models.ImportUnmapped, 0, len(im.unmapped))
+	for _, entry := range im.unmapped {
+		list = append(list, *entry)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Kind != list[j].Kind {
+			return list[i].Kind < list[j].Kind
+		}
+		return list[i].Value < list[j].Value
+	})
+	return list
+}
+
+// display returns the most readable identifier of a member
+func (m externalMember) display() string {
+	switch {
+	case m.Name != "" && m.Email != "":
+		return fmt.Sprintf("%s <%s>", m.Name, m.Email)
+	case m.Email != "":
+		return m.Email
+	default:
+		return m.Name
+	}
+}
+
+// trelloExport is the subset of a Trello board JSON export used by the importer
+type trelloExport struct {
+	Lists []struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Closed bool   `json:"closed"`
+	} `json:"lists"`
+	Cards []struct {
+		ID        string     `json:"id"`
+		Name      string     `json:"name"`
+		Desc      string     `json:"desc"`
+		IDList    string     `json:"idList"`
+		Due       *time.Time `json:"due"`
+		Closed    bool       `json:"closed"`
+		IDMembers []string   `json:"idMembers"`
+		Labels    []struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"labels"`
+	} `json:"cards"`
+	Members []trelloMember `json:"members"`
+	Actions []struct {
+		Type            string       `json:"type"`
+		Date            time.Time    `json:"date"`
+		IDMemberCreator string       `json:"idMemberCreator"`
+		MemberCreator   trelloMember `json:"memberCreator"`
+		Data            struct {
+			Text string `json:"text"`
+			Card struct {
+				ID string `json:"id"`
+			} `json:"card"`
+		} `json:"data"`
+	} `json:"actions"`
+}
+
+// trelloMember is a member of a Trello board. Exports only contain an email
+// address when the exporting user may see it.
+type trelloMember struct {
+	ID       string `json:"id"`
+	FullName string `json:"fullName"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// parseTrelloExport reads the cards of a Trello board JSON export
+func parseTrelloExport(data []byte) ([]externalItem, error) {
+	var export trelloExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("not a valid Trello JSON export: %v", err)
+	}
+
+	lists := make(map[string]string, len(export.Lists))
+	closedLists := make(map[string]bool)
+	for _, list := range export.Lists {
+		lists[list.ID] = list.Name
+		closedLists[list.ID] = list.Closed
+	}
+
+	members := make(map[string]externalMember, len(export.Members))
+	for _, m := range export.Members {
+		members[m.ID] = m.toExternal()
+	}
+
+	comments := make(map[string][]externalComment)
+	for _, action := range export.Actions {
+		if action.Type != "commentCard" {
+			continue
+		}
+
+		author, ok := members[action.IDMemberCreator]
+		if !ok {
+			author = action.MemberCreator.toExternal()
+		}
+		comments[action.Data.Card.ID] = append(comments[action.Data.Card.ID], externalComment{
+			Author:    author,
+			Text:      action.Data.Text,
+			CreatedAt: action.Date,
+		})
+	}
+
+	items := make([]externalItem, 0, len(export.Cards))
+	for _, card := range export.Cards {
+		item := externalItem{
+			SourceID:    card.ID,
+			Title:       card.Name,
+			Description: card.Desc,
+			Status:      lists[card.IDList],
+			Deadline:    card.Due,
+			Archived:    card.Closed || closedLists[card.IDList],
+		}
+
+		for _, label := range card.Labels {
+			name := label.Name
+			if name == "" {
+				name = label.Color // Trello labels may only have a color
+			}
+			item.Labels = append(item.Labels, name)
+		}
+
+		if len(card.IDMembers) > 0 {
+			item.Assignee = members[card.IDMembers[0]]
+		}
+
+		// Trello exports actions newest first
+		cardComments := comments[card.ID]
+		sort.Slice(cardComments, func(i, j int) bool { return cardComments[i].CreatedAt.Before(cardComments[j].CreatedAt) })
+		item.Comments = cardComments
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// toExternal converts a Trello member
+func (m trelloMember) toExternal() externalMember {
+	name := m.FullName
+	if name == "" {
+		name = m.Username
+	}
+	return externalMember{Name: name, Email: m.Email}
+}
+
+// parseJiraCSV reads the issues of a Jira CSV export. Jira repeats columns such
+// as Labels and Comment once per value.
+func parseJiraCSV(data []byte) ([]externalItem, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("not a valid Jira CSV export: missing header row")
+	}
+
+	columns := make(map[string][]int)
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(name))
+		columns[key] = append(columns[key], i)
+	}
+	if _, ok := columns["summary"]; !ok {
+		return nil, errors.New("not a valid Jira CSV export: no Summary column")
+	}
+
+	var items []externalItem
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("not a valid Jira CSV export: %v", err)
+		}
+
+		values := func(column string) []string {
+			var result []string
+			for _, i := range columns[column] {
+				if i < len(record) && strings.TrimSpace(record[i]) != "" {
+					result = append(result, strings.TrimSpace(record[i]))
+				}
+			}
+			return result
+		}
+		value := func(column string) string {
+			if v := values(column); len(v) > 0 {
+				return v[0]
+			}
+			return ""
+		}
+
+		item := externalItem{
+			SourceID:    value("issue key"),
+			Title:       value("summary"),
+			Description: value("description"),
+			Status:      value("status"),
+			Priority:    value("priority"),
+			Labels:      values("labels"),
+			Deadline:    parseJiraDate(value("due date")),
+		}
+		if item.SourceID == "" {
+			item.SourceID = value("issue id")
+		}
+
+		assignee := value("assignee")
+		item.Assignee = jiraMember(assignee, assignee)
+
+		// Comment cells look like "02/Jan/06 3:04 PM;author;text"
+		for _, cell := range values("comment") {
+			parts := strings.SplitN(cell, ";", 3)
+			if len(parts) != 3 {
+				item.Comments = append(item.Comments, externalComment{Text: cell})
+				continue
+			}
+
+			comment := externalComment{Author: jiraMember(parts[1], parts[1]), Text: parts[2]}
+			if created := parseJiraDate(parts[0]); created != nil {
+				comment.CreatedAt = *created
+			}
+			item.Comments = append(item.Comments, comment)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// jiraXMLExport is the subset of a Jira XML (RSS) export used by the importer
+type jiraXMLExport struct {
+	Items []struct {
+		Key         string           `xml:"key"`
+		Summary     string           `xml:"summary"`
+		Description string           `xml:"description"`
+		Status      string           `xml:"status"`
+		Priority    string           `xml:"priority"`
+		Due         string           `xml:"due"`
+		Resolution  string           `xml:"resolution"`
+		Assignee    jiraXMLUser      `xml:"assignee"`
+		Labels      []string         `xml:"labels>label"`
+		Comments    []jiraXMLComment `xml:"comments>comment"`
+	} `xml:"channel>item"`
+}
+
+// jiraXMLUser is a user reference in a Jira XML export
+type jiraXMLUser struct {
+	Username string `xml:"username,attr"`
+	Name     string `xml:",chardata"`
+}
+
+// jiraXMLComment is a comment in a Jira XML export
+type jiraXMLComment struct {
+	Author  string `xml:"author,attr"`
+	Created string `xml:"created,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// parseJiraXML reads the issues of a Jira XML (RSS) export
+func parseJiraXML(data []byte) ([]externalItem, error) {
+	var export jiraXMLExport
+	if err := xml.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("not a valid Jira XML export: %v", err)
+	}
+
+	items := make([]externalItem, 0, len(export.Items))
+	for _, issue := range export.Items {
+		item := externalItem{
+			SourceID:    issue.Key,
+			Title:       issue.Summary,
+			Description: strings.TrimSpace(issue.Description),
+			Status:      issue.Status,
+			Priority:    issue.Priority,
+			Labels:      issue.Labels,
+			Deadline:    parseJiraDate(issue.Due),
+			Assignee:    jiraMember(strings.TrimSpace(issue.Assignee.Name), issue.Assignee.Username),
+		}
+
+		for _, c := range issue.Comments {
+			comment := externalComment{Author: jiraMember(c.Author, c.Author), Text: strings.TrimSpace(c.Text)}
+			if created := parseJiraDate(c.Created); created != nil {
+				comment.CreatedAt = *created
+			}
+			item.Comments = append(item.Comments, comment)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// jiraMember builds a member from a Jira display name and user name. Jira only
+// exports an email address when it is used as the user name.
+func jiraMember(name, username string) externalMember {
+	if strings.EqualFold(name, "unassigned") {
+		return externalMember{}
+	}
+
+	member := externalMember{Name: name}
+	if strings.Contains(username, "@") {
+		member.Email = username
+	}
+	return member
+}
+
+// parseJiraDate parses the date formats used in Jira exports
+func parseJiraDate(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	for _, layout := range jiraDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// containsString checks if a slice contains a string
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
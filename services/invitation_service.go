@@ -0,0 +1,332 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/azka-art/taskwise-backend/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	invitationTTL  = 7 * 24 * time.Hour // How long an invitation can be accepted
+	invitationPath = "/accept-invite"   // Page of the app the invitation link opens
+)
+
+// CreateInvitation invites someone by email into the current organization, or
+// into a project of it, and emails them a link to accept. Inviting into the
+// organization needs the user.invite permission; project admins can invite into
+// their projects. Inviting an email again replaces its pending invitation. Links
+// point to APP_URL, which has to be set, like a mailer.
+func CreateInvitation(ctx context.Context, actorID uuid.UUID, actorRole models.UserRole, req models.InvitationRequest) (models.Invitation, error) {
+	organizationID, ok := repositories.OrganizationFromContext(ctx)
+	if !ok {
+		return models.Invitation{}, repositories.ErrNoOrganization
+	}
+	baseURL, err := appURL()
+	if err != nil {
+		return models.Invitation{}, err
+	}
+	if err := CheckMailer(); err != nil {
+		return models.Invitation{}, err
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if err := utils.ValidateEmail(email); err != nil {
+		return models.Invitation{}, invalidInput(err)
+	}
+
+	invitation := models.Invitation{
+		ProjectID: req.ProjectID,
+		Email:     email,
+		Role:      req.Role,
+		InvitedBy: actorID,
+		ExpiresAt: time.Now().Add(invitationTTL),
+	}
+	if invitation.Role == "" {
+		invitation.Role = string(models.RoleMember)
+	}
+
+	if req.ProjectID != nil {
+		if err := requireProjectAdmin(ctx, *req.ProjectID, actorID, actorRole); err != nil {
+			return models.Invitation{}, err
+		}
+		if role := models.ProjectRole(invitation.Role); role != models.ProjectRoleAdmin && role != models.ProjectRoleMember {
+			return models.Invitation{}, invalidInput(errors.New("role must be admin or member"))
+		}
+	} else {
+		if !actorRole.Can(models.PermUserInvite) {
+			return models.Invitation{}, ErrForbidden
+		}
+		if role := models.UserRole(invitation.Role); role != models.RoleAdmin && role != models.RoleMember {
+			return models.Invitation{}, invalidInput(errors.New("role must be admin or member"))
+		}
+	}
+
+	target, err := invitationTarget(ctx, organizationID, invitation.ProjectID)
+	if err != nil {
+		return models.Invitation{}, err
+	}
+
+	member, err := isInvitedMember(ctx, organizationID, invitation)
+	if err != nil {
+		return models.Invitation{}, err
+	}
+	if member {
+		return models.Invitation{}, invalidInput(fmt.Errorf("%s is already a member", email))
+	}
+
+	token, err := utils.GenerateSecureToken()
+	if err != nil {
+		return models.Invitation{}, err
+	}
+	invitation.TokenHash = utils.HashToken(token)
+
+	if err := repositories.CreateInvitation(ctx, &invitation); err != nil {
+		return models.Invitation{}, err
+	}
+
+	link := baseURL + invitationPath + "?token=" + url.QueryEscape(token)
+	if err := sendMailAsync(MailMessage{
+		To:      email,
+		Subject: "You're invited to " + target + " on TaskWise",
+		Body: fmt.Sprintf("Hi,\n\n"+
+			"You were invited to join %s on TaskWise. Open this link to accept:\n\n"+
+			"%s\n\n"+
+			"The link works once and expires in %d days. If you did not expect it, you can ignore this email.\n",
+			target, link, int(invitationTTL.Hours()/24)),
+	}); err != nil {
+		return models.Invitation{}, err
+	}
+	return invitation, nil
+}
+
+// GetInvitations lists the pending invitations of the current organization,
+// optionally of one project. Users without the user.invite permission only see
+// the invitations they sent.
+func GetInvitations(ctx context.Context, actorID uuid.UUID, actorRole models.UserRole, projectID *uuid.UUID) ([]models.Invitation, error) {
+	var invitedBy *uuid.UUID
+	if !actorRole.Can(models.PermUserInvite) {
+		invitedBy = &actorID
+	}
+	return repositories.GetPendingInvitations(ctx, invitedBy, projectID, time.Now())
+}
+
+// RevokeInvitation withdraws a pending invitation. It can be revoked by whoever
+// sent it, by users with the user.invite permission and, for project invitations,
+// by admins of the project.
+func RevokeInvitation(ctx context.Context, actorID uuid.UUID, actorRole models.UserRole, invitationID uuid.UUID) error {
+	invitation, err := repositories.GetInvitationByID(ctx, invitationID)
+	if err != nil {
+		return err
+	}
+	if invitation == nil || !invitation.IsPending(time.Now()) {
+		return ErrInvitationNotFound
+	}
+
+	allowed := invitation.InvitedBy == actorID || actorRole.Can(models.PermUserInvite)
+	if !allowed && invitation.ProjectID != nil {
+		if allowed, err = CanManageProject(ctx, *invitation.ProjectID, actorID, actorRole); err != nil {
+			return err
+		}
+	}
+	if !allowed {
+		return ErrInvitationNotFound
+	}
+
+	return repositories.RevokeInvitation(ctx, invitation.ID, time.Now())
+}
+
+// AcceptInvitation accepts an invitation with the token from its link. If nobody
+// has an account with the invited email, one is registered with the username and
+// password of the request; otherwise the existing account is added. Either way
+// the user joins the organization, and the project of a project invitation.
+func AcceptInvitation(ctx context.Context, req models.AcceptInvitationRequest) (models.InvitationAcceptance, error) {
+	// The invitation is accepted without signing in; it names the organization
+	ctx = repositories.AllOrganizations(ctx)
+
+	invitation, err := repositories.GetInvitationByHash(ctx, utils.HashToken(req.Token))
+	if err != nil {
+		return models.InvitationAcceptance{}, err
+	}
+	now := time.Now()
+	if invitation == nil || !invitation.IsPending(now) {
+		return models.InvitationAcceptance{}, ErrInvalidInvitation
+	}
+
+	orgCtx := repositories.WithOrganization(ctx, invitation.OrganizationID)
+	if invitation.ProjectID != nil {
+		project, err := repositories.GetProjectByID(orgCtx, *invitation.ProjectID)
+		if err != nil {
+			return models.InvitationAcceptance{}, err
+		}
+		if project == nil {
+			return models.InvitationAcceptance{}, ErrInvalidInvitation
+		}
+	}
+
+	// The account, the acceptance and the memberships are stored together, so a
+	// failure leaves neither an account nor a used invitation behind
+	var user *models.User
+	created := false
+	err = repositories.WithTransaction(ctx, func(ctx context.Context) error {
+		user, err = accountRepository(ctx).FindByEmail(invitation.Email)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			if req.Username == "" || req.Password == "" {
+				return invalidInput(errors.New("username and password are required to create an account"))
+			}
+			registered, err := registerUser(ctx, models.RegisterRequest{
+				Username: req.Username,
+				Email:    invitation.Email,
+				Password: req.Password,
+			}, invitation)
+			if err != nil {
+				return err
+			}
+			user, created = &registered, true
+		} else if !user.IsEmailVerified() {
+			if err := reclaimUnverifiedAccount(ctx, user, req.Password, now); err != nil {
+				return err
+			}
+		}
+
+		// A concurrent request may have used the invitation in the meantime
+		marked, err := repositories.MarkInvitationAccepted(ctx, invitation.ID, user.ID, now)
+		if err != nil {
+			return err
+		}
+		if !marked {
+			return ErrInvalidInvitation
+		}
+		return joinInvitation(repositories.WithOrganization(ctx, invitation.OrganizationID), invitation, user.ID)
+	})
+	if err != nil {
+		return models.InvitationAcceptance{}, err
+	}
+	log.Printf("✉️ User %s accepted invitation %s", user.ID, invitation.ID)
+
+	user.Password = ""
+	return models.InvitationAcceptance{
+		User:           *user,
+		OrganizationID: invitation.OrganizationID,
+		ProjectID:      invitation.ProjectID,
+		Created:        created,
+	}, nil
+}
+
+// reclaimUnverifiedAccount hands an account whose email was never verified to
+// the invited owner of the email. Whoever registered it may not own the address
+// but knows the password, so the account is only handed over like in a password
+// reset: the new password replaces the old one, and every session, API key and
+// 2FA setting of the previous holder is removed before the email counts as verified.
+func reclaimUnverifiedAccount(ctx context.Context, user *models.User, password string, now time.Time) error {
+	if password == "" {
+		return invalidInput(errors.New("an account with this email exists but was never verified; choose a new password to take it over"))
+	}
+	if err := utils.ValidatePassword(password); err != nil {
+		return invalidInput(err)
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	accounts := accountRepository(ctx)
+	if err := accounts.SetPassword(user.ID, hashedPassword); err != nil {
+		return err
+	}
+	if err := accounts.DeleteMFA(user.ID); err != nil {
+		return err
+	}
+	if err := LogoutAll(ctx, user.ID); err != nil {
+		return err
+	}
+
+	// The invitation was emailed to the address, which confirms it
+	if err := accounts.MarkEmailVerified(user.ID, now); err != nil {
+		return err
+	}
+	user.Password = hashedPassword
+	user.EmailVerifiedAt = &now
+	log.Printf("🔑 Unverified user %s was taken over by accepting an invitation", user.ID)
+	return nil
+}
+
+// joinInvitation adds a user to the organization and project of an invitation.
+// Roles of existing memberships are kept.
+func joinInvitation(ctx context.Context, invitation *models.Invitation, userID uuid.UUID) error {
+	role := models.RoleMember
+	if invitation.ProjectID == nil {
+		role = models.UserRole(invitation.Role)
+	}
+	if err := repositories.AddOrganizationMember(ctx, &models.OrganizationMember{
+		OrganizationID: invitation.OrganizationID,
+		UserID:         userID,
+		Role:           role,
+	}); err != nil {
+		return err
+	}
+
+	if invitation.ProjectID == nil {
+		return nil
+	}
+	existing, err := repositories.GetProjectMember(ctx, *invitation.ProjectID, userID)
+	if err != nil || existing != nil {
+		return err
+	}
+	return repositories.AddProjectMember(ctx, &models.ProjectMember{
+		ProjectID: *invitation.ProjectID,
+		UserID:    userID,
+		Role:      models.ProjectRole(invitation.Role),
+	})
+}
+
+// invitationTarget names the organization or project an invitation is into, for
+// the invitation email
+func invitationTarget(ctx context.Context, organizationID uuid.UUID, projectID *uuid.UUID) (string, error) {
+	if projectID != nil {
+		project, err := repositories.GetProjectByID(ctx, *projectID)
+		if err != nil {
+			return "", err
+		}
+		if project == nil {
+			return "", ErrProjectNotFound
+		}
+		return "the project " + project.Name, nil
+	}
+
+	organization, err := repositories.GetOrganizationByID(ctx, organizationID)
+	if err != nil {
+		return "", err
+	}
+	if organization == nil {
+		return "", ErrOrganizationNotFound
+	}
+	return organization.Name, nil
+}
+
+// isInvitedMember checks if the invited email already belongs to a member of what
+// the invitation is into
+func isInvitedMember(ctx context.Context, organizationID uuid.UUID, invitation models.Invitation) (bool, error) {
+	user, err := repositories.GetUserByEmail(repositories.AllOrganizations(ctx), invitation.Email)
+	if err != nil || user == nil {
+		return false, err
+	}
+
+	if invitation.ProjectID != nil {
+		member, err := repositories.GetProjectMember(ctx, *invitation.ProjectID, user.ID)
+		return member != nil, err
+	}
+	member, err := repositories.GetOrganizationMember(ctx, organizationID, user.ID)
+	return member != nil, err
+}
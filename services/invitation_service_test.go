@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/utils"
+)
+
+func TestReclaimUnverifiedAccountNeedsNewPassword(t *testing.T) {
+	accounts := useFakeAccounts(t)
+	squatter := accounts.addUser("invitee@example.com", false)
+	ctx := context.Background()
+
+	user, _ := accounts.FindByEmail("invitee@example.com")
+	err := reclaimUnverifiedAccount(ctx, user, "", time.Now())
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("err = %v, want ErrInvalidInput", err)
+	}
+	user, _ = accounts.FindByID(squatter.ID)
+	if user.EmailVerifiedAt != nil || user.Password != squatter.Password {
+		t.Error("account was verified or changed without a new password")
+	}
+	if _, ok := accounts.revoked[squatter.ID]; ok {
+		t.Error("sessions were revoked without a new password")
+	}
+
+	if err := reclaimUnverifiedAccount(ctx, user, "Invited1!", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	user, _ = accounts.FindByID(squatter.ID)
+	if user.EmailVerifiedAt == nil {
+		t.Error("account is still unverified")
+	}
+	if !utils.CheckPasswordHash("Invited1!", user.Password) {
+		t.Error("password of the previous holder still works instead of the new one")
+	}
+	if _, ok := accounts.revoked[squatter.ID]; !ok {
+		t.Error("sessions and API keys of the previous holder were not revoked")
+	}
+	if !accounts.mfaDeleted[squatter.ID] {
+		t.Error("2FA of the previous holder was not removed")
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MailMessage is a plain text email
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails. Replace the default with SetMailer, e.g. to use an email API.
+type Mailer interface {
+	Send(message MailMessage) error
+}
+
+var (
+	mailerMu     sync.RWMutex
+	customMailer Mailer
+)
+
+// SetMailer replaces the mailer used for all emails; nil restores the default
+func SetMailer(mailer Mailer) {
+	mailerMu.Lock()
+	defer mailerMu.Unlock()
+	customMailer = mailer
+}
+
+// CheckMailer reports whether emails can be sent. It returns ErrMailNotConfigured
+// unless a mailer was set with SetMailer, SMTP_HOST is set, or MAIL_DRIVER=log.
+func CheckMailer() error {
+	_, err := currentMailer()
+	return err
+}
+
+// currentMailer returns the mailer set with SetMailer, or the one chosen by
+// MAIL_DRIVER: SMTP by default, or "log" to only log the emails in development.
+// Logged emails contain working links, so they are never used without asking.
+func currentMailer() (Mailer, error) {
+	mailerMu.RLock()
+	defer mailerMu.RUnlock()
+	if customMailer != nil {
+		return customMailer, nil
+	}
+
+	switch os.Getenv("MAIL_DRIVER") {
+	case "log":
+		return LogMailer{}, nil
+	case "", "smtp":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return nil, ErrMailNotConfigured
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return SMTPMailer{
+			Addr:     net.JoinHostPort(host, port),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		}, nil
+	default:
+		return nil, ErrMailNotConfigured
+	}
+}
+
+// sendMailAsync sends an email in the background, so that requests neither wait
+// for the mail server nor reveal through their timing whether an email was sent.
+// It returns ErrMailNotConfigured right away when no mailer is configured.
+func sendMailAsync(message MailMessage) error {
+	mailer, err := currentMailer()
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := mailer.Send(message); err != nil {
+			log.Printf("❌ Failed to send email %q: %v", message.Subject, err)
+		}
+	}()
+	return nil
+}
+
+// SMTPMailer sends emails through an SMTP server, using STARTTLS when offered
+type SMTPMailer struct {
+	Addr     string // host:port
+	Username string // No authentication when empty
+	Password string
+	From     string
+}
+
+// Send delivers the message to the SMTP server
+func (m SMTPMailer) Send(message MailMessage) error {
+	from := m.From
+	if from == "" {
+		from = "TaskWise <no-reply@taskwise.local>"
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, err := net.SplitHostPort(m.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+
+	return smtp.SendMail(m.Addr, auth, envelopeAddress(from), []string{message.To}, formatMail(from, message))
+}
+
+// LogMailer writes emails to the log instead of sending them, for development
+// with MAIL_DRIVER=log
+type LogMailer struct{}
+
+// Send logs the message
+func (LogMailer) Send(message MailMessage) error {
+	log.Printf("✉️ Email to %s: %s\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
+
+// formatMail builds the headers and body of a plain text email
+func formatMail(from string, message MailMessage) []byte {
+	var mail strings.Builder
+	fmt.Fprintf(&mail, "From: %s\r\n", from)
+	fmt.Fprintf(&mail, "To: %s\r\n", message.To)
+	fmt.Fprintf(&mail, "Subject: %s\r\n", message.Subject)
+	fmt.Fprintf(&mail, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	mail.WriteString("MIME-Version: 1.0\r\n")
+	mail.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	mail.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	return []byte(mail.String())
+}
+
+// envelopeAddress returns the bare address of "Name <address>"
+func envelopeAddress(from string) string {
+	if start := strings.LastIndex(from, "<"); start >= 0 && strings.HasSuffix(from, ">") {
+		return from[start+1 : len(from)-1]
+	}
+	return from
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCurrentMailerNeedsConfiguration(t *testing.T) {
+	tests := []struct {
+		driver, host string
+		want         Mailer
+		wantErr      error
+	}{
+		{"", "", nil, ErrMailNotConfigured},
+		{"smtp", "", nil, ErrMailNotConfigured},
+		{"sendmail", "smtp.example.com", nil, ErrMailNotConfigured},
+		{"", "smtp.example.com", SMTPMailer{Addr: "smtp.example.com:587"}, nil},
+		{"log", "", LogMailer{}, nil},
+	}
+	for _, name := range []string{"SMTP_PORT", "SMTP_USERNAME", "SMTP_PASSWORD", "MAIL_FROM"} {
+		t.Setenv(name, "")
+	}
+	for _, tt := range tests {
+		t.Setenv("MAIL_DRIVER", tt.driver)
+		t.Setenv("SMTP_HOST", tt.host)
+
+		mailer, err := currentMailer()
+		if !errors.Is(err, tt.wantErr) || mailer != tt.want {
+			t.Errorf("MAIL_DRIVER=%q SMTP_HOST=%q: got %#v, %v; want %#v, %v", tt.driver, tt.host, mailer, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,471 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/azka-art/taskwise-backend/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	mfaIssuer          = "TaskWise"       // Name authenticator apps show for the account
+	mfaTokenTTL        = 5 * time.Minute  // How long the second login step can take
+	mfaTokenType       = "mfa_pending"    // typ claim of the token between the two login steps
+	mfaMaxAttempts     = 5                // Wrong codes in a row before the codes of a user are locked
+	mfaLockout         = 15 * time.Minute // First lockout, doubled on every further one
+	mfaMaxLockout      = 24 * time.Hour   // Longest lockout
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10 // Characters, shown in two groups of five
+)
+
+// GetMFAStatus tells whether a user has two-factor authentication
+func GetMFAStatus(ctx context.Context, userID uuid.UUID) (models.MFAStatus, error) {
+	user, err := repositories.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.MFAStatus{}, err
+	}
+	if user == nil {
+		return models.MFAStatus{}, errors.New("user not found")
+	}
+
+	mfa, err := repositories.GetUserMFA(ctx, userID)
+	if err != nil {
+		return models.MFAStatus{}, err
+	}
+
+	status := models.MFAStatus{}
+	if organizationID, ok := repositories.OrganizationFromContext(ctx); ok {
+		member, err := repositories.GetOrganizationMember(ctx, organizationID, userID)
+		if err != nil {
+			return models.MFAStatus{}, err
+		}
+		status.Required = member != nil && member.MFARequired
+	}
+	if mfa.IsEnabled() {
+		status.Enabled = true
+		status.EnabledAt = mfa.EnabledAt
+		if status.RecoveryCodesRemaining, err = repositories.CountUnusedRecoveryCodes(ctx, userID); err != nil {
+			return models.MFAStatus{}, err
+		}
+	}
+	return status, nil
+}
+
+// EnrollMFA creates a new TOTP secret for the user. It only protects logins once
+// a code from the authenticator app was confirmed with ConfirmMFA.
+func EnrollMFA(ctx context.Context, userID uuid.UUID) (models.MFAEnrollment, error) {
+	user, err := repositories.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.MFAEnrollment{}, err
+	}
+	if user == nil {
+		return models.MFAEnrollment{}, errors.New("user not found")
+	}
+
+	mfa, err := repositories.GetUserMFA(ctx, userID)
+	if err != nil {
+		return models.MFAEnrollment{}, err
+	}
+	if mfa.IsEnabled() {
+		return models.MFAEnrollment{}, invalidInput(errors.New("two-factor authentication is already enabled"))
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return models.MFAEnrollment{}, err
+	}
+	// A new enrollment replaces one that was never confirmed
+	enrollment := &models.UserMFA{UserID: userID, Secret: secret}
+	if mfa == nil {
+		err = repositories.CreateUserMFA(ctx, enrollment)
+	} else {
+		err = repositories.UpdateUserMFA(ctx, enrollment)
+	}
+	if err != nil {
+		return models.MFAEnrollment{}, err
+	}
+
+	return models.MFAEnrollment{
+		Secret:     secret,
+		OTPAuthURL: utils.TOTPURI(mfaIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmMFA enables two-factor authentication with a first code from the
+// authenticator app and returns the recovery codes, which are only shown once
+func ConfirmMFA(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	mfa, err := repositories.GetUserMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil {
+		return nil, invalidInput(errors.New("two-factor authentication has not been enrolled"))
+	}
+	if mfa.IsEnabled() {
+		return nil, invalidInput(errors.New("two-factor authentication is already enabled"))
+	}
+
+	now := time.Now()
+	step, ok := utils.ValidateTOTP(mfa.Secret, code, now)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, records, err := generateRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, err := repositories.EnableUserMFA(ctx, userID, now, step, records)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, invalidInput(errors.New("two-factor authentication is already enabled"))
+	}
+	return codes, nil
+}
+
+// DisableMFA turns two-factor authentication off after checking the password and
+// a code. Single sign-on accounts, which have no password of their own, confirm
+// with a current TOTP code instead. Users an admin of any of their organizations
+// requires to use it can't turn it off.
+func DisableMFA(ctx context.Context, userID uuid.UUID, req models.DisableMFARequest) error {
+	user, err := repositories.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+	required, err := repositories.IsMFARequiredInAnyOrganization(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrForbidden
+	}
+	if err := checkDisableMFAConfirmation(ctx, user, req); err != nil {
+		return err
+	}
+
+	mfa, err := repositories.GetUserMFA(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !mfa.IsEnabled() {
+		return invalidInput(errors.New("two-factor authentication is not enabled"))
+	}
+
+	if err := verifyMFACode(ctx, mfa, req.Code, req.RecoveryCode); err != nil {
+		return err
+	}
+	return repositories.DeleteUserMFA(ctx, userID)
+}
+
+// checkDisableMFAConfirmation checks the password of a request to disable 2FA.
+// Without a password, single sign-on accounts may confirm with a TOTP code, but
+// not with a recovery code alone.
+func checkDisableMFAConfirmation(ctx context.Context, user *models.User, req models.DisableMFARequest) error {
+	if req.Password != "" {
+		if !utils.CheckPasswordHash(req.Password, user.Password) {
+			return invalidInput(errors.New("incorrect password"))
+		}
+		return nil
+	}
+
+	sso, err := repositories.HasUserIdentities(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if !sso {
+		return invalidInput(errors.New("password is required"))
+	}
+	if req.Code == "" {
+		return invalidInput(errors.New("a code from the authenticator app is required"))
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user after checking a code
+func RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	mfa, err := repositories.GetUserMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !mfa.IsEnabled() {
+		return nil, invalidInput(errors.New("two-factor authentication is not enabled"))
+	}
+
+	if err := verifyMFACode(ctx, mfa, code, ""); err != nil {
+		return nil, err
+	}
+
+	codes, records, err := generateRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := repositories.ReplaceRecoveryCodes(ctx, userID, records); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// SetMFARequired lets an admin require a member of the current organization to
+// use two-factor authentication in it. Users without it can only set it up while
+// they are signed in to that organization, until they have done so.
+func SetMFARequired(ctx context.Context, userID uuid.UUID, role models.UserRole, required bool) error {
+	if !role.Can(models.PermUserManage) {
+		return ErrForbidden
+	}
+	organizationID, ok := repositories.OrganizationFromContext(ctx)
+	if !ok {
+		return repositories.ErrNoOrganization
+	}
+
+	err := repositories.SetMemberMFARequired(ctx, organizationID, userID, required)
+	if errors.Is(err, repositories.ErrNotUpdated) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// NeedsMFASetup checks if an organization requires a user to use two-factor
+// authentication but the user has not set it up yet
+func NeedsMFASetup(ctx context.Context, organizationID, userID uuid.UUID) (bool, error) {
+	member, err := repositories.GetOrganizationMember(ctx, organizationID, userID)
+	if err != nil || member == nil || !member.MFARequired {
+		return false, err
+	}
+
+	mfa, err := repositories.GetUserMFA(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return !mfa.IsEnabled(), nil
+}
+
+// CompleteMFALogin exchanges the mfa token returned by LoginUser and a TOTP or
+// recovery code for access and refresh tokens
+func CompleteMFALogin(ctx context.Context, req models.MFALoginRequest) (models.AuthTokens, models.User, error) {
+	// The organization is only known once the user signed in
+	ctx = repositories.AllOrganizations(ctx)
+
+	userID, tokenID, issuedAt, expiresAt, err := parseMFAToken(req.MFAToken)
+	if err != nil {
+		return models.AuthTokens{}, models.User{}, ErrInvalidMFAToken
+	}
+
+	// Tokens are revoked once used, after too many wrong codes and on logging out everywhere
+	revoked, err := repositories.IsAccessTokenRevoked(ctx, tokenID, userID, issuedAt)
+	if err != nil {
+		return models.AuthTokens{}, models.User{}, err
+	}
+	if revoked {
+		return models.AuthTokens{}, models.User{}, ErrInvalidMFAToken
+	}
+
+	user, err := repositories.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.AuthTokens{}, models.User{}, err
+	}
+	mfa, err := repositories.GetUserMFA(ctx, userID)
+	if err != nil {
+		return models.AuthTokens{}, models.User{}, err
+	}
+	if user == nil || !mfa.IsEnabled() {
+		return models.AuthTokens{}, models.User{}, ErrInvalidMFAToken
+	}
+
+	if err := verifyMFACode(ctx, mfa, req.Code, req.RecoveryCode); err != nil {
+		return models.AuthTokens{}, models.User{}, err
+	}
+
+	if err := revokeMFAToken(ctx, userID, tokenID, expiresAt); err != nil {
+		return models.AuthTokens{}, models.User{}, err
+	}
+	if err := checkLoginAllowed(user); err != nil {
+		return models.AuthTokens{}, models.User{}, err
+	}
+
+	tokens, err := issueTokens(ctx, user, uuid.New(), uuid.Nil)
+	if err != nil {
+		return models.AuthTokens{}, models.User{}, err
+	}
+
+	user.Password = ""
+	return tokens, *user, nil
+}
+
+// startMFALogin returns the token that lets a user who entered the right password
+// finish logging in with a code
+func startMFALogin(ctx context.Context, user *models.User) (models.LoginResult, error) {
+	now := time.Now()
+	expiresAt := now.Add(mfaTokenTTL)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"typ":     mfaTokenType,
+		"jti":     uuid.NewString(),
+		"user_id": user.ID.String(),
+		"exp":     expiresAt.Unix(),
+		"iat":     now.Unix(),
+	})
+
+	signed, err := token.SignedString([]byte(jwtSecret()))
+	if err != nil {
+		return models.LoginResult{}, err
+	}
+	return models.LoginResult{MFARequired: true, MFAToken: signed, MFAExpiresAt: expiresAt}, nil
+}
+
+// parseMFAToken verifies a token created by startMFALogin
+func parseMFAToken(tokenString string) (userID, tokenID uuid.UUID, issuedAt, expiresAt time.Time, err error) {
+	claims, err := ValidateToken(tokenString)
+	if err != nil {
+		return
+	}
+
+	invalid := errors.New("invalid mfa token")
+	if typ, _ := claims["typ"].(string); typ != mfaTokenType {
+		err = invalid
+		return
+	}
+
+	userIDClaim, _ := claims["user_id"].(string)
+	tokenIDClaim, _ := claims["jti"].(string)
+	if userID, err = uuid.Parse(userIDClaim); err != nil {
+		return
+	}
+	if tokenID, err = uuid.Parse(tokenIDClaim); err != nil {
+		return
+	}
+
+	iat, iatErr := claims.GetIssuedAt()
+	exp, expErr := claims.GetExpirationTime()
+	if iatErr != nil || expErr != nil || iat == nil || exp == nil {
+		err = invalid
+		return
+	}
+	return userID, tokenID, iat.Time, exp.Time, nil
+}
+
+// verifyMFACode checks a code of a user with checkMFACode. Wrong codes are counted
+// per user in the database, so they add up across mfa tokens and instances; after
+// mfaMaxAttempts in a row no codes are checked for a while.
+func verifyMFACode(ctx context.Context, mfa *models.UserMFA, code, recoveryCode string) error {
+	if mfa.LockedUntil != nil && time.Now().Before(*mfa.LockedUntil) {
+		return ErrMFALocked
+	}
+
+	err := checkMFACode(ctx, mfa, code, recoveryCode)
+	if errors.Is(err, ErrInvalidMFACode) {
+		if lockErr := recordMFAFailure(ctx, mfa.UserID); lockErr != nil {
+			return lockErr
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if mfa.FailedAttempts > 0 {
+		return repositories.ResetMFAFailures(ctx, mfa.UserID)
+	}
+	return nil
+}
+
+// checkMFACode checks a TOTP code, or a recovery code when no TOTP code is given.
+// Both only work once.
+func checkMFACode(ctx context.Context, mfa *models.UserMFA, code, recoveryCode string) error {
+	switch {
+	case code != "":
+		step, ok := utils.ValidateTOTP(mfa.Secret, code, time.Now())
+		if !ok {
+			return ErrInvalidMFACode
+		}
+		used, err := repositories.UseTOTPStep(ctx, mfa.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidMFACode // Already used, possibly by someone who saw it
+		}
+		return nil
+
+	case recoveryCode != "":
+		used, err := repositories.UseRecoveryCode(ctx, mfa.UserID, utils.HashToken(normalizeRecoveryCode(recoveryCode)), time.Now())
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidMFACode
+		}
+		return nil
+
+	default:
+		return invalidInput(errors.New("code or recovery_code is required"))
+	}
+}
+
+// recordMFAFailure counts a wrong code of a user. Every mfaMaxAttempts wrong codes
+// in a row lock the codes of the user, for twice as long as the lockout before.
+func recordMFAFailure(ctx context.Context, userID uuid.UUID) error {
+	failures, err := repositories.RecordMFAFailure(ctx, userID)
+	if err != nil || failures%mfaMaxAttempts != 0 {
+		return err
+	}
+
+	lockout := mfaMaxLockout
+	if doublings := failures/mfaMaxAttempts - 1; doublings < 8 {
+		lockout = min(mfaLockout<<doublings, mfaMaxLockout)
+	}
+	log.Printf("🔒 Two-factor codes of user %s locked for %s after %d wrong codes", userID, lockout, failures)
+
+	if err := repositories.LockUserMFA(ctx, userID, time.Now().Add(lockout)); err != nil {
+		return err
+	}
+	return ErrMFALocked
+}
+
+// revokeMFAToken makes an mfa token unusable
+func revokeMFAToken(ctx context.Context, userID, tokenID uuid.UUID, expiresAt time.Time) error {
+	return repositories.RevokeAccessToken(ctx, &models.RevokedToken{
+		TokenID:   tokenID,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// generateRecoveryCodes creates new recovery codes, returning them for the user
+// and hashed for storing
+func generateRecoveryCodes(userID uuid.UUID) ([]string, []models.MFARecoveryCode, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.MFARecoveryCode, recoveryCodeCount)
+
+	for i := range codes {
+		b := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(encoding.EncodeToString(b))[:recoveryCodeLength]
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		records[i] = models.MFARecoveryCode{UserID: userID, CodeHash: utils.HashToken(code)}
+	}
+	return codes, records, nil
+}
+
+// normalizeRecoveryCode ignores case, dashes and spaces in recovery codes
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
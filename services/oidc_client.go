@@ -0,0 +1,323 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	oidcHTTPTimeout      = 10 * time.Second // Timeout of requests to providers
+	oidcDiscoveryTTL     = time.Hour        // How long provider metadata is cached
+	oidcJWKSRefreshDelay = time.Minute      // Minimum time between fetching the keys of a provider again
+	oidcClockSkew        = time.Minute      // Clock difference allowed when checking ID tokens
+	oidcResponseLimit    = 1 << 20          // Bytes read from provider responses
+)
+
+// oidcHTTPClient talks to OpenID Connect providers
+var oidcHTTPClient = &http.Client{Timeout: oidcHTTPTimeout}
+
+// oidcSigningMethods are the ID token signatures that are accepted. HMAC is left
+// out on purpose, the client secret must not be usable to sign tokens.
+var oidcSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// oidcDiscovery is the provider metadata published at /.well-known/openid-configuration
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcTokenResponse is the answer of a provider's token endpoint
+type oidcTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// oidcKeySet caches the signing keys of a provider by key ID
+type oidcKeySet struct {
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+var oidcCache = struct {
+	mu         sync.Mutex
+	discovery  map[string]oidcDiscovery // By issuer
+	discovered map[string]time.Time
+	keySets    map[string]*oidcKeySet // By JWKS URI
+}{
+	discovery:  make(map[string]oidcDiscovery),
+	discovered: make(map[string]time.Time),
+	keySets:    make(map[string]*oidcKeySet),
+}
+
+// discoverOIDC returns the metadata of the provider with the given issuer
+func discoverOIDC(issuer string) (oidcDiscovery, error) {
+	oidcCache.mu.Lock()
+	discovery, ok := oidcCache.discovery[issuer]
+	fresh := ok && time.Since(oidcCache.discovered[issuer]) < oidcDiscoveryTTL
+	oidcCache.mu.Unlock()
+	if fresh {
+		return discovery, nil
+	}
+
+	if err := getOIDCJSON(strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", "", &discovery); err != nil {
+		return oidcDiscovery{}, fmt.Errorf("discovery failed: %w", err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return oidcDiscovery{}, fmt.Errorf("discovery returned issuer %q instead of %q", discovery.Issuer, issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return oidcDiscovery{}, errors.New("discovery is missing endpoints")
+	}
+
+	oidcCache.mu.Lock()
+	oidcCache.discovery[issuer] = discovery
+	oidcCache.discovered[issuer] = time.Now()
+	oidcCache.mu.Unlock()
+	return discovery, nil
+}
+
+// exchangeOIDCCode redeems an authorization code with its PKCE verifier
+func exchangeOIDCCode(provider oidcProvider, discovery oidcDiscovery, code, codeVerifier, redirectURI string) (oidcTokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("code_verifier", codeVerifier)
+	if provider.ClientSecret == "" {
+		form.Set("client_id", provider.ClientID) // Public client, identified by PKCE only
+	}
+
+	req, err := http.NewRequest(http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return oidcTokenResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if provider.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(provider.ClientID), url.QueryEscape(provider.ClientSecret))
+	}
+
+	resp, err := oidcHTTPClient.Do(req)
+	if err != nil {
+		return oidcTokenResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	var tokens oidcTokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, oidcResponseLimit)).Decode(&tokens); err != nil {
+		return oidcTokenResponse{}, fmt.Errorf("token endpoint answered %d: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || tokens.Error != "" {
+		return oidcTokenResponse{}, fmt.Errorf("token endpoint answered %d: %s %s", resp.StatusCode, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return oidcTokenResponse{}, errors.New("token endpoint returned no id_token")
+	}
+	return tokens, nil
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID
+// token and returns the identity it asserts
+func verifyIDToken(provider oidcProvider, discovery oidcDiscovery, rawIDToken, nonce string) (models.OIDCIdentity, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(oidcSigningMethods),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(provider.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(oidcClockSkew),
+	)
+
+	claims := jwt.MapClaims{}
+	if _, err := parser.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return oidcSigningKey(discovery.JWKSURI, kid)
+	}); err != nil {
+		return models.OIDCIdentity{}, fmt.Errorf("invalid id_token: %w", err)
+	}
+
+	// With several audiences the token must have been issued to us
+	if audience, _ := claims.GetAudience(); len(audience) > 1 {
+		if azp, _ := claims["azp"].(string); azp != provider.ClientID {
+			return models.OIDCIdentity{}, errors.New("id_token was issued to another client")
+		}
+	}
+
+	tokenNonce, _ := claims["nonce"].(string)
+	if subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+		return models.OIDCIdentity{}, errors.New("id_token nonce does not match")
+	}
+
+	identity := identityFromClaims(claims)
+	if identity.Subject == "" {
+		return models.OIDCIdentity{}, errors.New("id_token has no subject")
+	}
+	return identity, nil
+}
+
+// fetchOIDCUserinfo asks the provider for the claims missing from the ID token
+func fetchOIDCUserinfo(discovery oidcDiscovery, accessToken string) (models.OIDCIdentity, error) {
+	var claims map[string]interface{}
+	if err := getOIDCJSON(discovery.UserinfoEndpoint, accessToken, &claims); err != nil {
+		return models.OIDCIdentity{}, fmt.Errorf("userinfo failed: %w", err)
+	}
+	return identityFromClaims(claims), nil
+}
+
+// identityFromClaims reads the standard claims about the user
+func identityFromClaims(claims map[string]interface{}) models.OIDCIdentity {
+	identity := models.OIDCIdentity{}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	identity.PreferredUsername, _ = claims["preferred_username"].(string)
+
+	// Some providers send the flag as a string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+	return identity
+}
+
+// oidcSigningKey returns the key with the given ID from a provider's key set,
+// fetching the set again if the key is unknown, e.g. after the provider rotated keys
+func oidcSigningKey(jwksURI, kid string) (interface{}, error) {
+	oidcCache.mu.Lock()
+	set := oidcCache.keySets[jwksURI]
+	oidcCache.mu.Unlock()
+
+	if set != nil {
+		if key := set.find(kid); key != nil {
+			return key, nil
+		}
+		if time.Since(set.fetchedAt) < oidcJWKSRefreshDelay {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+	}
+
+	set, err := fetchOIDCKeySet(jwksURI)
+	if err != nil {
+		return nil, err
+	}
+	oidcCache.mu.Lock()
+	oidcCache.keySets[jwksURI] = set
+	oidcCache.mu.Unlock()
+
+	if key := set.find(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// find returns the key with the given ID, or the only key if the token names none
+func (s *oidcKeySet) find(kid string) interface{} {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key
+		}
+	}
+	return s.keys[kid]
+}
+
+// fetchOIDCKeySet downloads the JSON Web Key Set of a provider
+func fetchOIDCKeySet(jwksURI string) (*oidcKeySet, error) {
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := getOIDCJSON(jwksURI, "", &jwks); err != nil {
+		return nil, fmt.Errorf("fetching signing keys failed: %w", err)
+	}
+
+	set := &oidcKeySet{keys: make(map[string]interface{}), fetchedAt: time.Now()}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		switch jwk.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+			e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+			if errN != nil || errE != nil {
+				continue
+			}
+			set.keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+
+		case "EC":
+			var curve elliptic.Curve
+			switch jwk.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(jwk.X)
+			y, errY := base64.RawURLEncoding.DecodeString(jwk.Y)
+			if errX != nil || errY != nil {
+				continue
+			}
+			set.keys[jwk.Kid] = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	return set, nil
+}
+
+// getOIDCJSON fetches a JSON document from a provider, with a bearer token if given
+func getOIDCJSON(endpoint, accessToken string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := oidcHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, oidcResponseLimit)).Decode(v)
+}
@@ -0,0 +1,426 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/azka-art/taskwise-backend/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	oidcLoginTTL       = 10 * time.Minute // How long signing in at the provider can take
+	oidcRoutePrefix    = "/api/auth/oidc/"
+	oidcDefaultScopes  = "openid email profile"
+	maxUsernameLength  = 50
+	usernameSuffixSize = 4 // Random hex characters added when a username is taken
+)
+
+// usernameInvalidChars are the characters usernames can't contain
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// oidcProvider is an OpenID Connect provider users can sign in with. Providers
+// are configured with environment variables, see oidcProviders.
+type oidcProvider struct {
+	Name           string // Used in URLs
+	DisplayName    string
+	Issuer         string
+	ClientID       string
+	ClientSecret   string // Empty for public clients
+	Scopes         string
+	TrustEmail     bool     // Treat emails as verified even without email_verified, e.g. for a company directory
+	AllowedDomains []string // Email domains that may sign in; all when empty
+}
+
+// oidcProviders reads the providers listed in OIDC_PROVIDERS. For a provider
+// named "google" the settings are OIDC_GOOGLE_ISSUER, OIDC_GOOGLE_CLIENT_ID,
+// OIDC_GOOGLE_CLIENT_SECRET, OIDC_GOOGLE_DISPLAY_NAME, OIDC_GOOGLE_SCOPES,
+// OIDC_GOOGLE_TRUST_EMAIL and OIDC_GOOGLE_ALLOWED_DOMAINS.
+func oidcProviders() []oidcProvider {
+	var providers []oidcProvider
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := oidcProvider{
+			Name:         name,
+			DisplayName:  os.Getenv(prefix + "DISPLAY_NAME"),
+			Issuer:       strings.TrimSpace(os.Getenv(prefix + "ISSUER")),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Scopes:       os.Getenv(prefix + "SCOPES"),
+			TrustEmail:   os.Getenv(prefix+"TRUST_EMAIL") == "true",
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			log.Printf("⚠️ OIDC provider %q needs %sISSUER and %sCLIENT_ID, skipping it", name, prefix, prefix)
+			continue
+		}
+		if provider.DisplayName == "" {
+			provider.DisplayName = name
+		}
+		if provider.Scopes == "" {
+			provider.Scopes = oidcDefaultScopes
+		}
+		for _, domain := range strings.Split(os.Getenv(prefix+"ALLOWED_DOMAINS"), ",") {
+			if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+				provider.AllowedDomains = append(provider.AllowedDomains, domain)
+			}
+		}
+
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
+// findOIDCProvider returns the configured provider with the given name
+func findOIDCProvider(name string) (oidcProvider, error) {
+	for _, provider := range oidcProviders() {
+		if provider.Name == name {
+			return provider, nil
+		}
+	}
+	return oidcProvider{}, ErrOIDCProviderNotFound
+}
+
+// GetOIDCProviders lists the providers users can sign in with. Login URLs are on
+// OIDC_REDIRECT_BASE_URL, or on baseURL when it is not set.
+func GetOIDCProviders(baseURL string) []models.OIDCProviderInfo {
+	providers := make([]models.OIDCProviderInfo, 0)
+	for _, provider := range oidcProviders() {
+		providers = append(providers, models.OIDCProviderInfo{
+			Name:        provider.Name,
+			DisplayName: provider.DisplayName,
+			LoginURL:    oidcBaseURL(baseURL) + oidcRoutePrefix + provider.Name + "/login",
+		})
+	}
+	return providers
+}
+
+// StartOIDCLogin returns the URL of the provider's sign-in page, using the
+// authorization code flow with PKCE, and the state of the sign-in. The caller
+// keeps the state in the browser, e.g. in a cookie, and passes it back to
+// CompleteOIDCLogin, so that a sign-in can only be completed by the browser that
+// started it.
+func StartOIDCLogin(ctx context.Context, providerName, baseURL string) (authURL, state string, err error) {
+	provider, err := findOIDCProvider(providerName)
+	if err != nil {
+		return "", "", err
+	}
+	discovery, err := discoverOIDC(provider.Issuer)
+	if err != nil {
+		return "", "", oidcLoginFailed(provider, err)
+	}
+
+	state, err = utils.GenerateSecureToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := utils.GenerateSecureToken()
+	if err != nil {
+		return "", "", err
+	}
+	codeVerifier, err := utils.GenerateSecureToken() // 64 characters, within the 43 to 128 PKCE allows
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := repositories.CreateOIDCLoginState(ctx, &models.OIDCLoginState{
+		StateHash:    utils.HashToken(state),
+		Provider:     provider.Name,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(oidcLoginTTL),
+	}); err != nil {
+		return "", "", err
+	}
+
+	return oidcAuthorizationURL(provider, discovery, oidcRedirectURI(provider, baseURL), state, nonce, codeVerifier), state, nil
+}
+
+// oidcAuthorizationURL builds the URL of the provider's sign-in page, with the
+// S256 challenge of the PKCE code verifier
+func oidcAuthorizationURL(provider oidcProvider, discovery oidcDiscovery, redirectURI, state, nonce, codeVerifier string) string {
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", provider.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("scope", provider.Scopes)
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode()
+}
+
+// OIDCLoginTTL is how long a sign-in started with StartOIDCLogin can be completed
+func OIDCLoginTTL() time.Duration {
+	return oidcLoginTTL
+}
+
+// CompleteOIDCLogin handles the provider redirecting back with an authorization
+// code. browserState is the state StartOIDCLogin returned to the browser, which
+// must match the state of the redirect; otherwise an attacker could have the
+// browser complete a sign-in the attacker started, signing it in as them. The
+// user is found by a previous sign-in with the provider, then by verified email,
+// and is otherwise created if OIDC_AUTO_PROVISION allows it.
+func CompleteOIDCLogin(ctx context.Context, providerName, code, state, browserState, baseURL string) (models.LoginResult, models.User, error) {
+	// The organization is only known once the user signed in
+	ctx = repositories.AllOrganizations(ctx)
+
+	provider, err := findOIDCProvider(providerName)
+	if err != nil {
+		return models.LoginResult{}, models.User{}, err
+	}
+	if browserState == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return models.LoginResult{}, models.User{}, ErrInvalidOIDCState
+	}
+
+	// Every state works once, and only for the provider it was created for
+	login, err := repositories.ConsumeOIDCLoginState(ctx, utils.HashToken(state))
+	if err != nil {
+		return models.LoginResult{}, models.User{}, err
+	}
+	if login == nil || login.Provider != provider.Name || time.Now().After(login.ExpiresAt) {
+		return models.LoginResult{}, models.User{}, ErrInvalidOIDCState
+	}
+
+	discovery, err := discoverOIDC(provider.Issuer)
+	if err != nil {
+		return models.LoginResult{}, models.User{}, oidcLoginFailed(provider, err)
+	}
+	tokens, err := exchangeOIDCCode(provider, discovery, code, login.CodeVerifier, oidcRedirectURI(provider, baseURL))
+	if err != nil {
+		return models.LoginResult{}, models.User{}, oidcLoginFailed(provider, err)
+	}
+	identity, err := verifyIDToken(provider, discovery, tokens.IDToken, login.Nonce)
+	if err != nil {
+		return models.LoginResult{}, models.User{}, oidcLoginFailed(provider, err)
+	}
+
+	// Some providers only put the email into the userinfo
+	if identity.Email == "" && discovery.UserinfoEndpoint != "" && tokens.AccessToken != "" {
+		info, err := fetchOIDCUserinfo(discovery, tokens.AccessToken)
+		if err != nil {
+			return models.LoginResult{}, models.User{}, oidcLoginFailed(provider, err)
+		}
+		if info.Subject != identity.Subject {
+			return models.LoginResult{}, models.User{}, oidcLoginFailed(provider, errors.New("userinfo is about another user"))
+		}
+		identity.Email, identity.EmailVerified = info.Email, info.EmailVerified
+		if identity.Name == "" {
+			identity.Name = info.Name
+		}
+		if identity.PreferredUsername == "" {
+			identity.PreferredUsername = info.PreferredUsername
+		}
+	}
+	if provider.TrustEmail && identity.Email != "" {
+		identity.EmailVerified = true
+	}
+
+	user, err := findOIDCUser(ctx, provider, identity)
+	if err != nil {
+		return models.LoginResult{}, models.User{}, err
+	}
+	if err := checkLoginAllowed(user); err != nil {
+		return models.LoginResult{}, models.User{}, err
+	}
+
+	result, err := startSession(ctx, user)
+	if err != nil {
+		return models.LoginResult{}, models.User{}, err
+	}
+
+	user.Password = ""
+	return result, *user, nil
+}
+
+// findOIDCUser returns the user an identity belongs to, linking or creating the
+// account on the first sign-in
+func findOIDCUser(ctx context.Context, provider oidcProvider, identity models.OIDCIdentity) (*models.User, error) {
+	now := time.Now()
+	email := strings.TrimSpace(identity.Email)
+
+	if !oidcDomainAllowed(provider, email) {
+		return nil, fmt.Errorf("%w: %s accounts can't sign in with %s", ErrOIDCAccountNotAllowed, emailDomain(email), provider.DisplayName)
+	}
+
+	accounts := accountRepository(ctx)
+	linked, err := accounts.FindIdentity(provider.Name, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if linked != nil {
+		user, err := accounts.FindByID(linked.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, fmt.Errorf("%w: the linked account no longer exists", ErrOIDCAccountNotAllowed)
+		}
+		if err := accounts.TouchIdentity(linked.ID, email, now); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	// Accounts are only matched by email addresses the provider vouches for, so
+	// nobody can take over an account by entering someone else's email there
+	if email == "" || !identity.EmailVerified {
+		return nil, fmt.Errorf("%w: %s did not confirm a verified email address", ErrOIDCAccountNotAllowed, provider.DisplayName)
+	}
+
+	user, err := accounts.FindByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		if os.Getenv("OIDC_AUTO_PROVISION") == "false" {
+			return nil, fmt.Errorf("%w: there is no account for %s", ErrOIDCAccountNotAllowed, email)
+		}
+		if user, err = provisionOIDCUser(ctx, identity, email, now); err != nil {
+			return nil, err
+		}
+		log.Printf("👤 Created user %s for %s sign-in %s", user.ID, provider.Name, identity.Subject)
+	} else if !user.IsEmailVerified() {
+		// Whoever registered the account never proved they own the address and may
+		// still know its password, so it is not handed to the owner of the email
+		return nil, fmt.Errorf("%w: the account for %s has to verify its email address before it can use %s",
+			ErrOIDCAccountNotAllowed, email, provider.DisplayName)
+	}
+
+	if err := accounts.CreateIdentity(&models.UserIdentity{
+		UserID:      user.ID,
+		Provider:    provider.Name,
+		Subject:     identity.Subject,
+		Email:       email,
+		LastLoginAt: now,
+	}); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// provisionOIDCUser creates an account for someone signing in for the first time.
+// It has no usable password; one can be set with the password reset.
+func provisionOIDCUser(ctx context.Context, identity models.OIDCIdentity, email string, now time.Time) (*models.User, error) {
+	username, err := availableUsername(ctx, identity, email)
+	if err != nil {
+		return nil, err
+	}
+	password, err := utils.UnusablePasswordHash()
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		ID:              uuid.New(),
+		Username:        username,
+		Email:           email,
+		Password:        password,
+		Role:            models.RoleMember,
+		EmailVerifiedAt: &now,
+	}
+	if err := accountRepository(ctx).CreateWithOrganization(user, personalOrganization(user)); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// availableUsername derives a free username from the identity, adding a random
+// suffix if the preferred one is taken
+func availableUsername(ctx context.Context, identity models.OIDCIdentity, email string) (string, error) {
+	base := identity.PreferredUsername
+	if base == "" || strings.Contains(base, "@") {
+		base = strings.SplitN(email, "@", 2)[0]
+	}
+	base = strings.Trim(usernameInvalidChars.ReplaceAllString(base, "_"), "_")
+	if len(base) > maxUsernameLength-usernameSuffixSize-1 {
+		base = base[:maxUsernameLength-usernameSuffixSize-1]
+	}
+	for len(base) < 3 {
+		base += "_"
+	}
+
+	candidate := base
+	for attempt := 0; attempt < 5; attempt++ {
+		existing, err := accountRepository(ctx).FindByUsername(candidate)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return candidate, nil
+		}
+
+		suffix, err := utils.GenerateSecureToken()
+		if err != nil {
+			return "", err
+		}
+		candidate = base + "-" + suffix[:usernameSuffixSize]
+	}
+	return "", errors.New("could not find a free username")
+}
+
+// oidcDomainAllowed checks the email against the domains a provider is limited to
+func oidcDomainAllowed(provider oidcProvider, email string) bool {
+	if len(provider.AllowedDomains) == 0 {
+		return true
+	}
+	domain := strings.ToLower(emailDomain(email))
+	for _, allowed := range provider.AllowedDomains {
+		if domain == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// emailDomain returns the part of an email address after the @
+func emailDomain(email string) string {
+	if at := strings.LastIndex(email, "@"); at >= 0 {
+		return email[at+1:]
+	}
+	return ""
+}
+
+// oidcRedirectURI is the callback the provider sends users back to. It must be
+// registered with the provider.
+func oidcRedirectURI(provider oidcProvider, baseURL string) string {
+	return oidcBaseURL(baseURL) + oidcRoutePrefix + provider.Name + "/callback"
+}
+
+// oidcBaseURL returns the public URL of the API, OIDC_REDIRECT_BASE_URL or the
+// URL the request was sent to
+func oidcBaseURL(fallback string) string {
+	if base := os.Getenv("OIDC_REDIRECT_BASE_URL"); base != "" {
+		return strings.TrimSuffix(base, "/")
+	}
+	return strings.TrimSuffix(fallback, "/")
+}
+
+// oidcLoginFailed logs why talking to a provider failed, without telling the client
+func oidcLoginFailed(provider oidcProvider, err error) error {
+	log.Printf("❌ Sign-in with %s failed: %v", provider.Name, err)
+	return ErrOIDCLoginFailed
+}
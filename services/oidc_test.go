@@ -0,0 +1,620 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/config"
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/azka-art/taskwise-backend/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const (
+	testOIDCClientID = "taskwise-test"
+	testOIDCBaseURL  = "http://api.test"
+	testOIDCKeyID    = "test-key"
+)
+
+// mockOIDCUser is who signs in at the mock issuer
+type mockOIDCUser struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// mockOIDCAuthorization is an authorization code handed out by the mock issuer
+type mockOIDCAuthorization struct {
+	user        mockOIDCUser
+	challenge   string
+	nonce       string
+	redirectURI string
+}
+
+// mockOIDCIssuer is an OpenID Connect provider on an httptest server. It serves
+// discovery, a JSON Web Key Set and a token endpoint that checks PKCE.
+type mockOIDCIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu            sync.Mutex
+	codes         map[string]mockOIDCAuthorization
+	nonceOverride string // Put into ID tokens instead of the nonce of the sign-in, if set
+}
+
+func newMockOIDCIssuer(t *testing.T) *mockOIDCIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating signing key: %v", err)
+	}
+	issuer := &mockOIDCIssuer{key: key, codes: make(map[string]mockOIDCAuthorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 issuer.URL,
+			"authorization_endpoint": issuer.URL + "/authorize",
+			"token_endpoint":         issuer.URL + "/token",
+			"jwks_uri":               issuer.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, http.StatusOK, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": testOIDCKeyID,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", issuer.token)
+
+	issuer.Server = httptest.NewServer(mux)
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+// provider returns the configuration of a public client of the mock issuer
+func (m *mockOIDCIssuer) provider() oidcProvider {
+	return oidcProvider{
+		Name:        "mock",
+		DisplayName: "Mock",
+		Issuer:      m.URL,
+		ClientID:    testOIDCClientID,
+		Scopes:      oidcDefaultScopes,
+	}
+}
+
+// authorize signs the user in at the authorization URL, like the browser and the
+// provider's sign-in page would, and returns the authorization code
+func (m *mockOIDCIssuer) authorize(t *testing.T, authURL string, user mockOIDCUser) string {
+	t.Helper()
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parsing authorization URL: %v", err)
+	}
+	if !strings.HasPrefix(authURL, m.URL+"/authorize?") {
+		t.Fatalf("authorization URL %q is not on the issuer", authURL)
+	}
+	query := parsed.Query()
+	if query.Get("client_id") != testOIDCClientID || query.Get("response_type") != "code" {
+		t.Fatalf("unexpected authorization request: %s", parsed.RawQuery)
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("authorization request has no S256 PKCE challenge: %s", parsed.RawQuery)
+	}
+
+	code, err := utils.GenerateSecureToken()
+	if err != nil {
+		t.Fatalf("generating code: %v", err)
+	}
+	m.mu.Lock()
+	m.codes[code] = mockOIDCAuthorization{
+		user:        user,
+		challenge:   query.Get("code_challenge"),
+		nonce:       query.Get("nonce"),
+		redirectURI: query.Get("redirect_uri"),
+	}
+	m.mu.Unlock()
+	return code
+}
+
+// token redeems an authorization code once, if the PKCE verifier matches its challenge
+func (m *mockOIDCIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeTestJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	m.mu.Lock()
+	authorization, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	nonce := authorization.nonce
+	if m.nonceOverride != "" {
+		nonce = m.nonceOverride
+	}
+	m.mu.Unlock()
+
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("client_id") != testOIDCClientID ||
+		r.PostForm.Get("redirect_uri") != authorization.redirectURI {
+		writeTestJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(verifier[:]) != authorization.challenge {
+		writeTestJSON(w, http.StatusBadRequest, map[string]string{
+			"error":             "invalid_grant",
+			"error_description": "PKCE verification failed",
+		})
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            m.URL,
+		"aud":            testOIDCClientID,
+		"sub":            authorization.user.Subject,
+		"email":          authorization.user.Email,
+		"email_verified": authorization.user.EmailVerified,
+		"nonce":          nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	})
+	idToken.Header["kid"] = testOIDCKeyID
+	signed, err := idToken.SignedString(m.key)
+	if err != nil {
+		writeTestJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeTestJSON(w, http.StatusOK, map[string]string{
+		"access_token": "access-" + authorization.user.Subject,
+		"token_type":   "Bearer",
+		"id_token":     signed,
+	})
+}
+
+func writeTestJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestOIDCCodeExchangeWithPKCE(t *testing.T) {
+	issuer := newMockOIDCIssuer(t)
+	provider := issuer.provider()
+	discovery, err := discoverOIDC(provider.Issuer)
+	if err != nil {
+		t.Fatalf("discovery: %v", err)
+	}
+
+	redirectURI := oidcRedirectURI(provider, testOIDCBaseURL)
+	verifier, nonce := strings.Repeat("v", 64), "nonce-1"
+	authURL := oidcAuthorizationURL(provider, discovery, redirectURI, "state-1", nonce, verifier)
+	user := mockOIDCUser{Subject: "user-1", Email: "ada@example.com", EmailVerified: true}
+
+	t.Run("matching verifier", func(t *testing.T) {
+		code := issuer.authorize(t, authURL, user)
+		tokens, err := exchangeOIDCCode(provider, discovery, code, verifier, redirectURI)
+		if err != nil {
+			t.Fatalf("exchange: %v", err)
+		}
+		identity, err := verifyIDToken(provider, discovery, tokens.IDToken, nonce)
+		if err != nil {
+			t.Fatalf("verifying id_token: %v", err)
+		}
+		if identity.Subject != user.Subject || identity.Email != user.Email || !identity.EmailVerified {
+			t.Errorf("identity = %+v, want %+v", identity, user)
+		}
+	})
+
+	t.Run("wrong verifier", func(t *testing.T) {
+		code := issuer.authorize(t, authURL, user)
+		if _, err := exchangeOIDCCode(provider, discovery, code, strings.Repeat("x", 64), redirectURI); err == nil {
+			t.Fatal("exchange with the wrong code verifier succeeded")
+		}
+	})
+
+	t.Run("code works once", func(t *testing.T) {
+		code := issuer.authorize(t, authURL, user)
+		if _, err := exchangeOIDCCode(provider, discovery, code, verifier, redirectURI); err != nil {
+			t.Fatalf("first exchange: %v", err)
+		}
+		if _, err := exchangeOIDCCode(provider, discovery, code, verifier, redirectURI); err == nil {
+			t.Fatal("second exchange of the same code succeeded")
+		}
+	})
+}
+
+func TestOIDCNonceMismatch(t *testing.T) {
+	issuer := newMockOIDCIssuer(t)
+	provider := issuer.provider()
+	discovery, err := discoverOIDC(provider.Issuer)
+	if err != nil {
+		t.Fatalf("discovery: %v", err)
+	}
+
+	redirectURI := oidcRedirectURI(provider, testOIDCBaseURL)
+	verifier, nonce := strings.Repeat("v", 64), "nonce-of-this-sign-in"
+	authURL := oidcAuthorizationURL(provider, discovery, redirectURI, "state-1", nonce, verifier)
+	user := mockOIDCUser{Subject: "user-1", Email: "ada@example.com", EmailVerified: true}
+
+	// An ID token of another sign-in, e.g. replayed by an attacker
+	issuer.mu.Lock()
+	issuer.nonceOverride = "nonce-of-another-sign-in"
+	issuer.mu.Unlock()
+
+	code := issuer.authorize(t, authURL, user)
+	tokens, err := exchangeOIDCCode(provider, discovery, code, verifier, redirectURI)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	_, err = verifyIDToken(provider, discovery, tokens.IDToken, nonce)
+	if err == nil || !strings.Contains(err.Error(), "nonce") {
+		t.Fatalf("verifyIDToken error = %v, want a nonce mismatch", err)
+	}
+}
+
+func TestCompleteOIDCLoginRequiresBrowserState(t *testing.T) {
+	issuer := newMockOIDCIssuer(t)
+	configureMockOIDC(t, issuer)
+
+	for name, browserState := range map[string]string{"no cookie": "", "other sign-in": "state-b"} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := CompleteOIDCLogin(context.Background(), "mock", "code", "state-a", browserState, testOIDCBaseURL)
+			if !errors.Is(err, ErrInvalidOIDCState) {
+				t.Fatalf("error = %v, want %v", err, ErrInvalidOIDCState)
+			}
+		})
+	}
+}
+
+func TestCompleteOIDCLoginProvisionsUser(t *testing.T) {
+	setupOIDCDatabase(t)
+	issuer := newMockOIDCIssuer(t)
+	configureMockOIDC(t, issuer)
+
+	user := mockOIDCUser{Subject: uuid.NewString(), Email: uniqueTestEmail("new"), EmailVerified: true}
+	created, err := signInWithMockOIDC(t, issuer, user)
+	if err != nil {
+		t.Fatalf("first sign-in: %v", err)
+	}
+	if created.Email != user.Email || !created.IsEmailVerified() || created.Role != models.RoleMember {
+		t.Errorf("created user = %+v, want a verified member with email %s", created, user.Email)
+	}
+
+	member, err := repositories.GetFirstOrganizationMember(repositories.AllOrganizations(context.Background()), created.ID)
+	if err != nil || member == nil || member.Role != models.RoleAdmin {
+		t.Errorf("personal organization membership = %+v, %v; want an admin membership", member, err)
+	}
+
+	again, err := signInWithMockOIDC(t, issuer, user)
+	if err != nil {
+		t.Fatalf("second sign-in: %v", err)
+	}
+	if again.ID != created.ID {
+		t.Errorf("second sign-in returned user %s, want %s", again.ID, created.ID)
+	}
+}
+
+func TestCompleteOIDCLoginLinksVerifiedAccount(t *testing.T) {
+	setupOIDCDatabase(t)
+	issuer := newMockOIDCIssuer(t)
+	configureMockOIDC(t, issuer)
+
+	existing := createTestUser(t, uniqueTestEmail("linked"), true)
+	user := mockOIDCUser{Subject: uuid.NewString(), Email: existing.Email, EmailVerified: true}
+
+	signedIn, err := signInWithMockOIDC(t, issuer, user)
+	if err != nil {
+		t.Fatalf("sign-in: %v", err)
+	}
+	if signedIn.ID != existing.ID {
+		t.Errorf("signed in as %s, want the existing account %s", signedIn.ID, existing.ID)
+	}
+
+	identity, err := repositories.GetUserIdentity(repositories.AllOrganizations(context.Background()), "mock", user.Subject)
+	if err != nil || identity == nil || identity.UserID != existing.ID {
+		t.Errorf("linked identity = %+v, %v; want one of user %s", identity, err, existing.ID)
+	}
+}
+
+func TestCompleteOIDCLoginRefusesUnverifiedAccount(t *testing.T) {
+	setupOIDCDatabase(t)
+	issuer := newMockOIDCIssuer(t)
+	configureMockOIDC(t, issuer)
+
+	existing := createTestUser(t, uniqueTestEmail("unverified"), false)
+	user := mockOIDCUser{Subject: uuid.NewString(), Email: existing.Email, EmailVerified: true}
+
+	if _, err := signInWithMockOIDC(t, issuer, user); !errors.Is(err, ErrOIDCAccountNotAllowed) {
+		t.Fatalf("error = %v, want %v", err, ErrOIDCAccountNotAllowed)
+	}
+
+	identity, err := repositories.GetUserIdentity(repositories.AllOrganizations(context.Background()), "mock", user.Subject)
+	if err != nil || identity != nil {
+		t.Errorf("identity = %+v, %v; want none", identity, err)
+	}
+	stored, err := repositories.GetUserByID(repositories.AllOrganizations(context.Background()), existing.ID)
+	if err != nil || stored == nil || stored.IsEmailVerified() {
+		t.Errorf("account = %+v, %v; want it still unverified", stored, err)
+	}
+}
+
+func TestCompleteOIDCLoginRefusesUnverifiedProviderEmail(t *testing.T) {
+	setupOIDCDatabase(t)
+	issuer := newMockOIDCIssuer(t)
+	configureMockOIDC(t, issuer)
+
+	existing := createTestUser(t, uniqueTestEmail("victim"), true)
+	user := mockOIDCUser{Subject: uuid.NewString(), Email: existing.Email, EmailVerified: false}
+
+	if _, err := signInWithMockOIDC(t, issuer, user); !errors.Is(err, ErrOIDCAccountNotAllowed) {
+		t.Fatalf("error = %v, want %v", err, ErrOIDCAccountNotAllowed)
+	}
+}
+
+// testOIDCProvider is the provider findOIDCUser is tested with
+var testOIDCProvider = oidcProvider{Name: "mock", DisplayName: "Mock"}
+
+func TestFindOIDCUserProvisionsAccount(t *testing.T) {
+	accounts := useFakeAccounts(t)
+	t.Setenv("OIDC_AUTO_PROVISION", "")
+	ctx := context.Background()
+
+	identity := models.OIDCIdentity{Subject: uuid.NewString(), Email: "new@example.com", EmailVerified: true, PreferredUsername: "new.user"}
+	created, err := findOIDCUser(ctx, testOIDCProvider, identity)
+	if err != nil {
+		t.Fatalf("first sign-in: %v", err)
+	}
+	if created.Email != identity.Email || created.Username != "new_user" || !created.IsEmailVerified() || created.Role != models.RoleMember {
+		t.Errorf("created user = %+v, want a verified member new_user with email %s", created, identity.Email)
+	}
+	if _, ok := accounts.organizations[created.ID]; !ok {
+		t.Error("the new user has no personal organization")
+	}
+	if linked := accounts.identitiesOf(created.ID); len(linked) != 1 || linked[0].Subject != identity.Subject {
+		t.Errorf("linked identities = %+v, want the one of the sign-in", linked)
+	}
+
+	// The next sign-in finds the account through the identity, even with another email
+	identity.Email = "renamed@example.com"
+	again, err := findOIDCUser(ctx, testOIDCProvider, identity)
+	if err != nil {
+		t.Fatalf("second sign-in: %v", err)
+	}
+	if again.ID != created.ID {
+		t.Errorf("second sign-in returned user %s, want %s", again.ID, created.ID)
+	}
+	if linked := accounts.identitiesOf(created.ID); len(linked) != 1 || linked[0].Email != identity.Email {
+		t.Errorf("linked identities = %+v, want one with the new email", linked)
+	}
+}
+
+func TestFindOIDCUserWithoutAutoProvisioning(t *testing.T) {
+	accounts := useFakeAccounts(t)
+	t.Setenv("OIDC_AUTO_PROVISION", "false")
+
+	identity := models.OIDCIdentity{Subject: uuid.NewString(), Email: "new@example.com", EmailVerified: true}
+	if _, err := findOIDCUser(context.Background(), testOIDCProvider, identity); !errors.Is(err, ErrOIDCAccountNotAllowed) {
+		t.Fatalf("error = %v, want %v", err, ErrOIDCAccountNotAllowed)
+	}
+	if len(accounts.users) != 0 {
+		t.Errorf("%d accounts were created, want none", len(accounts.users))
+	}
+}
+
+func TestFindOIDCUserLinksVerifiedAccount(t *testing.T) {
+	accounts := useFakeAccounts(t)
+	existing := accounts.addUser("linked@example.com", true)
+
+	identity := models.OIDCIdentity{Subject: uuid.NewString(), Email: existing.Email, EmailVerified: true}
+	signedIn, err := findOIDCUser(context.Background(), testOIDCProvider, identity)
+	if err != nil {
+		t.Fatalf("sign-in: %v", err)
+	}
+	if signedIn.ID != existing.ID {
+		t.Errorf("signed in as %s, want the existing account %s", signedIn.ID, existing.ID)
+	}
+	if linked := accounts.identitiesOf(existing.ID); len(linked) != 1 || linked[0].Provider != testOIDCProvider.Name {
+		t.Errorf("linked identities = %+v, want one of the provider", linked)
+	}
+}
+
+func TestFindOIDCUserRefusesUnverifiedAccount(t *testing.T) {
+	accounts := useFakeAccounts(t)
+	existing := accounts.addUser("unverified@example.com", false)
+
+	identity := models.OIDCIdentity{Subject: uuid.NewString(), Email: existing.Email, EmailVerified: true}
+	if _, err := findOIDCUser(context.Background(), testOIDCProvider, identity); !errors.Is(err, ErrOIDCAccountNotAllowed) {
+		t.Fatalf("error = %v, want %v", err, ErrOIDCAccountNotAllowed)
+	}
+	if linked := accounts.identitiesOf(existing.ID); len(linked) != 0 {
+		t.Errorf("linked identities = %+v, want none", linked)
+	}
+	if stored, _ := accounts.FindByID(existing.ID); stored.IsEmailVerified() {
+		t.Error("the account was marked as verified")
+	}
+}
+
+func TestFindOIDCUserRefusesUnverifiedProviderEmail(t *testing.T) {
+	accounts := useFakeAccounts(t)
+	existing := accounts.addUser("victim@example.com", true)
+
+	identity := models.OIDCIdentity{Subject: uuid.NewString(), Email: existing.Email, EmailVerified: false}
+	if _, err := findOIDCUser(context.Background(), testOIDCProvider, identity); !errors.Is(err, ErrOIDCAccountNotAllowed) {
+		t.Fatalf("error = %v, want %v", err, ErrOIDCAccountNotAllowed)
+	}
+	if linked := accounts.identitiesOf(existing.ID); len(linked) != 0 {
+		t.Errorf("linked identities = %+v, want none", linked)
+	}
+}
+
+func TestFindOIDCUserRefusesOtherDomains(t *testing.T) {
+	accounts := useFakeAccounts(t)
+	provider := testOIDCProvider
+	provider.AllowedDomains = []string{"example.com"}
+
+	identity := models.OIDCIdentity{Subject: uuid.NewString(), Email: "someone@example.org", EmailVerified: true}
+	if _, err := findOIDCUser(context.Background(), provider, identity); !errors.Is(err, ErrOIDCAccountNotAllowed) {
+		t.Fatalf("error = %v, want %v", err, ErrOIDCAccountNotAllowed)
+	}
+	if len(accounts.users) != 0 {
+		t.Errorf("%d accounts were created, want none", len(accounts.users))
+	}
+}
+
+// configureMockOIDC configures the mock issuer as the provider named "mock"
+func configureMockOIDC(t *testing.T, issuer *mockOIDCIssuer) {
+	t.Helper()
+	t.Setenv("OIDC_PROVIDERS", "mock")
+	t.Setenv("OIDC_MOCK_ISSUER", issuer.URL)
+	t.Setenv("OIDC_MOCK_CLIENT_ID", testOIDCClientID)
+	t.Setenv("OIDC_REDIRECT_BASE_URL", "")
+	t.Setenv("OIDC_AUTO_PROVISION", "")
+	t.Setenv("UNVERIFIED_EMAIL_POLICY", "")
+}
+
+// signInWithMockOIDC goes through a whole sign-in with the mock issuer, with the
+// state cookie of the browser that started it
+func signInWithMockOIDC(t *testing.T, issuer *mockOIDCIssuer, user mockOIDCUser) (models.User, error) {
+	t.Helper()
+
+	authURL, state, err := StartOIDCLogin(context.Background(), "mock", testOIDCBaseURL)
+	if err != nil {
+		t.Fatalf("starting sign-in: %v", err)
+	}
+	code := issuer.authorize(t, authURL, user)
+
+	result, signedIn, err := CompleteOIDCLogin(context.Background(), "mock", code, state, state, testOIDCBaseURL)
+	if err == nil && result.Tokens.AccessToken == "" {
+		t.Fatal("sign-in returned no access token")
+	}
+	return signedIn, err
+}
+
+var testDatabase struct {
+	once sync.Once
+	db   *gorm.DB
+	err  error
+}
+
+// setupOIDCDatabase points config.DB to the PostgreSQL database in
+// TEST_DATABASE_DSN, with the tables single sign-on uses. Tests that need a
+// database are skipped without one.
+func setupOIDCDatabase(t *testing.T) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	testDatabase.once.Do(func() {
+		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		if err != nil {
+			testDatabase.err = err
+			return
+		}
+
+		// The users table comes from the initial schema, not from AutoMigrate
+		for _, statement := range []string{
+			`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`,
+			`CREATE TABLE IF NOT EXISTS users (
+				id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+				username text NOT NULL UNIQUE,
+				email text NOT NULL UNIQUE,
+				password text NOT NULL,
+				role varchar(20) DEFAULT 'member',
+				email_verified_at timestamptz,
+				created_at timestamptz,
+				updated_at timestamptz,
+				deleted_at timestamptz
+			)`,
+		} {
+			if err := db.Exec(statement).Error; err != nil {
+				testDatabase.err = err
+				return
+			}
+		}
+		if err := db.AutoMigrate(
+			&models.Organization{},
+			&models.OrganizationMember{},
+			&models.UserIdentity{},
+			&models.OIDCLoginState{},
+			&models.UserMFA{},
+			&models.RefreshToken{},
+		); err != nil {
+			testDatabase.err = err
+			return
+		}
+
+		testDatabase.err = repositories.RegisterTenantScope(db)
+		testDatabase.db = db
+	})
+	if testDatabase.err != nil {
+		t.Fatalf("setting up the test database: %v", testDatabase.err)
+	}
+
+	previous := config.DB
+	config.DB = testDatabase.db
+	t.Cleanup(func() { config.DB = previous })
+}
+
+// createTestUser creates an account with a personal organization
+func createTestUser(t *testing.T, email string, verified bool) models.User {
+	t.Helper()
+
+	password, err := utils.UnusablePasswordHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := models.User{
+		Username: "user_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12],
+		Email:    email,
+		Password: password,
+		Role:     models.RoleMember,
+	}
+	if verified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	ctx := repositories.AllOrganizations(context.Background())
+	if err := repositories.CreateUser(ctx, &user); err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	if err := createPersonalOrganization(ctx, &user); err != nil {
+		t.Fatalf("creating organization: %v", err)
+	}
+	return user
+}
+
+// uniqueTestEmail returns an email address no other test run uses
+func uniqueTestEmail(prefix string) string {
+	return prefix + "-" + uuid.NewString()[:8] + "@example.com"
+}
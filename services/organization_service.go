@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/google/uuid"
+)
+
+// GetOrganizations lists the organizations a user belongs to, marking the one of
+// the current access token
+func GetOrganizations(ctx context.Context, userID, currentID uuid.UUID) ([]models.OrganizationResponse, error) {
+	members, err := repositories.GetOrganizationMembersByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	organizations := make([]models.OrganizationResponse, 0, len(members))
+	for _, member := range members {
+		if member.Organization == nil {
+			continue
+		}
+		organizations = append(organizations, models.OrganizationResponse{
+			Organization: *member.Organization,
+			Role:         member.Role,
+			Current:      member.OrganizationID == currentID,
+		})
+	}
+	return organizations, nil
+}
+
+// CreateOrganization creates an organization with the user as its admin
+func CreateOrganization(ctx context.Context, userID uuid.UUID, req models.OrganizationRequest) (models.OrganizationResponse, error) {
+	organization := models.Organization{Name: req.Name}
+	if err := organization.Validate(); err != nil {
+		return models.OrganizationResponse{}, invalidInput(err)
+	}
+
+	if err := repositories.CreateOrganization(ctx, &organization, userID); err != nil {
+		return models.OrganizationResponse{}, err
+	}
+	return models.OrganizationResponse{Organization: organization, Role: models.RoleAdmin}, nil
+}
+
+// SwitchOrganization issues tokens for another organization of the user. The new
+// tokens start a new refresh token family.
+func SwitchOrganization(ctx context.Context, userID, organizationID uuid.UUID) (models.AuthTokens, models.User, error) {
+	organization, err := repositories.GetOrganizationByID(ctx, organizationID)
+	if err != nil {
+		return models.AuthTokens{}, models.User{}, err
+	}
+	if organization == nil {
+		return models.AuthTokens{}, models.User{}, ErrOrganizationNotFound
+	}
+
+	// The user is looked up across organizations, as they may not belong to the new one
+	ctx = repositories.AllOrganizations(ctx)
+	user, err := repositories.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.AuthTokens{}, models.User{}, err
+	}
+	if user == nil {
+		return models.AuthTokens{}, models.User{}, ErrUserNotFound
+	}
+
+	tokens, err := issueTokens(ctx, user, uuid.New(), organizationID)
+	if err != nil {
+		return models.AuthTokens{}, models.User{}, err
+	}
+
+	user.Password = ""
+	return tokens, *user, nil
+}
+
+// createPersonalOrganization creates the organization a new user starts in
+func createPersonalOrganization(ctx context.Context, user *models.User) error {
+	return repositories.CreateOrganization(ctx, personalOrganization(user), user.ID)
+}
+
+// personalOrganization returns a new organization named after a user
+func personalOrganization(user *models.User) *models.Organization {
+	return &models.Organization{Name: fmt.Sprintf("%s's organization", user.Username)}
+}
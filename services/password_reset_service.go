@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/azka-art/taskwise-backend/utils"
+)
+
+const (
+	passwordResetTTL  = time.Hour         // How long a reset link can be used
+	passwordResetPath = "/reset-password" // Page of the app the reset link opens
+)
+
+// RequestPasswordReset emails a password reset link to the user with the given
+// email. Nothing happens for unknown emails, and callers must not tell the
+// difference, so that the endpoint can't be used to find out who has an account.
+// Links point to APP_URL; without it or a mailer no email is sent.
+func RequestPasswordReset(ctx context.Context, email string) error {
+	baseURL, err := appURL()
+	if err != nil {
+		return err
+	}
+	if err := CheckMailer(); err != nil {
+		return err
+	}
+
+	// Accounts are found by email address across organizations
+	ctx = repositories.AllOrganizations(ctx)
+
+	user, err := repositories.GetUserByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	token, err := utils.GenerateSecureToken()
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(passwordResetTTL)
+	if err := repositories.CreatePasswordResetToken(ctx, &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return err
+	}
+
+	link := baseURL + passwordResetPath + "?token=" + url.QueryEscape(token)
+	return sendMailAsync(MailMessage{
+		To:      user.Email,
+		Subject: "Reset your TaskWise password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Someone asked to reset the password of your TaskWise account. Open this link to choose a new password:\n\n"+
+			"%s\n\n"+
+			"The link works once and expires in %d minutes. If you did not ask for it, you can ignore this email.\n",
+			user.Username, link, int(passwordResetTTL.Minutes())),
+	})
+}
+
+// ResetPassword sets a new password with a token from a reset link. The token
+// stops working, and the user is logged out everywhere.
+func ResetPassword(ctx context.Context, token, newPassword string) error {
+	// The reset link is used without signing in to an organization
+	ctx = repositories.AllOrganizations(ctx)
+
+	if err := utils.ValidatePassword(newPassword); err != nil {
+		return invalidInput(err)
+	}
+
+	stored, err := repositories.GetPasswordResetTokenByHash(ctx, utils.HashToken(token))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if stored == nil || stored.UsedAt != nil || now.After(stored.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	// A concurrent request may have used the token in the meantime
+	marked, err := repositories.MarkPasswordResetTokenUsed(ctx, stored.ID, now)
+	if err != nil {
+		return err
+	}
+	if !marked {
+		return ErrInvalidResetToken
+	}
+
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := repositories.SetPasswordHash(ctx, stored.UserID, hashedPassword); err != nil {
+		return err
+	}
+
+	log.Printf("🔑 Password of user %s was reset", stored.UserID)
+	return LogoutAll(ctx, stored.UserID)
+}
+
+// appURL returns the URL of the web app links in emails point to. It is only
+// taken from APP_URL, never from request headers, which clients control.
+func appURL() (string, error) {
+	base := strings.TrimSuffix(strings.TrimSpace(os.Getenv("APP_URL")), "/")
+	if base == "" {
+		return "", ErrAppURLNotConfigured
+	}
+	return base, nil
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/google/uuid"
+)
+
+// CreateProject creates a new project owned by the given user
+func CreateProject(ctx context.Context, ownerID uuid.UUID, req models.ProjectRequest) (models.Project, error) {
+	project := models.Project{
+		Name:        req.Name,
+		Description: req.Description,
+		OwnerID:     ownerID,
+	}
+
+	if err := repositories.CreateProject(ctx, &project); err != nil {
+		return models.Project{}, err
+	}
+	return project, nil
+}
+
+// GetProjectsForUser retrieves all projects the user is a member of
+func GetProjectsForUser(ctx context.Context, userID uuid.UUID) ([]models.Project, error) {
+	return repositories.GetProjectsByUserID(ctx, userID)
+}
+
+// GetProject retrieves a project visible to the given user
+func GetProject(ctx context.Context, projectID, userID uuid.UUID, role models.UserRole) (*models.Project, error) {
+	project, err := repositories.GetProjectByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, ErrProjectNotFound
+	}
+
+	canView, err := CanViewProject(ctx, projectID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, ErrForbidden
+	}
+
+	return project, nil
+}
+
+// AddProjectMember adds a user to a project (project admins only)
+func AddProjectMember(ctx context.Context, projectID, actorID uuid.UUID, actorRole models.UserRole, req models.ProjectMemberRequest) (models.ProjectMember, error) {
+	if !actorRole.Can(models.PermProjectMembers) {
+		return models.ProjectMember{}, ErrForbidden
+	}
+	if err := requireProjectAdmin(ctx, projectID, actorID, actorRole); err != nil {
+		return models.ProjectMember{}, err
+	}
+
+	user, err := repositories.GetUserByID(ctx, req.UserID)
+	if err != nil {
+		return models.ProjectMember{}, err
+	}
+	if user == nil {
+		return models.ProjectMember{}, errors.New("user not found")
+	}
+
+	member := models.ProjectMember{
+		ProjectID: projectID,
+		UserID:    req.UserID,
+		Role:      req.Role,
+	}
+	if member.Role == "" {
+		member.Role = models.ProjectRoleMember
+	}
+
+	if err := repositories.AddProjectMember(ctx, &member); err != nil {
+		return models.ProjectMember{}, err
+	}
+	return member, nil
+}
+
+// CanViewProject checks if a user is a member of the project or may view any project
+func CanViewProject(ctx context.Context, projectID, userID uuid.UUID, role models.UserRole) (bool, error) {
+	// Projects of other organizations are not found
+	if role.Can(models.PermProjectViewAny) {
+		project, err := repositories.GetProjectByID(ctx, projectID)
+		return project != nil, err
+	}
+
+	member, err := repositories.GetProjectMember(ctx, projectID, userID)
+	if err != nil {
+		return false, err
+	}
+	return member != nil, nil
+}
+
+// CanManageProject checks if a user is a project admin or may manage any project
+func CanManageProject(ctx context.Context, projectID, userID uuid.UUID, role models.UserRole) (bool, error) {
+	if role.Can(models.PermProjectManageAny) {
+		project, err := repositories.GetProjectByID(ctx, projectID)
+		return project != nil, err
+	}
+
+	member, err := repositories.GetProjectMember(ctx, projectID, userID)
+	if err != nil {
+		return false, err
+	}
+	return member != nil && member.Role == models.ProjectRoleAdmin, nil
+}
+
+// requireProjectAdmin returns an error unless the user may manage the project
+func requireProjectAdmin(ctx context.Context, projectID, userID uuid.UUID, role models.UserRole) error {
+	project, err := repositories.GetProjectByID(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if project == nil {
+		return ErrProjectNotFound
+	}
+
+	canManage, err := CanManageProject(ctx, projectID, userID, role)
+	if err != nil {
+		return err
+	}
+	if !canManage {
+		return ErrForbidden
+	}
+	return nil
+}
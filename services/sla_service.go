@@ -0,0 +1,507 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/google/uuid"
+)
+
+const (
+	slaCheckInterval  = time.Minute // How often missed SLA targets are looked for
+	slaBreachBatch    = 100         // Breaches reported per run
+	slaAtRiskFraction = 4           // A target is at risk with less than 1/4 of its time left
+)
+
+// StartSLAWorker keeps the SLA clocks of tasks up to date with task events and
+// starts the background worker that reports missed targets
+func StartSLAWorker() {
+	ctx := repositories.AllOrganizations(context.Background())
+	SubscribeEvents(handleSLAEvent)
+
+	go func() {
+		ticker := time.NewTicker(slaCheckInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			reportSLABreaches(ctx)
+		}
+	}()
+}
+
+// GetBusinessHours returns the business hours of a project, or the defaults if it has none
+func GetBusinessHours(ctx context.Context, projectID, userID uuid.UUID, role models.UserRole) (models.BusinessHours, error) {
+	if _, err := GetProject(ctx, projectID, userID, role); err != nil {
+		return models.BusinessHours{}, err
+	}
+
+	return businessHoursOf(ctx, projectID)
+}
+
+// SetBusinessHours replaces the business hours of a project (project admins only)
+// and recalculates the SLA clocks of its tasks
+func SetBusinessHours(ctx context.Context, projectID, actorID uuid.UUID, actorRole models.UserRole, req models.BusinessHoursRequest) (models.BusinessHours, error) {
+	if err := requireProjectAdmin(ctx, projectID, actorID, actorRole); err != nil {
+		return models.BusinessHours{}, err
+	}
+
+	hours := models.BusinessHours{
+		ProjectID: projectID,
+		Timezone:  req.Timezone,
+		DayStart:  req.DayStart,
+		DayEnd:    req.DayEnd,
+		Workdays:  req.Workdays,
+		Holidays:  req.Holidays,
+	}
+	if hours.Timezone == "" {
+		hours.Timezone = "UTC"
+	}
+	if hours.Holidays == nil {
+		hours.Holidays = []string{}
+	}
+	if err := hours.Validate(); err != nil {
+		return models.BusinessHours{}, invalidInput(err)
+	}
+
+	existing, err := repositories.GetBusinessHours(ctx, projectID)
+	if err != nil {
+		return models.BusinessHours{}, err
+	}
+	if existing == nil {
+		err = repositories.CreateBusinessHours(ctx, &hours)
+	} else {
+		err = repositories.UpdateBusinessHours(ctx, &hours)
+	}
+	if err != nil {
+		return models.BusinessHours{}, err
+	}
+
+	resyncProjectSLAs(ctx, projectID)
+	return hours, nil
+}
+
+// GetSLAPolicies lists the SLA policies of a project
+func GetSLAPolicies(ctx context.Context, projectID, userID uuid.UUID, role models.UserRole) ([]models.SLAPolicy, error) {
+	if _, err := GetProject(ctx, projectID, userID, role); err != nil {
+		return nil, err
+	}
+
+	return repositories.GetSLAPoliciesByProjectID(ctx, projectID)
+}
+
+// SetSLAPolicy sets the SLA targets of a priority in a project (project admins only),
+// replacing the previous targets of that priority
+func SetSLAPolicy(ctx context.Context, projectID, actorID uuid.UUID, actorRole models.UserRole, req models.SLAPolicyRequest) (models.SLAPolicy, error) {
+	if err := requireProjectAdmin(ctx, projectID, actorID, actorRole); err != nil {
+		return models.SLAPolicy{}, err
+	}
+
+	policy, err := repositories.GetSLAPolicy(ctx, projectID, req.Priority)
+	if err != nil {
+		return models.SLAPolicy{}, err
+	}
+	create := policy == nil
+	if create {
+		policy = &models.SLAPolicy{ProjectID: projectID, Priority: req.Priority}
+	}
+	policy.ResponseTarget = req.ResponseTarget
+	policy.ResolutionTarget = req.ResolutionTarget
+
+	if err := policy.Validate(); err != nil {
+		return models.SLAPolicy{}, invalidInput(err)
+	}
+
+	if create {
+		err = repositories.CreateSLAPolicy(ctx, policy)
+	} else {
+		err = repositories.UpdateSLAPolicy(ctx, policy)
+	}
+	if errors.Is(err, repositories.ErrNotUpdated) {
+		return models.SLAPolicy{}, ErrSLANotFound
+	}
+	if err != nil {
+		return models.SLAPolicy{}, err
+	}
+
+	resyncProjectSLAs(ctx, projectID)
+	return *policy, nil
+}
+
+// DeleteSLAPolicy removes the SLA targets of a priority (project admins only)
+func DeleteSLAPolicy(ctx context.Context, projectID, policyID, actorID uuid.UUID, actorRole models.UserRole) error {
+	if err := requireProjectAdmin(ctx, projectID, actorID, actorRole); err != nil {
+		return err
+	}
+
+	policy, err := repositories.GetSLAPolicyByID(ctx, policyID)
+	if err != nil {
+		return err
+	}
+	if policy == nil || policy.ProjectID != projectID {
+		return ErrSLANotFound
+	}
+
+	if err := repositories.DeleteSLAPolicy(ctx, policyID); err != nil {
+		return err
+	}
+
+	resyncProjectSLAs(ctx, projectID)
+	return nil
+}
+
+// GetTaskSLAStatus computes the SLA status of a task
+func GetTaskSLAStatus(ctx context.Context, taskID, userID uuid.UUID, role models.UserRole) (models.TaskSLAStatus, error) {
+	task, err := getVisibleTask(ctx, taskID, userID, role)
+	if err != nil {
+		return models.TaskSLAStatus{}, err
+	}
+
+	// Clocks are created lazily for tasks that have not changed since their policy was set
+	sla, err := repositories.GetTaskSLA(ctx, taskID)
+	if err != nil {
+		return models.TaskSLAStatus{}, err
+	}
+	if sla == nil {
+		if sla, err = syncTaskSLA(ctx, taskID); err != nil {
+			return models.TaskSLAStatus{}, err
+		}
+	}
+	if sla == nil {
+		return models.TaskSLAStatus{}, ErrSLANotFound
+	}
+
+	policy, err := repositories.GetSLAPolicyByID(ctx, sla.PolicyID)
+	if err != nil {
+		return models.TaskSLAStatus{}, err
+	}
+	if policy == nil {
+		return models.TaskSLAStatus{}, ErrSLANotFound
+	}
+
+	hours, err := businessHoursOf(ctx, sla.ProjectID)
+	if err != nil {
+		return models.TaskSLAStatus{}, err
+	}
+
+	return computeSLAStatus(*task, *sla, *policy, hours, time.Now()), nil
+}
+
+// GetProjectSLAStatuses computes the SLA status of every open task of a project
+// that has SLA targets, optionally only those with the given status
+func GetProjectSLAStatuses(ctx context.Context, projectID, userID uuid.UUID, role models.UserRole, status models.SLAStatus) ([]models.TaskSLAStatus, error) {
+	if _, err := GetProject(ctx, projectID, userID, role); err != nil {
+		return nil, err
+	}
+
+	slas, err := repositories.GetOpenTaskSLAs(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := repositories.GetSLAPoliciesByProjectID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	policyByID := make(map[uuid.UUID]models.SLAPolicy, len(policies))
+	for _, policy := range policies {
+		policyByID[policy.ID] = policy
+	}
+
+	hours, err := businessHoursOf(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	statuses := make([]models.TaskSLAStatus, 0, len(slas))
+	for _, sla := range slas {
+		policy, ok := policyByID[sla.PolicyID]
+		if !ok {
+			continue
+		}
+
+		task, err := repositories.GetTaskByID(ctx, sla.TaskID)
+		if err != nil {
+			continue // Deleted in the meantime
+		}
+
+		taskStatus := computeSLAStatus(*task, sla, policy, hours, now)
+		if status == "" || taskStatus.Status == status {
+			statuses = append(statuses, taskStatus)
+		}
+	}
+	return statuses, nil
+}
+
+// handleSLAEvent updates the SLA clock of a task whenever the task changes
+func handleSLAEvent(ctx context.Context, event models.Event) {
+	if event.TaskID == nil {
+		return
+	}
+
+	switch event.Type {
+	case models.EventTaskDeleted:
+		if err := repositories.DeleteTaskSLA(ctx, *event.TaskID); err != nil {
+			log.Printf("❌ Failed to remove SLA of task %s: %v", *event.TaskID, err)
+		}
+	case models.EventTaskCreated, models.EventTaskUpdated, models.EventTaskStatusChanged, models.EventTaskEscalated:
+		if _, err := syncTaskSLA(ctx, *event.TaskID); err != nil {
+			log.Printf("❌ Failed to update SLA of task %s: %v", *event.TaskID, err)
+		}
+	}
+}
+
+// syncTaskSLA recalculates the SLA clock of a task from its policy, business hours
+// and status history. It returns nil if no SLA applies to the task.
+func syncTaskSLA(ctx context.Context, taskID uuid.UUID) (*models.TaskSLA, error) {
+	task, err := repositories.GetTaskByID(ctx, taskID)
+	if err != nil || task.ProjectID == nil {
+		return nil, repositories.DeleteTaskSLA(ctx, taskID)
+	}
+
+	policy, err := repositories.GetSLAPolicy(ctx, *task.ProjectID, task.Priority)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, repositories.DeleteTaskSLA(ctx, taskID)
+	}
+
+	hours, err := businessHoursOf(ctx, *task.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	transitions, err := repositories.GetTaskStatusTransitions(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	sla, err := repositories.GetTaskSLA(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	create := sla == nil
+	if create {
+		sla = &models.TaskSLA{TaskID: taskID}
+	}
+	sla.ProjectID = *task.ProjectID
+	sla.PolicyID = policy.ID
+
+	responseDue := slaDue(task.CreatedAt, policy.ResponseTarget, hours)
+	if !sameTime(responseDue, sla.ResponseDue) {
+		sla.ResponseBreachedAt = nil // A new target is judged again
+	}
+	sla.ResponseDue = responseDue
+
+	resolutionDue := slaDue(task.CreatedAt, policy.ResolutionTarget, hours)
+	if !sameTime(resolutionDue, sla.ResolutionDue) {
+		sla.ResolutionBreachedAt = nil
+	}
+	sla.ResolutionDue = resolutionDue
+
+	sla.RespondedAt, sla.ResolvedAt = slaCompletion(*task, transitions)
+
+	if create {
+		err = repositories.CreateTaskSLA(ctx, sla)
+	} else {
+		err = repositories.UpdateTaskSLA(ctx, sla)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sla, nil
+}
+
+// resyncProjectSLAs recalculates the SLA clocks of every task of a project
+// after its policies or business hours changed
+func resyncProjectSLAs(ctx context.Context, projectID uuid.UUID) {
+	tasks, err := repositories.FindTasks(ctx, models.TaskFilter{ProjectID: &projectID})
+	if err != nil {
+		log.Printf("❌ Failed to load tasks of project %s: %v", projectID, err)
+		return
+	}
+
+	for _, task := range tasks {
+		if _, err := syncTaskSLA(ctx, task.ID); err != nil {
+			log.Printf("❌ Failed to update SLA of task %s: %v", task.ID, err)
+		}
+	}
+}
+
+// reportSLABreaches records and publishes every SLA target that was missed
+func reportSLABreaches(ctx context.Context) {
+	now := time.Now()
+	slas, err := repositories.FindBreachedTaskSLAs(ctx, now, slaBreachBatch)
+	if err != nil {
+		log.Printf("❌ Failed to look for SLA breaches: %v", err)
+		return
+	}
+
+	for _, sla := range slas {
+		if breached(sla.ResponseDue, sla.RespondedAt, sla.ResponseBreachedAt, now) {
+			reportSLABreach(ctx, sla.TaskID, "response", *sla.ResponseDue, now)
+		}
+		if breached(sla.ResolutionDue, sla.ResolvedAt, sla.ResolutionBreachedAt, now) {
+			reportSLABreach(ctx, sla.TaskID, "resolution", *sla.ResolutionDue, now)
+		}
+	}
+}
+
+// reportSLABreach marks a target as missed and publishes the breach once
+func reportSLABreach(ctx context.Context, taskID uuid.UUID, target string, due, now time.Time) {
+	marked, err := repositories.MarkTaskSLABreached(ctx, taskID, target, now)
+	if err != nil {
+		log.Printf("❌ Failed to record SLA breach of task %s: %v", taskID, err)
+		return
+	}
+	if !marked {
+		return
+	}
+
+	task, err := repositories.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return
+	}
+	publishTaskEvent(ctx, models.EventTaskSLABreached, *task, uuid.Nil, models.SLABreach{
+		Task:   taskEventData(ctx, *task, uuid.Nil),
+		Target: target,
+		Due:    due,
+	})
+}
+
+// computeSLAStatus evaluates every target of a task's SLA clock
+func computeSLAStatus(task models.Task, sla models.TaskSLA, policy models.SLAPolicy, hours models.BusinessHours, now time.Time) models.TaskSLAStatus {
+	status := models.TaskSLAStatus{TaskID: task.ID, Title: task.Title, Priority: task.Priority}
+
+	if sla.ResponseDue != nil {
+		status.Response = targetStatus(policy.ResponseTarget, *sla.ResponseDue, sla.RespondedAt, hours, now)
+	}
+	if sla.ResolutionDue != nil {
+		status.Resolution = targetStatus(policy.ResolutionTarget, *sla.ResolutionDue, sla.ResolvedAt, hours, now)
+	}
+
+	// The overall status is the worst of the targets
+	status.Status = models.SLAMet
+	for _, target := range []*models.SLATargetStatus{status.Response, status.Resolution} {
+		if target == nil {
+			continue
+		}
+		switch {
+		case target.Status == models.SLABreached:
+			status.Status = models.SLABreached
+		case target.Status == models.SLAAtRisk && status.Status != models.SLABreached:
+			status.Status = models.SLAAtRisk
+		case target.Status == models.SLAOnTrack && status.Status == models.SLAMet:
+			status.Status = models.SLAOnTrack
+		}
+	}
+	return status
+}
+
+// targetStatus evaluates a single SLA target
+func targetStatus(target string, due time.Time, completedAt *time.Time, hours models.BusinessHours, now time.Time) *models.SLATargetStatus {
+	status := &models.SLATargetStatus{Target: target, Due: due, CompletedAt: completedAt}
+
+	if completedAt != nil {
+		status.RemainingMinutes = int64(hours.Between(*completedAt, due).Minutes())
+		if completedAt.After(due) {
+			status.Status = models.SLABreached
+		} else {
+			status.Status = models.SLAMet
+		}
+		return status
+	}
+
+	remaining := hours.Between(now, due)
+	status.RemainingMinutes = int64(remaining.Minutes())
+
+	total, _ := models.ParseSLATarget(target, hours.DayLength())
+	switch {
+	case now.After(due):
+		status.Status = models.SLABreached
+	case remaining < total/slaAtRiskFraction:
+		status.Status = models.SLAAtRisk
+	default:
+		status.Status = models.SLAOnTrack
+	}
+	return status
+}
+
+// slaCompletion finds when a task first left Pending and when it was last
+// completed, if it is done. Tasks without recorded history use their last update.
+func slaCompletion(task models.Task, transitions []models.StatusTransition) (*time.Time, *time.Time) {
+	var respondedAt, resolvedAt *time.Time
+
+	for i := range transitions {
+		if transitions[i].To != models.StatusPending {
+			respondedAt = &transitions[i].At
+			break
+		}
+	}
+	if respondedAt == nil && task.Status != models.StatusPending {
+		respondedAt = &task.UpdatedAt
+	}
+
+	if task.Status == models.StatusDone {
+		resolvedAt = &task.UpdatedAt
+		for i := len(transitions) - 1; i >= 0; i-- {
+			if transitions[i].To == models.StatusDone {
+				resolvedAt = &transitions[i].At
+				break
+			}
+		}
+	}
+
+	return respondedAt, resolvedAt
+}
+
+// slaDue returns when a target is due for a task created at start, or nil without a target
+func slaDue(start time.Time, target string, hours models.BusinessHours) *time.Time {
+	if target == "" {
+		return nil
+	}
+
+	d, err := models.ParseSLATarget(target, hours.DayLength())
+	if err != nil {
+		return nil
+	}
+
+	due := hours.Add(start, d)
+	return &due
+}
+
+// businessHoursOf loads the business hours of a project, falling back to the defaults
+func businessHoursOf(ctx context.Context, projectID uuid.UUID) (models.BusinessHours, error) {
+	hours, err := repositories.GetBusinessHours(ctx, projectID)
+	if err != nil {
+		return models.BusinessHours{}, err
+	}
+	if hours == nil {
+		return models.DefaultBusinessHours(projectID), nil
+	}
+	return *hours, nil
+}
+
+// breached checks if a target was missed and not reported yet
+func breached(due, completedAt, reportedAt *time.Time, now time.Time) bool {
+	if due == nil || reportedAt != nil {
+		return false
+	}
+	if completedAt != nil {
+		return completedAt.After(*due)
+	}
+	return now.After(*due)
+}
+
+// sameTime checks if two optional times are equal
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
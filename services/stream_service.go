@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/config"
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	streamBufferSize       = 64              // Events buffered per client before it is disconnected
+	streamReplayLimit      = 500             // Maximum events replayed for a Last-Event-ID
+	streamVisibilityTTL    = time.Minute     // How long a client's project memberships are cached
+	streamReconnectBackoff = 5 * time.Second // Delay before reconnecting the LISTEN connection
+	streamCatchUpBatchSize = 100             // Events loaded at a time after a reconnect
+	streamGapTTL           = time.Minute     // How long a skipped event ID is waited for
+	streamMaxGaps          = 1000            // Skipped event IDs waited for at most
+)
+
+// StreamSubscription receives the events a single stream client is allowed to see
+type StreamSubscription struct {
+	Events chan models.Event
+
+	organizationID uuid.UUID
+	userID         uuid.UUID
+	role           models.UserRole
+	projectID      *uuid.UUID // Only events of this project, if set
+
+	mu              sync.Mutex
+	visibleProjects map[uuid.UUID]bool
+	refreshedAt     time.Time
+}
+
+// streamHub fans events out to the stream clients connected to this instance.
+// Event IDs are taken when an event is inserted, but transactions can commit in
+// another order, so IDs skipped below the last broadcast event are kept as gaps
+// and still broadcast when their event arrives. IDs of rolled back transactions
+// are never used, so gaps expire after a while.
+type streamHub struct {
+	mu            sync.Mutex
+	subscriptions map[*StreamSubscription]struct{}
+	lastEventID   int64
+	gaps          map[int64]time.Time // Skipped event IDs, with when they were skipped
+}
+
+var hub = &streamHub{
+	subscriptions: make(map[*StreamSubscription]struct{}),
+	gaps:          make(map[int64]time.Time),
+}
+
+// StartEventListener listens for events stored by any backend instance and
+// forwards them to the stream clients connected to this one
+func StartEventListener() {
+	go func() {
+		for {
+			if err := listenForEvents(repositories.AllOrganizations(context.Background())); err != nil {
+				log.Printf("❌ Event listener disconnected: %v", err)
+			}
+			time.Sleep(streamReconnectBackoff)
+		}
+	}()
+}
+
+// SubscribeStream registers a stream client, optionally for the events of a
+// single project the user can see
+func SubscribeStream(ctx context.Context, userID uuid.UUID, role models.UserRole, projectID *uuid.UUID) (*StreamSubscription, error) {
+	if projectID != nil {
+		if _, err := GetProject(ctx, *projectID, userID, role); err != nil {
+			return nil, err
+		}
+	}
+
+	organizationID, ok := repositories.OrganizationFromContext(ctx)
+	if !ok {
+		return nil, repositories.ErrNoOrganization
+	}
+
+	sub := &StreamSubscription{
+		Events:         make(chan models.Event, streamBufferSize),
+		organizationID: organizationID,
+		userID:         userID,
+		role:           role,
+		projectID:      projectID,
+	}
+
+	hub.mu.Lock()
+	hub.subscriptions[sub] = struct{}{}
+	hub.mu.Unlock()
+
+	return sub, nil
+}
+
+// UnsubscribeStream removes a stream client
+func UnsubscribeStream(sub *StreamSubscription) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if _, ok := hub.subscriptions[sub]; ok {
+		delete(hub.subscriptions, sub)
+		close(sub.Events)
+	}
+}
+
+// GetMissedEvents returns the events after lastEventID the subscriber may see,
+// so that a reconnecting client can resume where it stopped
+func GetMissedEvents(ctx context.Context, sub *StreamSubscription, lastEventID int64) ([]models.Event, error) {
+	events, err := repositories.GetEventsAfter(ctx, lastEventID, streamReplayLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make([]models.Event, 0, len(events))
+	for _, event := range events {
+		if sub.CanSee(event) {
+			visible = append(visible, event)
+		}
+	}
+	return visible, nil
+}
+
+// CanSee checks if the subscriber is allowed to receive the event
+func (s *StreamSubscription) CanSee(event models.Event) bool {
+	if event.OrganizationID != s.organizationID {
+		return false
+	}
+	if s.projectID != nil && (event.ProjectID == nil || *event.ProjectID != *s.projectID) {
+		return false
+	}
+
+	// Tasks outside of a project are visible to every user
+	if event.ProjectID == nil || s.role.Can(models.PermProjectViewAny) {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.visibleProjects == nil || time.Since(s.refreshedAt) > streamVisibilityTTL {
+		ids, err := repositories.GetProjectIDsByUserID(repositories.WithOrganization(context.Background(), s.organizationID), s.userID)
+		if err != nil {
+			log.Printf("❌ Failed to load projects of user %s: %v", s.userID, err)
+			return false
+		}
+
+		s.visibleProjects = make(map[uuid.UUID]bool, len(ids))
+		for _, id := range ids {
+			s.visibleProjects[id] = true
+		}
+		s.refreshedAt = time.Now()
+	}
+
+	return s.visibleProjects[*event.ProjectID]
+}
+
+// listenForEvents holds a dedicated connection LISTENing for new event IDs until it fails
+func listenForEvents(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, config.DSN)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+repositories.EventsChannel); err != nil {
+		return err
+	}
+	log.Println("✅ Listening for realtime events")
+
+	// Deliver whatever was stored while the listener was disconnected
+	hub.catchUp(ctx)
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(notification.Payload, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		hub.mu.Lock()
+		missed := hub.lastEventID != 0 && id > hub.lastEventID+1
+		hub.mu.Unlock()
+
+		// A notification was missed, or an earlier event has not committed yet;
+		// loading everything after the oldest gap picks up what is already stored
+		if missed {
+			hub.catchUp(ctx)
+			continue
+		}
+
+		event, err := repositories.GetEventByID(ctx, id)
+		if err != nil || event == nil {
+			continue
+		}
+		hub.broadcast(*event)
+	}
+}
+
+// catchUp broadcasts every stored event that was not broadcast yet, starting at
+// the oldest gap
+func (h *streamHub) catchUp(ctx context.Context) {
+	h.mu.Lock()
+	h.expireGaps()
+	lastEventID := h.lastEventID
+	for id := range h.gaps {
+		if id <= lastEventID {
+			lastEventID = id - 1
+		}
+	}
+	h.mu.Unlock()
+
+	// Nothing was broadcast yet, so clients use Last-Event-ID to replay instead
+	if lastEventID == 0 {
+		return
+	}
+
+	for {
+		events, err := repositories.GetEventsAfter(ctx, lastEventID, streamCatchUpBatchSize)
+		if err != nil {
+			log.Printf("❌ Failed to load missed events: %v", err)
+			return
+		}
+
+		for _, event := range events {
+			h.broadcast(event)
+			lastEventID = event.ID
+		}
+
+		if len(events) < streamCatchUpBatchSize {
+			return
+		}
+	}
+}
+
+// broadcast sends an event to every subscriber that may see it, once. Subscribers
+// that can't keep up are disconnected and resume with Last-Event-ID.
+func (h *streamHub) broadcast(event models.Event) {
+	h.mu.Lock()
+	if event.ID <= h.lastEventID {
+		// Only events of skipped IDs are new; everything else was broadcast already
+		if _, ok := h.gaps[event.ID]; !ok {
+			h.mu.Unlock()
+			return
+		}
+		delete(h.gaps, event.ID)
+	} else {
+		if h.lastEventID != 0 {
+			now := time.Now()
+			for id := max(h.lastEventID+1, event.ID-streamMaxGaps); id < event.ID; id++ {
+				h.gaps[id] = now
+			}
+		}
+		h.lastEventID = event.ID
+		h.expireGaps()
+	}
+
+	subscriptions := make([]*StreamSubscription, 0, len(h.subscriptions))
+	for sub := range h.subscriptions {
+		subscriptions = append(subscriptions, sub)
+	}
+	h.mu.Unlock()
+
+	for _, sub := range subscriptions {
+		if !sub.CanSee(event) {
+			continue
+		}
+
+		h.mu.Lock()
+		if _, ok := h.subscriptions[sub]; ok {
+			select {
+			case sub.Events <- event:
+			default:
+				delete(h.subscriptions, sub)
+				close(sub.Events)
+			}
+		}
+		h.mu.Unlock()
+	}
+}
+
+// expireGaps stops waiting for skipped event IDs that are too old, or too many.
+// The caller holds h.mu.
+func (h *streamHub) expireGaps() {
+	cutoff := time.Now().Add(-streamGapTTL)
+	for id, skippedAt := range h.gaps {
+		if skippedAt.Before(cutoff) || id <= h.lastEventID-streamMaxGaps {
+			delete(h.gaps, id)
+		}
+	}
+}
@@ -1,41 +1,96 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
 
 	"github.com/azka-art/taskwise-backend/config"
 	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/azka-art/taskwise-backend/utils"
 	"github.com/google/uuid"
 )
 
+// maxTaskRankLength is the rank length at which a status column is rebalanced
+const maxTaskRankLength = 16
+
 // CreateTask creates a new task
-func CreateTask(task models.Task) (models.Task, error) {
+func CreateTask(ctx context.Context, task models.Task) (models.Task, error) {
 	// Ensure task ID is generated if not provided
 	if task.ID == uuid.Nil {
 		task.ID = uuid.New()
 	}
 
-	if err := config.DB.Create(&task).Error; err != nil {
+	// Only project members can add tasks to a project
+	if task.ProjectID != nil {
+		member, err := repositories.GetProjectMember(ctx, *task.ProjectID, task.CreatedBy)
+		if err != nil {
+			return models.Task{}, err
+		}
+		if member == nil {
+			return models.Task{}, ErrForbidden
+		}
+	}
+
+	return insertTask(ctx, task)
+}
+
+// insertTask stores a new task at the end of its status column. Callers check
+// that the user may add the task.
+func insertTask(ctx context.Context, task models.Task) (models.Task, error) {
+	if err := validateAssignee(ctx, task); err != nil {
+		return models.Task{}, err
+	}
+
+	// New tasks go to the end of their status column
+	task.SetDefaults()
+	rank, err := nextTaskRank(ctx, task.ProjectID, task.Status)
+	if err != nil {
 		return models.Task{}, err
 	}
+	task.Rank = rank
+
+	if err := config.DB.WithContext(ctx).Create(&task).Error; err != nil {
+		return models.Task{}, err
+	}
+
+	publishTaskEvent(ctx, models.EventTaskCreated, task, task.CreatedBy, taskEventData(ctx, task, task.CreatedBy))
 	return task, nil
 }
 
 // GetAllTasks retrieves all tasks
-func GetAllTasks() ([]models.Task, error) {
+func GetAllTasks(ctx context.Context) ([]models.Task, error) {
 	var tasks []models.Task
-	if err := config.DB.Preload("Comments").Find(&tasks).Error; err != nil {
+	if err := config.DB.WithContext(ctx).Preload("Comments").Find(&tasks).Error; err != nil {
 		return nil, err
 	}
 	return tasks, nil
 }
 
+// GetTasks retrieves the tasks matching a filter in the given sort order
+func GetTasks(ctx context.Context, filter models.TaskFilter, sort []models.TaskSort) ([]models.Task, error) {
+	return repositories.FindSortedTasks(ctx, filter, sort)
+}
+
 // ✅ Fix: UpdateTask now accepts `uuid.UUID`
-func UpdateTask(id uuid.UUID, updatedTask models.Task) (models.Task, error) {
+func UpdateTask(ctx context.Context, id uuid.UUID, updatedTask models.UpdateTaskRequest, actorID uuid.UUID, role models.UserRole) (models.Task, error) {
+	if !role.Can(models.PermTaskUpdate) {
+		return models.Task{}, ErrForbidden
+	}
+	if err := updatedTask.Validate(); err != nil {
+		return models.Task{}, invalidInput(err)
+	}
+	if _, err := getVisibleTask(ctx, id, actorID, role); err != nil {
+		return models.Task{}, err
+	}
+
 	var task models.Task
-	if err := config.DB.First(&task, "id = ?", id).Error; err != nil {
-		return models.Task{}, errors.New("task not found")
+	if err := config.DB.WithContext(ctx).First(&task, "id = ?", id).Error; err != nil {
+		return models.Task{}, ErrTaskNotFound
 	}
+	previousStatus := task.Status
+	previousAssignee := task.AssigneeID
 
 	// Update fields only if new values are provided
 	if updatedTask.Title != "" {
@@ -47,24 +102,350 @@ func UpdateTask(id uuid.UUID, updatedTask models.Task) (models.Task, error) {
 	if updatedTask.Priority != "" {
 		task.Priority = updatedTask.Priority
 	}
-	if updatedTask.Status != "" {
+	if updatedTask.Status != "" && updatedTask.Status != task.Status {
 		task.Status = updatedTask.Status
+
+		// A task moved to another column through an update goes to its end
+		rank, err := nextTaskRank(ctx, task.ProjectID, task.Status)
+		if err != nil {
+			return models.Task{}, err
+		}
+		task.Rank = rank
 	}
 	if updatedTask.Deadline != nil {
 		task.Deadline = updatedTask.Deadline
 	}
+	if updatedTask.AssigneeID != nil {
+		task.AssigneeID = updatedTask.AssigneeID
+		if err := validateAssignee(ctx, task); err != nil {
+			return models.Task{}, err
+		}
+	}
 
 	// Save updated task
-	if err := config.DB.Save(&task).Error; err != nil {
+	if err := repositories.UpdateTask(ctx, &task); err != nil {
+		if errors.Is(err, repositories.ErrNotUpdated) {
+			return models.Task{}, ErrTaskNotFound
+		}
+		return models.Task{}, err
+	}
+
+	// Labels are only replaced when the update contains them
+	if updatedTask.Labels != nil {
+		labels, err := repositories.ReplaceTaskLabels(ctx, task.ID, updatedTask.Labels)
+		if err != nil {
+			return models.Task{}, err
+		}
+		task.Labels = labels
+	}
+
+	publishTaskEvent(ctx, models.EventTaskUpdated, task, actorID, taskEventData(ctx, task, actorID))
+	if task.Status != previousStatus {
+		publishTaskEvent(ctx, models.EventTaskStatusChanged, task, actorID, models.StatusChange{
+			Task: taskEventData(ctx, task, actorID),
+			From: previousStatus,
+			To:   task.Status,
+		})
+	}
+	if !sameUUID(task.AssigneeID, previousAssignee) {
+		publishTaskEvent(ctx, models.EventTaskAssigned, task, actorID, models.AssigneeChange{
+			Task: taskEventData(ctx, task, actorID),
+			From: previousAssignee,
+			To:   task.AssigneeID,
+		})
+	}
+	return task, nil
+}
+
+// DeleteTask deletes a task. Tasks can be deleted by their creator, by admins of
+// their project and by users who may delete any task.
+func DeleteTask(ctx context.Context, id uuid.UUID, actorID uuid.UUID, role models.UserRole) error {
+	visible, err := getVisibleTask(ctx, id, actorID, role)
+	if err != nil {
+		return err
+	}
+
+	if visible.CreatedBy != actorID && !role.Can(models.PermTaskDeleteAny) {
+		canManage := false
+		if visible.ProjectID != nil {
+			if canManage, err = CanManageProject(ctx, *visible.ProjectID, actorID, role); err != nil {
+				return err
+			}
+		}
+		if !canManage {
+			return ErrForbidden
+		}
+	}
+
+	var task models.Task
+	if err := config.DB.WithContext(ctx).First(&task, "id = ?", id).Error; err != nil {
+		return ErrTaskNotFound
+	}
+
+	if err := config.DB.WithContext(ctx).Delete(&task).Error; err != nil {
+		return err
+	}
+
+	publishTaskEvent(ctx, models.EventTaskDeleted, task, actorID, taskEventData(ctx, task, actorID))
+	return nil
+}
+
+// MoveTask places a task between two neighbours of a status column, changing its
+// status when it is moved to another column
+func MoveTask(ctx context.Context, id uuid.UUID, req models.MoveTaskRequest, userID uuid.UUID, role models.UserRole) (models.Task, error) {
+	if !role.Can(models.PermTaskMove) {
+		return models.Task{}, ErrForbidden
+	}
+	task, err := getVisibleTask(ctx, id, userID, role)
+	if err != nil {
+		return models.Task{}, err
+	}
+	previousStatus := task.Status
+
+	status := req.Status
+	if status == "" {
+		status = task.Status
+	}
+	if status != models.StatusPending && status != models.StatusInProgress && status != models.StatusDone {
+		return models.Task{}, invalidInput(errors.New("invalid status value"))
+	}
+
+	rank, err := rankBetweenNeighbours(ctx, task, status, req)
+	if errors.Is(err, utils.ErrInvalidRank) {
+		// Neighbours without a rank, or with no room between them, are fixed by
+		// rebalancing the column once
+		if err := repositories.RebalanceTaskRanks(ctx, task.ProjectID, status); err != nil {
+			return models.Task{}, err
+		}
+		rank, err = rankBetweenNeighbours(ctx, task, status, req)
+		if errors.Is(err, utils.ErrInvalidRank) {
+			return models.Task{}, invalidInput(errors.New("before_id must come before after_id"))
+		}
+	}
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	if err := repositories.UpdateTaskPosition(ctx, task.ID, status, rank); err != nil {
+		return models.Task{}, err
+	}
+
+	// Ranks grow by a digit whenever neighbours leave no room, so long ones are spread out again
+	if len(rank) > maxTaskRankLength {
+		if err := repositories.RebalanceTaskRanks(ctx, task.ProjectID, status); err != nil {
+			return models.Task{}, err
+		}
+	}
+
+	task, err = repositories.GetTaskByID(ctx, task.ID)
+	if err != nil {
 		return models.Task{}, err
 	}
+
+	publishTaskEvent(ctx, models.EventTaskUpdated, *task, userID, taskEventData(ctx, *task, userID))
+	if task.Status != previousStatus {
+		publishTaskEvent(ctx, models.EventTaskStatusChanged, *task, userID, models.StatusChange{
+			Task: taskEventData(ctx, *task, userID),
+			From: previousStatus,
+			To:   task.Status,
+		})
+	}
+	return *task, nil
+}
+
+// DuplicateTask copies a task with the selected parts. The copy goes to the end
+// of the Pending column and links back to the original. Whoever may see a task
+// may duplicate it, like they may see its copy.
+func DuplicateTask(ctx context.Context, id uuid.UUID, req models.DuplicateTaskRequest, userID uuid.UUID, role models.UserRole) (models.Task, error) {
+	original, err := getVisibleTask(ctx, id, userID, role)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	duplicate := newDuplicate(*original, req, userID)
+
+	// The assignee is only kept while they may still be assigned to the task
+	if req.IncludeAssignee && original.AssigneeID != nil {
+		duplicate.AssigneeID = original.AssigneeID
+		problem, err := assigneeProblem(ctx, duplicate)
+		if err != nil {
+			return models.Task{}, err
+		}
+		if problem != "" {
+			duplicate.AssigneeID = nil
+		}
+	}
+
+	if req.IncludeLabels {
+		labels, err := repositories.GetTaskLabels(ctx, original.ID)
+		if err != nil {
+			return models.Task{}, err
+		}
+		for _, label := range labels {
+			duplicate.Labels = append(duplicate.Labels, models.TaskLabel{Name: label.Name})
+		}
+	}
+
+	return insertTask(ctx, duplicate)
+}
+
+// newDuplicate copies the fields of a task selected by a duplicate request.
+// Comments and other records of the original stay with it.
+func newDuplicate(original models.Task, req models.DuplicateTaskRequest, userID uuid.UUID) models.Task {
+	duplicate := models.Task{
+		Title:          req.Title,
+		Priority:       original.Priority,
+		Status:         models.StatusPending,
+		ProjectID:      original.ProjectID,
+		CreatedBy:      userID,
+		DuplicatedFrom: &original.ID,
+	}
+	if duplicate.Title == "" {
+		duplicate.Title = "Copy of " + original.Title
+	}
+	if req.IncludeDescription {
+		duplicate.Description = original.Description
+	}
+	if req.IncludeDeadline && original.Deadline != nil {
+		deadline := original.Deadline.AddDate(0, 0, req.DeadlineOffsetDays)
+		duplicate.Deadline = &deadline
+	}
+	return duplicate
+}
+
+// rankBetweenNeighbours computes the rank of a task moved between the requested
+// neighbours. With a single neighbour the other one is the task next to it, and
+// without any the task goes to the end of the column.
+func rankBetweenNeighbours(ctx context.Context, task *models.Task, status models.Status, req models.MoveTaskRequest) (string, error) {
+	before, err := getNeighbourTask(ctx, req.BeforeID, "before_id", task, status)
+	if err != nil {
+		return "", err
+	}
+	after, err := getNeighbourTask(ctx, req.AfterID, "after_id", task, status)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case before == nil && after == nil:
+		before, err = repositories.GetAdjacentTask(ctx, task.ProjectID, status, "", true, task.ID)
+	case after == nil:
+		after, err = repositories.GetAdjacentTask(ctx, task.ProjectID, status, before.Rank, false, task.ID)
+	case before == nil:
+		before, err = repositories.GetAdjacentTask(ctx, task.ProjectID, status, after.Rank, true, task.ID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var prev, next string
+	if before != nil {
+		prev = before.Rank
+		if prev == "" {
+			return "", utils.ErrInvalidRank
+		}
+	}
+	if after != nil {
+		next = after.Rank
+		if next == "" {
+			return "", utils.ErrInvalidRank
+		}
+	}
+	return utils.RankBetween(prev, next)
+}
+
+// getNeighbourTask loads a neighbour of a move, which must be in the target column
+func getNeighbourTask(ctx context.Context, id *uuid.UUID, field string, task *models.Task, status models.Status) (*models.Task, error) {
+	if id == nil {
+		return nil, nil
+	}
+	if *id == task.ID {
+		return nil, invalidInput(fmt.Errorf("%s can't be the moved task", field))
+	}
+
+	neighbour, err := repositories.GetTaskByID(ctx, *id)
+	if err != nil {
+		return nil, invalidInput(fmt.Errorf("%s: task not found", field))
+	}
+	if neighbour.Status != status || !sameUUID(neighbour.ProjectID, task.ProjectID) {
+		return nil, invalidInput(fmt.Errorf("%s is not in the target column", field))
+	}
+	return neighbour, nil
+}
+
+// getVisibleTask loads a task the user is allowed to see
+func getVisibleTask(ctx context.Context, id, userID uuid.UUID, role models.UserRole) (*models.Task, error) {
+	task, err := repositories.GetTaskByID(ctx, id)
+	if err != nil {
+		return nil, ErrTaskNotFound
+	}
+
+	if task.ProjectID != nil {
+		canView, err := CanViewProject(ctx, *task.ProjectID, userID, role)
+		if err != nil {
+			return nil, err
+		}
+		if !canView {
+			return nil, ErrTaskNotFound
+		}
+	}
 	return task, nil
 }
 
-// ✅ Fix: DeleteTask now accepts `uuid.UUID`
-func DeleteTask(id uuid.UUID) error {
-	if err := config.DB.Delete(&models.Task{}, "id = ?", id).Error; err != nil {
+// nextTaskRank returns the rank that puts a task at the end of a status column
+func nextTaskRank(ctx context.Context, projectID *uuid.UUID, status models.Status) (string, error) {
+	last, err := repositories.GetLastTaskRank(ctx, projectID, status)
+	if err != nil {
+		return "", err
+	}
+	return utils.RankBetween(last, "")
+}
+
+// sameUUID checks if two optional IDs are equal
+func sameUUID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// validateAssignee makes sure a task is assigned to an existing user who,
+// for tasks in a project, is a member of that project
+func validateAssignee(ctx context.Context, task models.Task) error {
+	problem, err := assigneeProblem(ctx, task)
+	if err != nil {
 		return err
 	}
+	if problem != "" {
+		return invalidInput(errors.New(problem))
+	}
 	return nil
 }
+
+// assigneeProblem describes why the assignee of a task is not acceptable,
+// or returns an empty string if it is
+func assigneeProblem(ctx context.Context, task models.Task) (string, error) {
+	if task.AssigneeID == nil {
+		return "", nil
+	}
+
+	if task.ProjectID != nil {
+		member, err := repositories.GetProjectMember(ctx, *task.ProjectID, *task.AssigneeID)
+		if err != nil {
+			return "", err
+		}
+		if member == nil {
+			return "assignee is not a member of the project", nil
+		}
+		return "", nil
+	}
+
+	user, err := repositories.GetUserByID(ctx, *task.AssigneeID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "assignee not found", nil
+	}
+	return "", nil
+}
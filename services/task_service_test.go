@@ -0,0 +1,75 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/google/uuid"
+)
+
+func TestNewDuplicateCopiesSelectedFieldsOnly(t *testing.T) {
+	projectID := uuid.New()
+	assigneeID := uuid.New()
+	deadline := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	original := models.Task{
+		ID:          uuid.New(),
+		Title:       "Write report",
+		Description: "Quarterly numbers",
+		Priority:    models.PriorityHigh,
+		Status:      models.StatusDone,
+		Deadline:    &deadline,
+		ProjectID:   &projectID,
+		AssigneeID:  &assigneeID,
+		Rank:        "m",
+		CreatedBy:   uuid.New(),
+		Comments:    []models.Comment{{ID: uuid.New(), Content: "Done"}},
+		Labels:      []models.TaskLabel{{Name: "finance"}},
+	}
+	userID := uuid.New()
+
+	duplicate := newDuplicate(original, models.DuplicateTaskRequest{}, userID)
+	if duplicate.Title != "Copy of Write report" || duplicate.Priority != models.PriorityHigh || duplicate.Status != models.StatusPending {
+		t.Errorf("duplicate = %q %s %s, want the default title, the priority and Pending", duplicate.Title, duplicate.Priority, duplicate.Status)
+	}
+	if duplicate.ProjectID == nil || *duplicate.ProjectID != projectID || duplicate.CreatedBy != userID {
+		t.Errorf("duplicate is in project %v by %s, want %s by %s", duplicate.ProjectID, duplicate.CreatedBy, projectID, userID)
+	}
+	if duplicate.DuplicatedFrom == nil || *duplicate.DuplicatedFrom != original.ID {
+		t.Errorf("duplicated_from = %v, want %s", duplicate.DuplicatedFrom, original.ID)
+	}
+	if duplicate.Description != "" || duplicate.Deadline != nil || duplicate.AssigneeID != nil || duplicate.Rank != "" {
+		t.Error("duplicate copied fields that were not selected")
+	}
+	// Child records stay with the original
+	if len(duplicate.Comments) != 0 || len(duplicate.Labels) != 0 {
+		t.Errorf("duplicate has %d comments and %d labels, want none", len(duplicate.Comments), len(duplicate.Labels))
+	}
+
+	duplicate = newDuplicate(original, models.DuplicateTaskRequest{
+		Title:              "Next report",
+		IncludeDescription: true,
+		IncludeDeadline:    true,
+		DeadlineOffsetDays: 7,
+	}, userID)
+	if duplicate.Title != "Next report" || duplicate.Description != original.Description {
+		t.Errorf("duplicate = %q %q, want the new title and the description", duplicate.Title, duplicate.Description)
+	}
+	if want := deadline.AddDate(0, 0, 7); duplicate.Deadline == nil || !duplicate.Deadline.Equal(want) {
+		t.Errorf("deadline = %v, want %s", duplicate.Deadline, want)
+	}
+	if len(duplicate.Comments) != 0 {
+		t.Errorf("duplicate has %d comments, want none", len(duplicate.Comments))
+	}
+}
+
+func TestTaskInputIgnoresDuplicatedFrom(t *testing.T) {
+	var task models.Task
+	if err := json.Unmarshal([]byte(`{"title": "Task", "duplicated_from": "`+uuid.NewString()+`"}`), &task); err != nil {
+		t.Fatal(err)
+	}
+	if task.DuplicatedFrom != nil {
+		t.Errorf("duplicated_from = %s, want it ignored", task.DuplicatedFrom)
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/azka-art/taskwise-backend/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	revocationCheckTTL      = 30 * time.Second // How long a token that is not revoked is trusted before checking again
+	revocationCacheSize     = 10000            // Cached tokens before expired entries are swept
+	tokenCleanupInterval    = time.Hour        // How often expired revocations and tokens are removed
+	revokedTokenGracePeriod = time.Minute      // Clock skew allowed before a revoked token's row is removed
+)
+
+// revocationEntry is the cached revocation state of an access token
+type revocationEntry struct {
+	revoked   bool
+	checkedAt time.Time
+	expiresAt time.Time
+}
+
+// revocationCache keeps the revocation state of recently seen access tokens, so
+// that not every request queries the database. Revocations made by this instance
+// apply at once; those made by other instances within revocationCheckTTL.
+var revocationCache = struct {
+	mu      sync.Mutex
+	entries map[uuid.UUID]revocationEntry
+}{entries: make(map[uuid.UUID]revocationEntry)}
+
+// StartTokenCleanupWorker periodically removes revocations of expired access
+// tokens, expired refresh, password reset and email verification tokens, and
+// abandoned single sign-on attempts
+func StartTokenCleanupWorker() {
+	ctx := repositories.AllOrganizations(context.Background())
+
+	go func() {
+		ticker := time.NewTicker(tokenCleanupInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			now := time.Now()
+			if err := repositories.DeleteExpiredRevokedTokens(ctx, now.Add(-revokedTokenGracePeriod)); err != nil {
+				log.Printf("❌ Failed to remove expired token revocations: %v", err)
+			}
+			if err := repositories.DeleteExpiredRefreshTokens(ctx, now); err != nil {
+				log.Printf("❌ Failed to remove expired refresh tokens: %v", err)
+			}
+			if err := repositories.DeleteExpiredPasswordResetTokens(ctx, now); err != nil {
+				log.Printf("❌ Failed to remove expired password reset tokens: %v", err)
+			}
+			if err := repositories.DeleteExpiredEmailVerificationTokens(ctx, now); err != nil {
+				log.Printf("❌ Failed to remove expired email verification tokens: %v", err)
+			}
+			if err := repositories.DeleteExpiredOIDCLoginStates(ctx, now); err != nil {
+				log.Printf("❌ Failed to remove expired sign-in states: %v", err)
+			}
+		}
+	}()
+}
+
+// AuthenticateToken verifies an access token and checks that it was not revoked
+func AuthenticateToken(ctx context.Context, tokenString string) (models.AccessClaims, error) {
+	mapClaims, err := ValidateToken(tokenString)
+	if err != nil {
+		return models.AccessClaims{}, err
+	}
+
+	claims, err := parseAccessClaims(mapClaims)
+	if err != nil {
+		return models.AccessClaims{}, err
+	}
+
+	// The organization of the token is only trusted once it is known not to be revoked
+	revoked, err := isTokenRevoked(repositories.AllOrganizations(ctx), claims)
+	if err != nil {
+		return models.AccessClaims{}, err
+	}
+	if revoked {
+		return models.AccessClaims{}, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// Logout revokes the current access token and, if given, the refresh token family
+// it was issued with
+func Logout(ctx context.Context, claims models.AccessClaims, refreshToken string) error {
+	if err := repositories.RevokeAccessToken(ctx, &models.RevokedToken{
+		TokenID:   claims.TokenID,
+		UserID:    claims.UserID,
+		ExpiresAt: claims.ExpiresAt,
+	}); err != nil {
+		return err
+	}
+	cacheRevocation(claims.TokenID, true, claims.ExpiresAt)
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	stored, err := repositories.GetRefreshTokenByHash(ctx, utils.HashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	if stored == nil || stored.UserID != claims.UserID {
+		return nil // Nothing of this user's to revoke
+	}
+	return repositories.RevokeRefreshTokenFamily(ctx, stored.FamilyID, time.Now())
+}
+
+// LogoutAll revokes every access and refresh token and every API key of a user,
+// in all of their organizations
+func LogoutAll(ctx context.Context, userID uuid.UUID) error {
+	if err := accountRepository(ctx).RevokeSessions(userID, time.Now()); err != nil {
+		return err
+	}
+
+	// Cached tokens of the user are checked again on their next request
+	revocationCache.mu.Lock()
+	revocationCache.entries = make(map[uuid.UUID]revocationEntry)
+	revocationCache.mu.Unlock()
+	return nil
+}
+
+// parseAccessClaims reads the claims the API relies on from a verified token
+func parseAccessClaims(mapClaims jwt.MapClaims) (models.AccessClaims, error) {
+	invalid := errors.New("invalid token claims")
+
+	// Other kinds of tokens, such as mfa tokens, don't grant access
+	if typ, ok := mapClaims["typ"]; ok && typ != "" {
+		return models.AccessClaims{}, invalid
+	}
+
+	userIDClaim, _ := mapClaims["user_id"].(string)
+	userID, err := uuid.Parse(userIDClaim)
+	if err != nil {
+		return models.AccessClaims{}, invalid
+	}
+
+	organizationIDClaim, _ := mapClaims["org_id"].(string)
+	organizationID, err := uuid.Parse(organizationIDClaim)
+	if err != nil {
+		return models.AccessClaims{}, invalid
+	}
+
+	// Tokens without an ID can't be revoked, so they are not accepted
+	tokenIDClaim, _ := mapClaims["jti"].(string)
+	tokenID, err := uuid.Parse(tokenIDClaim)
+	if err != nil {
+		return models.AccessClaims{}, invalid
+	}
+
+	issuedAt, err := mapClaims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		return models.AccessClaims{}, invalid
+	}
+	expiresAt, err := mapClaims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return models.AccessClaims{}, invalid
+	}
+
+	role, _ := mapClaims["role"].(string)
+	emailVerified, _ := mapClaims["email_verified"].(bool)
+	mfaSetupRequired, _ := mapClaims["mfa_setup"].(bool)
+	return models.AccessClaims{
+		UserID:           userID,
+		OrganizationID:   organizationID,
+		Role:             models.UserRole(role),
+		EmailVerified:    emailVerified,
+		MFASetupRequired: mfaSetupRequired,
+		TokenID:          tokenID,
+		IssuedAt:         issuedAt.Time,
+		ExpiresAt:        expiresAt.Time,
+	}, nil
+}
+
+// isTokenRevoked checks the revocation state of a token, using the cache when possible
+func isTokenRevoked(ctx context.Context, claims models.AccessClaims) (bool, error) {
+	now := time.Now()
+
+	revocationCache.mu.Lock()
+	entry, ok := revocationCache.entries[claims.TokenID]
+	revocationCache.mu.Unlock()
+	if ok && (entry.revoked || now.Sub(entry.checkedAt) < revocationCheckTTL) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := repositories.IsAccessTokenRevoked(ctx, claims.TokenID, claims.UserID, claims.IssuedAt)
+	if err != nil {
+		return false, err
+	}
+	cacheRevocation(claims.TokenID, revoked, claims.ExpiresAt)
+	return revoked, nil
+}
+
+// cacheRevocation stores the revocation state of a token, sweeping stale entries
+// when the cache has grown large
+func cacheRevocation(tokenID uuid.UUID, revoked bool, expiresAt time.Time) {
+	now := time.Now()
+
+	revocationCache.mu.Lock()
+	defer revocationCache.mu.Unlock()
+
+	if len(revocationCache.entries) >= revocationCacheSize {
+		for id, entry := range revocationCache.entries {
+			if now.After(entry.expiresAt) || (!entry.revoked && now.Sub(entry.checkedAt) >= revocationCheckTTL) {
+				delete(revocationCache.entries, id)
+			}
+		}
+	}
+
+	revocationCache.entries[tokenID] = revocationEntry{revoked: revoked, checkedAt: now, expiresAt: expiresAt}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/google/uuid"
+)
+
+// CreateSavedView saves a named task list preset for a user, optionally shared with a project
+func CreateSavedView(ctx context.Context, userID uuid.UUID, role models.UserRole, req models.SavedViewRequest) (models.SavedView, error) {
+	view := models.SavedView{OwnerID: userID}
+	if err := applySavedViewRequest(ctx, &view, req, userID, role); err != nil {
+		return models.SavedView{}, err
+	}
+
+	if err := repositories.CreateSavedView(ctx, &view); err != nil {
+		return models.SavedView{}, err
+	}
+	return view, nil
+}
+
+// GetSavedViews lists the personal views of a user and the views shared with the
+// user's projects, optionally only those of one project
+func GetSavedViews(ctx context.Context, userID uuid.UUID, projectID *uuid.UUID) ([]models.SavedView, error) {
+	return repositories.GetSavedViewsForUser(ctx, userID, projectID)
+}
+
+// GetSavedView loads a view the user is allowed to open
+func GetSavedView(ctx context.Context, id, userID uuid.UUID, role models.UserRole) (*models.SavedView, error) {
+	view, err := repositories.GetSavedViewByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if view == nil {
+		return nil, ErrViewNotFound
+	}
+
+	if view.ProjectID == nil {
+		if view.OwnerID != userID {
+			return nil, ErrViewNotFound
+		}
+		return view, nil
+	}
+
+	canView, err := CanViewProject(ctx, *view.ProjectID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, ErrViewNotFound
+	}
+	return view, nil
+}
+
+// UpdateSavedView changes a view. Shared views can be changed by their owner and
+// by project admins.
+func UpdateSavedView(ctx context.Context, id, userID uuid.UUID, role models.UserRole, req models.SavedViewRequest) (models.SavedView, error) {
+	view, err := getManageableSavedView(ctx, id, userID, role)
+	if err != nil {
+		return models.SavedView{}, err
+	}
+
+	if err := applySavedViewRequest(ctx, view, req, userID, role); err != nil {
+		return models.SavedView{}, err
+	}
+
+	if err := repositories.UpdateSavedView(ctx, view); err != nil {
+		if errors.Is(err, repositories.ErrNotUpdated) {
+			return models.SavedView{}, ErrViewNotFound
+		}
+		return models.SavedView{}, err
+	}
+	return *view, nil
+}
+
+// DeleteSavedView deletes a view. Shared views can be deleted by their owner and
+// by project admins.
+func DeleteSavedView(ctx context.Context, id, userID uuid.UUID, role models.UserRole) error {
+	if _, err := getManageableSavedView(ctx, id, userID, role); err != nil {
+		return err
+	}
+
+	return repositories.DeleteSavedView(ctx, id)
+}
+
+// RunSavedView returns the tasks matching a view for the requesting user, sorted
+// and limited to the view's fields. Filters such as assigned_to_me resolve to
+// whoever runs the view.
+func RunSavedView(ctx context.Context, id, userID uuid.UUID, role models.UserRole) (models.SavedViewTasks, error) {
+	view, err := GetSavedView(ctx, id, userID, role)
+	if err != nil {
+		return models.SavedViewTasks{}, err
+	}
+
+	sort, err := models.ParseTaskSort(view.Sort)
+	if err != nil {
+		return models.SavedViewTasks{}, invalidInput(err)
+	}
+
+	tasks, err := repositories.FindSortedTasks(ctx, view.Filters.ForUser(userID, role), sort)
+	if err != nil {
+		return models.SavedViewTasks{}, err
+	}
+
+	result := models.SavedViewTasks{View: *view, Tasks: make([]map[string]interface{}, 0, len(tasks))}
+	for _, task := range tasks {
+		fields, err := taskFields(task, view.Fields)
+		if err != nil {
+			return models.SavedViewTasks{}, err
+		}
+		result.Tasks = append(result.Tasks, fields)
+	}
+	return result, nil
+}
+
+// applySavedViewRequest copies and validates the requested settings of a view
+func applySavedViewRequest(ctx context.Context, view *models.SavedView, req models.SavedViewRequest, userID uuid.UUID, role models.UserRole) error {
+	if req.ProjectID != nil && !sameUUID(req.ProjectID, view.ProjectID) {
+		canView, err := CanViewProject(ctx, *req.ProjectID, userID, role)
+		if err != nil {
+			return err
+		}
+		if !canView {
+			return ErrForbidden
+		}
+	}
+
+	view.Name = req.Name
+	view.ProjectID = req.ProjectID
+	view.Filters = req.Filters
+	view.Sort = req.Sort
+	view.Fields = req.Fields
+
+	if err := view.Validate(); err != nil {
+		return invalidInput(err)
+	}
+	return nil
+}
+
+// getManageableSavedView loads a view the user may change or delete
+func getManageableSavedView(ctx context.Context, id, userID uuid.UUID, role models.UserRole) (*models.SavedView, error) {
+	view, err := GetSavedView(ctx, id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if view.OwnerID == userID {
+		return view, nil
+	}
+
+	// Only shared views are visible to anyone but their owner
+	canManage, err := CanManageProject(ctx, *view.ProjectID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if !canManage {
+		return nil, ErrForbidden
+	}
+	return view, nil
+}
+
+// taskFields converts a task to its JSON fields, keeping only the given ones
+// (and always the ID). Without fields, every field is kept.
+func taskFields(task models.Task, fields []string) (map[string]interface{}, error) {
+	data, err := json.Marshal(task.ToResponse())
+	if err != nil {
+		return nil, err
+	}
+
+	var all map[string]interface{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return all, nil
+	}
+
+	selected := map[string]interface{}{"id": all["id"]}
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			selected[field] = value
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,424 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/azka-art/taskwise-backend/models"
+	"github.com/azka-art/taskwise-backend/repositories"
+	"github.com/azka-art/taskwise-backend/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	webhookMaxAttempts  = 8                // Give up after this many failed attempts
+	webhookBaseBackoff  = 30 * time.Second // Delay before the first retry, doubled on every retry
+	webhookTimeout      = 10 * time.Second // Timeout of a single delivery request
+	webhookLease        = time.Minute      // How long a claimed delivery is hidden from other workers
+	webhookPollInterval = 5 * time.Second  // How often the worker looks for due deliveries
+	webhookBatchSize    = 20               // Deliveries sent per worker run
+)
+
+// WebhookPayload is the JSON body POSTed to webhook URLs
+type WebhookPayload struct {
+	ID        uuid.UUID        `json:"id"`
+	Event     models.EventType `json:"event"`
+	ProjectID *uuid.UUID       `json:"project_id"`
+	ActorID   *uuid.UUID       `json:"actor_id,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      interface{}      `json:"data"`
+}
+
+var (
+	webhookWake = make(chan struct{}, 1)
+
+	errWebhookAddress = errors.New("webhook URL must point to a public address")
+
+	// webhookClient only connects to public addresses. The address is checked
+	// after DNS resolution, so redirects and rebinding DNS cannot reach internal
+	// hosts either.
+	webhookClient = &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: webhookTimeout,
+				Control: func(network, address string, _ syscall.RawConn) error {
+					host, _, err := net.SplitHostPort(address)
+					if err != nil {
+						return err
+					}
+					if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+						return errWebhookAddress
+					}
+					return nil
+				},
+			}).DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+		},
+	}
+)
+
+// StartWebhookWorker subscribes webhooks to published events and starts the
+// background worker that sends and retries deliveries
+func StartWebhookWorker() {
+	SubscribeEvents(enqueueWebhookDeliveries)
+	ctx := repositories.AllOrganizations(context.Background())
+
+	go func() {
+		ticker := time.NewTicker(webhookPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-webhookWake:
+			}
+			processDueWebhookDeliveries(ctx)
+		}
+	}()
+}
+
+// CreateWebhook registers a webhook for a project (project admins only)
+func CreateWebhook(ctx context.Context, projectID, actorID uuid.UUID, actorRole models.UserRole, req models.WebhookRequest) (models.WebhookResponse, error) {
+	if err := requireProjectAdmin(ctx, projectID, actorID, actorRole); err != nil {
+		return models.WebhookResponse{}, err
+	}
+
+	if err := validateWebhookURL(ctx, req.URL); err != nil {
+		return models.WebhookResponse{}, err
+	}
+
+	secret, err := utils.GenerateSecureToken()
+	if err != nil {
+		return models.WebhookResponse{}, err
+	}
+
+	events := make([]string, len(req.Events))
+	for i, e := range req.Events {
+		events[i] = string(e)
+	}
+
+	webhook := models.Webhook{
+		ProjectID: projectID,
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    strings.Join(events, ","),
+		Active:    true,
+		CreatedBy: actorID,
+	}
+	if err := webhook.Validate(); err != nil {
+		return models.WebhookResponse{}, invalidInput(err)
+	}
+
+	if err := repositories.CreateWebhook(ctx, &webhook); err != nil {
+		return models.WebhookResponse{}, err
+	}
+
+	response := toWebhookResponse(webhook)
+	response.Secret = secret // The secret is only shown once
+	return response, nil
+}
+
+// GetWebhooks lists the webhooks of a project (project admins only)
+func GetWebhooks(ctx context.Context, projectID, actorID uuid.UUID, actorRole models.UserRole) ([]models.WebhookResponse, error) {
+	if err := requireProjectAdmin(ctx, projectID, actorID, actorRole); err != nil {
+		return nil, err
+	}
+
+	webhooks, err := repositories.GetWebhooksByProjectID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.WebhookResponse, len(webhooks))
+	for i, w := range webhooks {
+		responses[i] = toWebhookResponse(w)
+	}
+	return responses, nil
+}
+
+// DeleteWebhook removes a webhook from a project (project admins only)
+func DeleteWebhook(ctx context.Context, projectID, webhookID, actorID uuid.UUID, actorRole models.UserRole) error {
+	if _, err := getProjectWebhook(ctx, projectID, webhookID, actorID, actorRole); err != nil {
+		return err
+	}
+
+	return repositories.DeleteWebhook(ctx, webhookID)
+}
+
+// GetWebhookDeliveries returns the delivery log of a webhook (project admins only)
+func GetWebhookDeliveries(ctx context.Context, projectID, webhookID, actorID uuid.UUID, actorRole models.UserRole, page, size int) ([]models.WebhookDelivery, int64, error) {
+	if _, err := getProjectWebhook(ctx, projectID, webhookID, actorID, actorRole); err != nil {
+		return nil, 0, err
+	}
+
+	return repositories.GetPaginatedWebhookDeliveries(ctx, webhookID, page, size)
+}
+
+// RedeliverWebhook queues a new delivery with the payload of an earlier one
+func RedeliverWebhook(ctx context.Context, projectID, webhookID, deliveryID, actorID uuid.UUID, actorRole models.UserRole) (models.WebhookDelivery, error) {
+	if _, err := getProjectWebhook(ctx, projectID, webhookID, actorID, actorRole); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	original, err := repositories.GetWebhookDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	if original == nil || original.WebhookID != webhookID {
+		return models.WebhookDelivery{}, ErrWebhookNotFound
+	}
+
+	now := time.Now()
+	delivery := models.WebhookDelivery{
+		WebhookID:     webhookID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: &now,
+		RedeliveryOf:  &original.ID,
+	}
+
+	if err := repositories.CreateWebhookDelivery(ctx, &delivery); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	wakeWebhookWorker()
+	return delivery, nil
+}
+
+// SignWebhookPayload returns the value of the X-TaskWise-Signature header for a payload
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// getProjectWebhook loads a webhook after checking it belongs to the project and
+// that the user may manage the project
+func getProjectWebhook(ctx context.Context, projectID, webhookID, actorID uuid.UUID, actorRole models.UserRole) (*models.Webhook, error) {
+	if err := requireProjectAdmin(ctx, projectID, actorID, actorRole); err != nil {
+		return nil, err
+	}
+
+	webhook, err := repositories.GetWebhookByID(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if webhook == nil || webhook.ProjectID != projectID {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// enqueueWebhookDeliveries creates a pending delivery for every webhook subscribed to the event
+func enqueueWebhookDeliveries(ctx context.Context, event models.Event) {
+	if event.ProjectID == nil {
+		return
+	}
+
+	webhooks, err := repositories.GetActiveWebhooksByProjectID(ctx, *event.ProjectID)
+	if err != nil {
+		log.Printf("❌ Failed to load webhooks for project %s: %v", event.ProjectID, err)
+		return
+	}
+
+	payload, err := json.Marshal(WebhookPayload{
+		ID:        uuid.New(),
+		Event:     event.Type,
+		ProjectID: event.ProjectID,
+		ActorID:   event.ActorID,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Data,
+	})
+	if err != nil {
+		log.Printf("❌ Failed to encode webhook payload for %s: %v", event.Type, err)
+		return
+	}
+
+	queued := false
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+
+		now := time.Now()
+		delivery := models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			Event:         event.Type,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: &now,
+		}
+		if err := repositories.CreateWebhookDelivery(ctx, &delivery); err != nil {
+			log.Printf("❌ Failed to queue webhook delivery for %s: %v", webhook.ID, err)
+			continue
+		}
+		queued = true
+	}
+
+	if queued {
+		wakeWebhookWorker()
+	}
+}
+
+// wakeWebhookWorker makes the worker look for due deliveries without waiting for the next tick
+func wakeWebhookWorker() {
+	select {
+	case webhookWake <- struct{}{}:
+	default:
+	}
+}
+
+// processDueWebhookDeliveries sends every delivery whose next attempt is due
+func processDueWebhookDeliveries(ctx context.Context) {
+	deliveries, err := repositories.ClaimDueWebhookDeliveries(ctx, webhookBatchSize, webhookLease)
+	if err != nil {
+		log.Printf("❌ Failed to load due webhook deliveries: %v", err)
+		return
+	}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		attemptWebhookDelivery(ctx, delivery)
+
+		if err := repositories.UpdateWebhookDeliveryResult(ctx, delivery); err != nil {
+			log.Printf("❌ Failed to update webhook delivery %s: %v", delivery.ID, err)
+		}
+	}
+}
+
+// attemptWebhookDelivery sends a delivery once and records the outcome on it,
+// scheduling a retry with exponential backoff when it fails
+func attemptWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+	delivery.ResponseCode = 0
+	delivery.Error = ""
+
+	webhook, err := repositories.GetWebhookByID(ctx, delivery.WebhookID)
+	if err != nil {
+		delivery.Error = err.Error()
+		scheduleWebhookRetry(delivery)
+		return
+	}
+	if webhook == nil || !webhook.Active {
+		delivery.Status = models.DeliveryFailed
+		delivery.Error = "webhook was removed or disabled"
+		delivery.NextAttemptAt = nil
+		return
+	}
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Status = models.DeliveryFailed
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = nil
+		return
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TaskWise-Webhook/1.0")
+	req.Header.Set("X-TaskWise-Event", string(delivery.Event))
+	req.Header.Set("X-TaskWise-Delivery", delivery.ID.String())
+	req.Header.Set("X-TaskWise-Signature", SignWebhookPayload(webhook.Secret, body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		delivery.Error = err.Error()
+		scheduleWebhookRetry(delivery)
+		return
+	}
+	// The response body is not kept, so webhooks cannot be used to read other services
+	resp.Body.Close()
+	delivery.ResponseCode = resp.StatusCode
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		delivery.Status = models.DeliverySucceeded
+		delivery.NextAttemptAt = nil
+		return
+	}
+
+	delivery.Error = fmt.Sprintf("webhook responded with status %d", resp.StatusCode)
+	scheduleWebhookRetry(delivery)
+}
+
+// validateWebhookURL checks that a webhook URL is http(s) and that its host only
+// resolves to public addresses
+func validateWebhookURL(ctx context.Context, rawURL string) error {
+	if err := utils.ValidateURL(rawURL); err != nil {
+		return invalidInput(err)
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return invalidInput(errors.New("webhook URL must be an http or https URL"))
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, parsed.Hostname())
+	if err != nil {
+		return invalidInput(fmt.Errorf("cannot resolve webhook host: %s", parsed.Hostname()))
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return invalidInput(errWebhookAddress)
+		}
+	}
+	return nil
+}
+
+// isPublicIP reports if an IP is routable on the internet, excluding loopback,
+// private, link-local (such as the 169.254.169.254 metadata service), shared and
+// unspecified addresses
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		// 0.0.0.0/8 and the 100.64.0.0/10 shared address space of carrier-grade NAT
+		return ip4[0] != 0 && !(ip4[0] == 100 && ip4[1]&0xc0 == 64)
+	}
+	return true
+}
+
+// scheduleWebhookRetry sets the next attempt of a failed delivery, or marks it
+// as failed once all attempts are used up
+func scheduleWebhookRetry(delivery *models.WebhookDelivery) {
+	if delivery.Attempts >= webhookMaxAttempts {
+		delivery.Status = models.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		return
+	}
+
+	backoff := webhookBaseBackoff * time.Duration(math.Pow(2, float64(delivery.Attempts-1)))
+	next := time.Now().Add(backoff)
+	delivery.Status = models.DeliveryPending
+	delivery.NextAttemptAt = &next
+}
+
+// toWebhookResponse converts a webhook to its API representation
+func toWebhookResponse(webhook models.Webhook) models.WebhookResponse {
+	return models.WebhookResponse{
+		ID:        webhook.ID,
+		ProjectID: webhook.ProjectID,
+		URL:       webhook.URL,
+		Events:    webhook.EventList(),
+		Active:    webhook.Active,
+		CreatedBy: webhook.CreatedBy,
+		CreatedAt: webhook.CreatedAt,
+	}
+}
@@ -35,14 +35,6 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
-// GenerateSecureToken generates a cryptographically secure token for various purposes
-// (like password reset, email verification, etc.)
-func GenerateSecureToken() (string, error) {
-	// TODO: Implement secure token generation, possibly using crypto/rand
-	// For now, returning a placeholder
-	return "", errors.New("not implemented")
-}
-
 // CompareHashAndPassword is a wrapper around bcrypt's CompareHashAndPassword
 // that returns a more user-friendly error message
 func CompareHashAndPassword(hashedPassword, password string) error {
@@ -60,3 +52,18 @@ func IsHashedPassword(str string) bool {
 	// bcrypt hashes start with $2a$, $2b$, or $2y$
 	return len(str) == 60 && (str[:4] == "$2a$" || str[:4] == "$2b$" || str[:4] == "$2y$")
 }
+
+// UnusablePasswordHash returns the bcrypt hash of a random password nobody knows,
+// for accounts that sign in another way, such as single sign-on
+func UnusablePasswordHash() (string, error) {
+	password, err := GenerateSecureToken()
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// TaskLinkPrefix is the path task references such as #<task id> link to
+const TaskLinkPrefix = "/tasks/"
+
+// taskRefClass marks the links created for task references
+const taskRefClass = "task-ref"
+
+// markdown converts GitHub flavored Markdown to HTML. Raw HTML in the source is
+// not rendered, and the output is sanitized again by markdownPolicy.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(
+		parser.WithInlineParsers(util.Prioritized(&taskReferenceParser{}, 500)),
+	),
+)
+
+// markdownPolicy is the allowlist of elements and attributes user content may use
+var markdownPolicy = func() *bluemonday.Policy {
+	policy := bluemonday.UGCPolicy()
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).OnElements("code")
+	policy.AllowAttrs("class").Matching(regexp.MustCompile("^" + taskRefClass + "$")).OnElements("a")
+	policy.AllowAttrs("type", "checked", "disabled").OnElements("input") // Task list items
+	policy.RequireNoFollowOnLinks(true)
+	policy.AddTargetBlankToFullyQualifiedLinks(true)
+	return policy
+}()
+
+// RenderMarkdown renders Markdown as sanitized HTML that is safe to embed in a
+// page. References to tasks written as #<task id> become links to the task.
+func RenderMarkdown(source string) string {
+	if source == "" {
+		return ""
+	}
+
+	var html bytes.Buffer
+	if err := markdown.Convert([]byte(source), &html); err != nil {
+		return markdownPolicy.Sanitize(source) // Never happens with a bytes.Buffer
+	}
+	return markdownPolicy.Sanitize(html.String())
+}
+
+// taskReferenceParser turns #<task id> into a link to the task
+type taskReferenceParser struct{}
+
+// Trigger returns the characters that start a task reference
+func (p *taskReferenceParser) Trigger() []byte {
+	return []byte{'#'}
+}
+
+// Parse reads a task reference, leaving the text alone if # is not followed by a task ID
+func (p *taskReferenceParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	const idLength = 36
+	if len(line) < idLength+1 {
+		return nil
+	}
+
+	id, err := uuid.Parse(string(line[1 : idLength+1]))
+	if err != nil {
+		return nil
+	}
+	// Part of a longer word, such as a URL fragment
+	if len(line) > idLength+1 && isWordByte(line[idLength+1]) {
+		return nil
+	}
+
+	link := ast.NewLink()
+	link.Destination = []byte(TaskLinkPrefix + id.String())
+	link.SetAttributeString("class", []byte(taskRefClass))
+	link.AppendChild(link, ast.NewTextSegment(segment.WithStop(segment.Start+idLength+1)))
+
+	block.Advance(idLength + 1)
+	return link
+}
+
+// isWordByte checks if a byte is a letter, digit or underscore
+func isWordByte(b byte) bool {
+	return b == '_' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
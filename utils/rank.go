@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+// rankDigits are the digits of a rank, in ascending order. Ranks are compared
+// byte by byte (COLLATE "C" in PostgreSQL).
+const rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const rankBase = len(rankDigits)
+
+// ErrInvalidRank is returned for ranks with unknown digits or neighbours in the wrong order
+var ErrInvalidRank = errors.New("invalid rank")
+
+// RankBetween returns a rank that sorts strictly between prev and next. An empty
+// prev means the start of the list and an empty next means the end. Ranks never
+// end with the lowest digit, so there is always room for another rank before them.
+//
+// Ranks at either end of the list are stepped by a single digit instead of
+// halving the gap, so repeatedly adding to the top or bottom grows them slowly.
+func RankBetween(prev, next string) (string, error) {
+	if !validRank(prev) || !validRank(next) || (next != "" && prev >= next) {
+		return "", ErrInvalidRank
+	}
+	appending := prev != "" && next == ""
+	prepending := prev == "" && next != ""
+
+	var rank strings.Builder
+	upperOpen := next == "" // Once the rank is below next, only prev bounds it
+	for i := 0; ; i++ {
+		low := 0
+		if i < len(prev) {
+			low = strings.IndexByte(rankDigits, prev[i])
+		}
+
+		high := rankBase
+		if !upperOpen && i < len(next) {
+			high = strings.IndexByte(rankDigits, next[i])
+		}
+
+		if low == high {
+			rank.WriteByte(rankDigits[low])
+			continue
+		}
+
+		mid := (low + high) / 2
+		switch {
+		case appending && low+1 < high:
+			mid = low + 1
+		case prepending && high-1 > low:
+			mid = high - 1
+		}
+		if mid > low {
+			rank.WriteByte(rankDigits[mid])
+			return rank.String(), nil
+		}
+
+		// The digits are adjacent: keep prev's digit and find room in the next position
+		rank.WriteByte(rankDigits[low])
+		upperOpen = true
+	}
+}
+
+// EvenRanks returns n ascending ranks of equal length spread evenly over the
+// whole range, used to rebalance a list whose ranks have grown long
+func EvenRanks(n int) []string {
+	// One digit more than needed leaves a gap of at least rankBase between ranks
+	width := 1
+	for capacity := rankBase; capacity <= n; capacity *= rankBase {
+		width++
+	}
+	width++
+
+	space := 1
+	for i := 0; i < width; i++ {
+		space *= rankBase
+	}
+	step := space / (n + 1)
+
+	ranks := make([]string, n)
+	for i := range ranks {
+		value := step * (i + 1)
+		if value%rankBase == 0 {
+			value++ // Ranks must not end with the lowest digit
+		}
+
+		digits := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			digits[j] = rankDigits[value%rankBase]
+			value /= rankBase
+		}
+		ranks[i] = string(digits)
+	}
+	return ranks
+}
+
+// validRank checks that a rank only uses rank digits and doesn't end with the lowest one
+func validRank(rank string) bool {
+	for i := 0; i < len(rank); i++ {
+		if strings.IndexByte(rankDigits, rank[i]) < 0 {
+			return false
+		}
+	}
+	return !strings.HasSuffix(rank, rankDigits[:1])
+}
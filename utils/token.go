@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// secureTokenBytes is the amount of randomness in tokens from GenerateSecureToken
+const secureTokenBytes = 32
+
+// GenerateSecureToken generates a cryptographically secure token for various purposes
+// (like password reset, email verification, etc.)
+func GenerateSecureToken() (string, error) {
+	b := make([]byte, secureTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken returns the SHA-256 hex digest of a token so it can be stored
+// without keeping the token itself in the database
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}